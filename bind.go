@@ -0,0 +1,294 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	json "github.com/json-iterator/go"
+	"github.com/raoptimus/data-response.go/v2/internal/conv"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// Struct tags used by Bind.
+const (
+	TagPath   = "path"
+	TagQuery  = "query"
+	TagHeader = "header"
+	TagForm   = "form"
+)
+
+// defaultMaxMultipartMemory is the memory limit of multipart form parsing, the rest is stored on disk.
+const defaultMaxMultipartMemory = 32 << 20
+
+// ValidationErrors maps attribute pointers, e.g. "query.limit" or "body.name", to error messages.
+// TypedHandler responds to it with Factory.ValidationError.
+type ValidationErrors map[string][]string
+
+// Add adds the message for the attribute pointer.
+func (e ValidationErrors) Add(pointer, message string) {
+	e[pointer] = append(e[pointer], message)
+}
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	pointers := make([]string, 0, len(e))
+	for pointer := range e {
+		pointers = append(pointers, pointer)
+	}
+	slices.Sort(pointers)
+
+	parts := make([]string, 0, len(pointers))
+	for _, pointer := range pointers {
+		parts = append(parts, pointer+": "+strings.Join(e[pointer], ", "))
+	}
+
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Bind binds the request to v, a pointer to a struct.
+//
+// The body is decoded by Content-Type: JSON (default), XML, or a form into fields tagged "form".
+// Then fields tagged "path", "query" and "header" are set from http.Request.PathValue, the URL query
+// and headers; slice fields take all values. Body is not read if all fields are tagged path, query or header.
+// The body never sets fields tagged path, query or header, they keep their values if the parameter is missing.
+//
+// A malformed body results in *response.Error with 400 or 415 status,
+// invalid parameters are accumulated into ValidationErrors with "path.id" like pointers.
+func Bind(r *http.Request, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a non-nil pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+
+	if hasBodyFields(rv.Type()) {
+		if err := bindBody(r, rv); err != nil {
+			return err
+		}
+	}
+
+	verrs := make(ValidationErrors)
+	if err := bindParams(r, rv, verrs); err != nil {
+		return err
+	}
+
+	if len(verrs) > 0 {
+		return verrs
+	}
+
+	return nil
+}
+
+func bindBody(r *http.Request, rv reflect.Value) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	mediaType := response.ContentTypeJSON
+	if contentType := r.Header.Get(response.HeaderContentType); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return response.WrapError(http.StatusBadRequest, err, "invalid Content-Type")
+		}
+	}
+
+	// Parameters must not be spoofed by the body, e.g. {"UserID": "admin"} for a header field
+	restore := detachParams(rv)
+	defer restore()
+
+	var err error
+	switch {
+	case mediaType == response.ContentTypeJSON || strings.HasSuffix(mediaType, "+json"):
+		err = json.NewDecoder(r.Body).Decode(rv.Addr().Interface())
+	case mediaType == response.ContentTypeXML || mediaType == response.ContentTypeTextXML:
+		err = xml.NewDecoder(r.Body).Decode(rv.Addr().Interface())
+	case mediaType == response.ContentTypeForm:
+		if err := r.ParseForm(); err != nil {
+			return response.WrapError(http.StatusBadRequest, err, "invalid form")
+		}
+
+		return nil
+	case mediaType == response.ContentTypeMultipartForm:
+		if err := r.ParseMultipartForm(defaultMaxMultipartMemory); err != nil {
+			return response.WrapError(http.StatusBadRequest, err, "invalid multipart form")
+		}
+
+		return nil
+	default:
+		return response.NewError(http.StatusUnsupportedMediaType, "unsupported Content-Type "+mediaType)
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return response.WrapError(http.StatusBadRequest, err, "invalid request body")
+	}
+
+	return nil
+}
+
+func bindParams(r *http.Request, rv reflect.Value, verrs ValidationErrors) error {
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && fieldTag(field) == "" {
+			if field.Type.Kind() == reflect.Struct {
+				if err := bindParams(r, fv, verrs); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		source, name := fieldTagSource(field)
+		if source == "" {
+			continue
+		}
+
+		var values []string
+		switch source {
+		case TagPath:
+			if value := r.PathValue(name); value != "" {
+				values = []string{value}
+			}
+		case TagQuery:
+			values = r.URL.Query()[name]
+		case TagHeader:
+			values = r.Header.Values(name)
+		case TagForm:
+			if r.Form == nil {
+				continue
+			}
+			values = r.Form[name]
+		}
+
+		if err := conv.SetValues(fv, values); err != nil {
+			if errors.Is(err, conv.ErrUnsupportedType) {
+				return fmt.Errorf("bind %s.%s: %w", source, name, err)
+			}
+
+			verrs.Add(source+"."+name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// detachParams zeroes fields tagged path, query and header before the body is decoded,
+// the returned function restores their values. Embedded struct pointers allocated by the decoder
+// have their parameter fields zeroed.
+func detachParams(rv reflect.Value) (restore func()) {
+	var fields, saved, embedded []reflect.Value
+
+	var walk func(rv reflect.Value)
+	walk = func(rv reflect.Value) {
+		rt := rv.Type()
+
+		for i := range rt.NumField() {
+			field := rt.Field(i)
+			fv := rv.Field(i)
+
+			switch source := fieldTag(field); {
+			case source == "" && field.Anonymous && field.Type.Kind() == reflect.Struct:
+				walk(fv)
+			case source == "" && field.Anonymous && isStructPointer(field.Type):
+				if fv.IsNil() {
+					embedded = append(embedded, fv)
+				} else {
+					walk(fv.Elem())
+				}
+			case source == "", source == TagForm, !fv.CanSet():
+				continue
+			default:
+				value := reflect.New(fv.Type()).Elem()
+				value.Set(fv)
+				fv.SetZero()
+
+				fields = append(fields, fv)
+				saved = append(saved, value)
+			}
+		}
+	}
+	walk(rv)
+
+	return func() {
+		for i, fv := range fields {
+			fv.Set(saved[i])
+		}
+
+		for _, fv := range embedded {
+			if !fv.IsNil() {
+				detachParams(fv.Elem())
+			}
+		}
+	}
+}
+
+func isStructPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct
+}
+
+// fieldTagSource returns the bind source and the parameter name of the field.
+func fieldTagSource(field reflect.StructField) (source, name string) {
+	for _, tag := range []string{TagPath, TagQuery, TagHeader, TagForm} {
+		if name, ok := field.Tag.Lookup(tag); ok {
+			name, _, _ = strings.Cut(name, ",")
+			if name == "" {
+				name = field.Name
+			}
+
+			return tag, name
+		}
+	}
+
+	return "", ""
+}
+
+func fieldTag(field reflect.StructField) string {
+	source, _ := fieldTagSource(field)
+
+	return source
+}
+
+// hasBodyFields reports whether the struct has fields bound from the body.
+func hasBodyFields(t reflect.Type) bool {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		switch source := fieldTag(field); {
+		case source == TagForm:
+			return true
+		case source != "":
+			continue
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			if hasBodyFields(field.Type) {
+				return true
+			}
+		case field.IsExported() && field.Tag.Get("json") != "-":
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,360 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// bindRequest describes the request to bind.
+type bindRequest struct {
+	method      string
+	target      string
+	contentType string
+	body        string
+	pathValues  map[string]string
+	header      map[string]string
+}
+
+func (br bindRequest) build() *http.Request {
+	method, target := br.method, br.target
+	if method == "" {
+		method = http.MethodPost
+	}
+	if target == "" {
+		target = "/"
+	}
+
+	var body *strings.Reader
+	if br.body != "" {
+		body = strings.NewReader(br.body)
+	}
+
+	var r *http.Request
+	if body != nil {
+		r = httptest.NewRequest(method, target, body)
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+
+	if br.contentType != "" {
+		r.Header.Set(response.HeaderContentType, br.contentType)
+	}
+	for name, value := range br.header {
+		r.Header.Set(name, value)
+	}
+	for name, value := range br.pathValues {
+		r.SetPathValue(name, value)
+	}
+
+	return r
+}
+
+type bindUser struct {
+	ID      int64    `path:"id"`
+	Fields  []string `query:"fields"`
+	Limit   *int     `query:"limit"`
+	TraceID string   `header:"X-Trace-Id"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+type bindParamsOnly struct {
+	ID    int64 `path:"id"`
+	Limit int   `query:"limit"`
+}
+
+type bindTyped struct {
+	Since   time.Time     `query:"since"`
+	Timeout time.Duration `query:"timeout"`
+	IP      netip.Addr    `header:"X-Real-Ip"`
+	Active  bool          `query:"active"`
+	Ratio   float64       `query:"ratio"`
+	Count   uint          `query:"count"`
+}
+
+type bindForm struct {
+	Title string   `form:"title"`
+	Tags  []string `form:"tag"`
+	Page  int      `query:"page"`
+}
+
+type bindAuth struct {
+	UserID string `header:"X-User-Id"`
+	Role   string `query:"role"`
+}
+
+type bindEmbedded struct {
+	bindAuth
+
+	Name string `json:"name"`
+}
+
+type bindEmbeddedPointer struct {
+	*bindAuth
+
+	Name string `json:"name"`
+}
+
+type bindUnsupported struct {
+	Filter map[string]string `query:"filter"`
+}
+
+func TestBind(t *testing.T) {
+	limit := 10
+
+	tests := []struct {
+		name       string
+		req        bindRequest
+		target     func() any
+		want       any
+		wantStatus int                 // Status of *response.Error
+		wantVerrs  map[string][]string // Expected ValidationErrors
+	}{
+		{
+			name: "json body and parameters",
+			req: bindRequest{
+				target:     "/users/42?fields=id&fields=name&limit=10",
+				body:       `{"name":"Alice"}`,
+				pathValues: map[string]string{"id": "42"},
+				header:     map[string]string{"X-Trace-Id": "trace"},
+			},
+			target: func() any { return &bindUser{} },
+			want:   &bindUser{ID: 42, Fields: []string{"id", "name"}, Limit: &limit, TraceID: "trace", Name: "Alice"},
+		},
+		{
+			name:   "json suffix media type",
+			req:    bindRequest{contentType: "application/merge-patch+json", body: `{"name":"Alice"}`},
+			target: func() any { return &bindUser{} },
+			want:   &bindUser{Name: "Alice"},
+		},
+		{
+			name:   "xml body",
+			req:    bindRequest{contentType: "application/xml; charset=utf-8", body: `<user><name>Alice</name></user>`},
+			target: func() any { return &bindUser{} },
+			want:   &bindUser{Name: "Alice"},
+		},
+		{
+			name:   "empty body",
+			req:    bindRequest{method: http.MethodGet, target: "/?limit=10"},
+			target: func() any { return &bindUser{} },
+			want:   &bindUser{Limit: &limit},
+		},
+		{
+			name:   "missing parameters keep values",
+			req:    bindRequest{method: http.MethodGet},
+			target: func() any { return &bindParamsOnly{ID: 1, Limit: 20} },
+			want:   &bindParamsOnly{ID: 1, Limit: 20},
+		},
+		{
+			name:   "body is not read without body fields",
+			req:    bindRequest{target: "/?limit=5", body: `not json`},
+			target: func() any { return &bindParamsOnly{} },
+			want:   &bindParamsOnly{Limit: 5},
+		},
+		{
+			name: "typed parameters",
+			req: bindRequest{
+				method: http.MethodGet,
+				target: "/?since=2026-01-02T03:04:05Z&timeout=1m30s&active=true&ratio=0.5&count=3",
+				header: map[string]string{"X-Real-Ip": "10.0.0.1"},
+			},
+			target: func() any { return &bindTyped{} },
+			want: &bindTyped{
+				Since:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				Timeout: 90 * time.Second,
+				IP:      netip.MustParseAddr("10.0.0.1"),
+				Active:  true,
+				Ratio:   0.5,
+				Count:   3,
+			},
+		},
+		{
+			name: "invalid parameters are accumulated",
+			req: bindRequest{
+				method: http.MethodGet,
+				target: "/?since=yesterday&timeout=long&active=maybe&ratio=half&count=-1",
+				header: map[string]string{"X-Real-Ip": "localhost"},
+			},
+			target: func() any { return &bindTyped{} },
+			wantVerrs: map[string][]string{
+				"query.since":      {"must be a valid RFC 3339 date-time"},
+				"query.timeout":    {"must be a duration"},
+				"header.X-Real-Ip": {"must be a valid Addr"},
+				"query.active":     {"must be a boolean"},
+				"query.ratio":      {"must be a number"},
+				"query.count":      {"must be a non-negative integer"},
+			},
+		},
+		{
+			name:      "invalid path value",
+			req:       bindRequest{method: http.MethodGet, pathValues: map[string]string{"id": "abc"}},
+			target:    func() any { return &bindParamsOnly{} },
+			wantVerrs: map[string][]string{"path.id": {"must be an integer"}},
+		},
+		{
+			name: "url encoded form",
+			req: bindRequest{
+				target:      "/?page=2",
+				contentType: response.ContentTypeForm,
+				body:        "title=Report&tag=a&tag=b",
+			},
+			target: func() any { return &bindForm{} },
+			want:   &bindForm{Title: "Report", Tags: []string{"a", "b"}, Page: 2},
+		},
+		{
+			name:       "malformed json",
+			req:        bindRequest{body: `{"name":`},
+			target:     func() any { return &bindUser{} },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid content type",
+			req:        bindRequest{contentType: "application/json; =", body: `{}`},
+			target:     func() any { return &bindUser{} },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported content type",
+			req:        bindRequest{contentType: "text/csv", body: `name`},
+			target:     func() any { return &bindUser{} },
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "body does not set parameters",
+			req: bindRequest{
+				target: "/users/1",
+				body:   `{"ID":99,"Fields":["secret"],"Limit":1000,"TraceID":"spoofed","name":"Alice"}`,
+			},
+			target: func() any { return &bindUser{} },
+			want:   &bindUser{Name: "Alice"},
+		},
+		{
+			name:   "body does not set parameters of embedded struct",
+			req:    bindRequest{body: `{"UserID":"admin","Role":"root","name":"Alice"}`},
+			target: func() any { return &bindEmbedded{} },
+			want:   &bindEmbedded{Name: "Alice"},
+		},
+		{
+			name:   "body does not set parameters of embedded pointer",
+			req:    bindRequest{body: `{"UserID":"admin","Role":"root","name":"Alice"}`},
+			target: func() any { return &bindEmbeddedPointer{} },
+			// The decoder allocates the embedded struct, its parameter fields stay zero
+			want: &bindEmbeddedPointer{bindAuth: &bindAuth{}, Name: "Alice"},
+		},
+		{
+			name:   "body does not set parameters of allocated embedded pointer",
+			req:    bindRequest{body: `{"UserID":"admin","Role":"root","name":"Alice"}`},
+			target: func() any { return &bindEmbeddedPointer{bindAuth: &bindAuth{}} },
+			want:   &bindEmbeddedPointer{bindAuth: &bindAuth{}, Name: "Alice"},
+		},
+		{
+			name: "parameters of embedded struct",
+			req: bindRequest{
+				target: "/?role=editor",
+				body:   `{"name":"Alice"}`,
+				header: map[string]string{"X-User-Id": "u1"},
+			},
+			target: func() any { return &bindEmbedded{} },
+			want:   &bindEmbedded{bindAuth: bindAuth{UserID: "u1", Role: "editor"}, Name: "Alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target()
+			err := Bind(tt.req.build(), target)
+
+			switch {
+			case tt.wantStatus != 0:
+				var respErr *response.Error
+				if !errors.As(err, &respErr) || respErr.Code() != tt.wantStatus {
+					t.Fatalf("Bind() = %v, want *response.Error with status %d", err, tt.wantStatus)
+				}
+			case tt.wantVerrs != nil:
+				var verrs ValidationErrors
+				if !errors.As(err, &verrs) {
+					t.Fatalf("Bind() = %v, want ValidationErrors", err)
+				}
+				if !reflect.DeepEqual(map[string][]string(verrs), tt.wantVerrs) {
+					t.Errorf("validation errors = %v, want %v", verrs, tt.wantVerrs)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Bind() = %v", err)
+				}
+				if !reflect.DeepEqual(target, tt.want) {
+					t.Errorf("bound = %+v, want %+v", target, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBind_Multipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("title", "Report")
+	_ = mw.WriteField("tag", "a")
+	_ = mw.WriteField("tag", "b")
+	_ = mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/?page=3", &body)
+	r.Header.Set(response.HeaderContentType, mw.FormDataContentType())
+
+	var got bindForm
+	if err := Bind(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := bindForm{Title: "Report", Tags: []string{"a", "b"}, Page: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bound = %+v, want %+v", got, want)
+	}
+}
+
+func TestBind_InvalidTarget(t *testing.T) {
+	var nilUser *bindUser
+
+	tests := []struct {
+		name   string
+		target any
+	}{
+		{name: "struct value", target: bindUser{}},
+		{name: "nil pointer", target: nilUser},
+		{name: "pointer to non-struct", target: new(string)},
+		{name: "unsupported field type", target: &bindUnsupported{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bindRequest{method: http.MethodGet, target: "/?filter=x"}.build()
+
+			err := Bind(r, tt.target)
+			if err == nil {
+				t.Fatal("Bind() = nil, want error")
+			}
+
+			var verrs ValidationErrors
+			if errors.As(err, &verrs) {
+				t.Errorf("Bind() = %v, want a programming error, not ValidationErrors", err)
+			}
+		})
+	}
+}
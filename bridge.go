@@ -0,0 +1,319 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// streamBufferSize is how much a streaming handler may write before the response is attached to the client.
+const streamBufferSize = 64 << 10
+
+// errStreamClosed cancels a streaming handler whose response is closed before it is written.
+var errStreamClosed = errors.New("stream response is closed")
+
+// HTTPHandlerOption configures FromHTTPHandler.
+type HTTPHandlerOption func(o *httpHandlerOptions)
+
+type httpHandlerOptions struct {
+	maxBufferSize int64
+}
+
+// WithMaxBufferSize switches the response to streaming once the handler has written more than size bytes
+// (default: unlimited).
+func WithMaxBufferSize(size int64) HTTPHandlerOption {
+	return func(o *httpHandlerOptions) {
+		o.maxBufferSize = size
+	}
+}
+
+// FromHTTPHandler converts a standard http.Handler (legacy endpoints, pprof, GraphQL servers)
+// to DataResponse Handler, so DataResponse middlewares apply to it.
+//
+// The status, headers and body written by the handler are captured into the response with WithFormatted.
+// A handler that flushes (e.g. server-sent events) is switched to streaming passthrough: the response
+// becomes a stream response with the status and headers written so far, and the rest of the body
+// is written to the client directly as the handler writes and flushes it.
+// A handler that hijacks the connection (e.g. WebSocket) gets a stream response with status 101,
+// the connection is handed over once the response is written.
+//
+// The handler runs in its own goroutine. A streaming handler is blocked after 64 KB written
+// until the response is written, its request context is canceled when the response is closed
+// without being written (e.g. replaced by a middleware) or the request completes.
+func FromHTTPHandler(h http.Handler, opts ...HTTPHandlerOption) Handler {
+	o := &httpHandlerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return HandlerFunc(func(r *http.Request, _ *Factory) *response.DataResponse {
+		bw := newBridgeWriter(o.maxBufferSize)
+		bw.run(h, r)
+
+		return bw.response()
+	})
+}
+
+// FromHTTPHandlerFunc converts a standard http.HandlerFunc to DataResponse Handler.
+func FromHTTPHandlerFunc(fn http.HandlerFunc, opts ...HTTPHandlerOption) Handler {
+	return FromHTTPHandler(fn, opts...)
+}
+
+// bridgeWriter buffers the response of a standard handler running in its own goroutine
+// until the handler returns, flushes or hijacks the connection, then forwards it to the client writer.
+type bridgeWriter struct {
+	mu            sync.Mutex
+	ctx           context.Context
+	cancel        context.CancelCauseFunc
+	header        http.Header
+	handlerHeader http.Header
+	statusCode    int
+	buf           bytes.Buffer
+	maxBufferSize int64
+
+	target    http.ResponseWriter
+	streaming bool
+	hijacked  bool
+	closed    bool
+	panicked  any
+
+	ready    chan struct{}
+	readyOne sync.Once
+	attached chan struct{}
+	done     chan struct{}
+}
+
+func newBridgeWriter(maxBufferSize int64) *bridgeWriter {
+	return &bridgeWriter{
+		handlerHeader: make(http.Header),
+		maxBufferSize: maxBufferSize,
+		ready:         make(chan struct{}),
+		attached:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// run serves the request in a new goroutine and waits until the response is ready,
+// a panic of the handler is propagated unless the response is streaming.
+func (bw *bridgeWriter) run(h http.Handler, r *http.Request) {
+	// Not canceled once the handler returns, the response of next passed through a std middleware
+	// may still depend on it. The context is released with the request one.
+	bw.ctx, bw.cancel = context.WithCancelCause(r.Context())
+
+	go bw.serve(h, r.WithContext(bw.ctx))
+
+	<-bw.ready
+
+	// A streaming handler may still be running, its panic is propagated by attach
+	if !bw.streaming && bw.panicked != nil {
+		panic(bw.panicked)
+	}
+}
+
+func (bw *bridgeWriter) serve(h http.Handler, r *http.Request) {
+	defer close(bw.done)
+	defer func() {
+		if p := recover(); p != nil {
+			bw.mu.Lock()
+			bw.panicked = p
+			bw.mu.Unlock()
+		}
+
+		bw.signal(false)
+	}()
+
+	h.ServeHTTP(bw, r)
+}
+
+// response returns the captured response: buffered, or stream one if the handler flushes or hijacks.
+func (bw *bridgeWriter) response() *response.DataResponse {
+	if bw.streaming {
+		return response.NewDataResponse(bw.statusCode, nil).
+			WithHeaders(bw.header).
+			WithStream(bw.attach).
+			WithCloser(bw)
+	}
+
+	body := bw.buf.Bytes()
+
+	return response.NewDataResponse(bw.statusCode, nil).
+		WithHeaders(withoutFraming(bw.header)).
+		WithFormatted(response.FormattedResponse{
+			Stream:     bytes.NewReader(body),
+			StreamSize: int64(len(body)),
+		})
+}
+
+// withoutFraming returns the captured headers without Content-Length and Transfer-Encoding,
+// Write sets them for the body it writes, e.g. compressed by a middleware.
+func withoutFraming(header http.Header) http.Header {
+	header = header.Clone()
+	header.Del(response.HeaderContentLength)
+	header.Del(response.HeaderTransferEncoding)
+
+	return header
+}
+
+// signal sets the default status and headers if nothing is written yet and releases run once.
+func (bw *bridgeWriter) signal(streaming bool) {
+	bw.readyOne.Do(func() {
+		bw.setStatus(http.StatusOK)
+		bw.streaming = streaming
+
+		close(bw.ready)
+	})
+}
+
+func (bw *bridgeWriter) Header() http.Header {
+	return bw.handlerHeader
+}
+
+func (bw *bridgeWriter) WriteHeader(statusCode int) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	bw.setStatus(statusCode)
+}
+
+func (bw *bridgeWriter) Write(b []byte) (int, error) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	bw.setStatus(http.StatusOK)
+
+	// A streaming handler waits for the client instead of buffering without limit
+	for bw.streaming && bw.target == nil && bw.buf.Len() >= streamBufferSize {
+		bw.mu.Unlock()
+		err := bw.waitAttached()
+		bw.mu.Lock()
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if bw.target != nil {
+		return bw.target.Write(b)
+	}
+
+	n, err := bw.buf.Write(b)
+
+	if bw.maxBufferSize > 0 && int64(bw.buf.Len()) > bw.maxBufferSize {
+		bw.signal(true)
+	}
+
+	return n, err
+}
+
+// setStatus sets the status once and snapshots the headers like http.ResponseWriter does,
+// headers changed afterwards are not sent.
+func (bw *bridgeWriter) setStatus(statusCode int) {
+	if bw.statusCode != 0 {
+		return
+	}
+
+	bw.statusCode = statusCode
+	bw.header = bw.handlerHeader.Clone()
+}
+
+// Flush switches the response to streaming, the client is flushed once it is attached.
+func (bw *bridgeWriter) Flush() {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	if bw.target != nil {
+		_ = http.NewResponseController(bw.target).Flush()
+
+		return
+	}
+
+	bw.signal(true)
+}
+
+// Hijack switches the response to a stream one with status 101 and waits until it is written
+// to take over the client connection.
+func (bw *bridgeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	bw.mu.Lock()
+	if bw.target == nil {
+		if bw.statusCode == 0 {
+			bw.setStatus(http.StatusSwitchingProtocols)
+			bw.hijacked = true
+		}
+
+		bw.signal(true)
+	}
+	bw.mu.Unlock()
+
+	if err := bw.waitAttached(); err != nil {
+		return nil, nil, fmt.Errorf("hijack: %w", err)
+	}
+
+	return http.NewResponseController(bw.target).Hijack()
+}
+
+// waitAttached waits until the stream response is written to the client.
+func (bw *bridgeWriter) waitAttached() error {
+	select {
+	case <-bw.attached:
+		return nil
+	case <-bw.ctx.Done():
+		return fmt.Errorf("response is not written: %w", context.Cause(bw.ctx))
+	}
+}
+
+// Close cancels the handler if the stream response is closed before it is written.
+func (bw *bridgeWriter) Close() error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	if bw.target == nil && !bw.closed {
+		bw.closed = true
+		bw.cancel(errStreamClosed)
+	}
+
+	return nil
+}
+
+// attach writes the buffered body to the client and forwards the rest of it until the handler returns.
+func (bw *bridgeWriter) attach(w http.ResponseWriter) error {
+	var err error
+
+	bw.mu.Lock()
+	if !bw.hijacked {
+		if _, err = w.Write(bw.buf.Bytes()); err == nil {
+			_ = http.NewResponseController(w).Flush()
+		}
+	}
+	bw.buf.Reset()
+	bw.target = w
+	bw.mu.Unlock()
+
+	close(bw.attached)
+
+	if err != nil {
+		return err
+	}
+
+	<-bw.done
+
+	if bw.panicked != nil {
+		panic(bw.panicked)
+	}
+
+	return nil
+}
@@ -0,0 +1,376 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newBridgeFactory() *Factory {
+	return New(WithFormatter(formatter.NewJSON()))
+}
+
+func TestFromHTTPHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		opts        []HTTPHandlerOption
+		wantStatus  int
+		wantHeader  map[string]string
+		wantBody    string
+		wantFlushed bool
+	}{
+		{
+			name: "status headers and body",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set(response.HeaderContentType, "text/csv")
+				w.Header().Set("X-Legacy", "yes")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("id,name\n1,Alice\n"))
+			},
+			wantStatus: http.StatusCreated,
+			wantHeader: map[string]string{response.HeaderContentType: "text/csv", "X-Legacy": "yes"},
+			wantBody:   "id,name\n1,Alice\n",
+		},
+		{
+			name: "implicit status",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:       "empty response",
+			handler:    func(http.ResponseWriter, *http.Request) {},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "first status wins",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "headers set after the body are ignored",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("X-Before", "yes")
+				_, _ = w.Write([]byte("ok"))
+				w.Header().Set("X-After", "yes")
+			},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"X-Before": "yes", "X-After": ""},
+			wantBody:   "ok",
+		},
+		{
+			name: "content length of the handler is set by the writer",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set(response.HeaderContentLength, "2")
+				_, _ = w.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{response.HeaderContentLength: "2"},
+			wantBody:   "ok",
+		},
+		{
+			name: "std error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "legacy failure", http.StatusBadGateway)
+			},
+			wantStatus: http.StatusBadGateway,
+			wantHeader: map[string]string{response.HeaderContentType: "text/plain; charset=utf-8"},
+			wantBody:   "legacy failure\n",
+		},
+		{
+			name: "flush switches to streaming",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set(response.HeaderContentType, "text/event-stream")
+				_, _ = w.Write([]byte("data: 1\n\n"))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte("data: 2\n\n"))
+			},
+			wantStatus:  http.StatusOK,
+			wantHeader:  map[string]string{response.HeaderContentType: "text/event-stream"},
+			wantBody:    "data: 1\n\ndata: 2\n\n",
+			wantFlushed: true,
+		},
+		{
+			name: "max buffer size switches to streaming",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				for range 4 {
+					_, _ = w.Write([]byte("0123456789"))
+				}
+			},
+			opts:        []HTTPHandlerOption{WithMaxBufferSize(15)},
+			wantStatus:  http.StatusOK,
+			wantBody:    strings.Repeat("0123456789", 4),
+			wantFlushed: true,
+		},
+		{
+			name: "body within max buffer size is buffered",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("0123456789"))
+			},
+			opts:       []HTTPHandlerOption{WithMaxBufferSize(15)},
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := WrapHandler(Chain(FromHTTPHandler(tt.handler, tt.opts...), markMiddleware), newBridgeFactory())
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/legacy", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			for name, want := range tt.wantHeader {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Values(response.HeaderContentLength); len(got) > 1 {
+				t.Errorf("Content-Length = %q, want one value", got)
+			}
+			if w.Flushed != tt.wantFlushed {
+				t.Errorf("flushed = %t, want %t", w.Flushed, tt.wantFlushed)
+			}
+			if w.Header().Get("X-Middleware") != "applied" {
+				t.Error("DataResponse middleware is not applied")
+			}
+		})
+	}
+}
+
+func TestFromHTTPHandler_Panic(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "buffered",
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("legacy panic")
+			},
+		},
+		{
+			name: "streaming",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.(http.Flusher).Flush()
+				panic("legacy panic")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := WrapHandler(FromHTTPHandler(tt.handler), newBridgeFactory())
+
+			defer func() {
+				if p := recover(); p != "legacy panic" {
+					t.Errorf("recovered %v, want the handler panic", p)
+				}
+			}()
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+}
+
+func TestFromHTTPHandler_StreamingPassthrough(t *testing.T) {
+	next := make(chan struct{})
+
+	srv := httptest.NewServer(WrapHandler(FromHTTPHandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(response.HeaderContentType, "text/event-stream")
+		for i := range 3 {
+			_, _ = w.Write([]byte("data: " + string(rune('1'+i)) + "\n"))
+			w.(http.Flusher).Flush()
+			<-next
+		}
+	}), newBridgeFactory()))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get(response.HeaderContentType); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want %q", got, "text/event-stream")
+	}
+
+	// Each event is received before the handler writes the next one
+	reader := bufio.NewReader(resp.Body)
+	for i := range 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event %d: %v", i+1, err)
+		}
+		if want := "data: " + string(rune('1'+i)) + "\n"; line != want {
+			t.Errorf("event = %q, want %q", line, want)
+		}
+		next <- struct{}{}
+	}
+
+	if rest, _ := io.ReadAll(reader); len(rest) != 0 {
+		t.Errorf("unexpected trailing body %q", rest)
+	}
+}
+
+func TestFromHTTPHandler_BoundedStreamBuffer(t *testing.T) {
+	const chunkSize = 16 << 10
+
+	var written atomic.Int64
+	handlerDone := make(chan error, 1)
+
+	stream := FromHTTPHandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.(http.Flusher).Flush()
+
+		chunk := make([]byte, chunkSize)
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				handlerDone <- err
+
+				return
+			}
+			written.Add(chunkSize)
+		}
+	})
+
+	// holdMiddleware keeps the stream response unwritten for a while, then replaces it
+	holdMiddleware := func(next Handler) Handler {
+		return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+			resp := next.Handle(r, f)
+			time.Sleep(50 * time.Millisecond)
+			_ = resp.Close()
+
+			return f.Forbidden(r.Context(), "replaced")
+		})
+	}
+
+	w := httptest.NewRecorder()
+	WrapHandler(Chain(stream, holdMiddleware), newBridgeFactory()).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if got := written.Load(); got > streamBufferSize {
+		t.Errorf("handler has written %d bytes before the response is attached, want at most %d", got, streamBufferSize)
+	}
+
+	select {
+	case err := <-handlerDone:
+		if !errors.Is(err, errStreamClosed) {
+			t.Errorf("write error = %v, want %v", err, errStreamClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler is not released when the response is closed")
+	}
+}
+
+func TestFromHTTPHandler_ClosedStreamCancelsHandler(t *testing.T) {
+	cause := make(chan error, 1)
+
+	stream := FromHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		cause <- context.Cause(r.Context())
+	})
+
+	replace := func(next Handler) Handler {
+		return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+			_ = next.Handle(r, f).Close()
+
+			return f.Success(r.Context(), "replaced")
+		})
+	}
+
+	w := httptest.NewRecorder()
+	WrapHandler(Chain(stream, replace), newBridgeFactory()).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.TrimSpace(w.Body.String()); got != `"replaced"` {
+		t.Errorf("body = %s, want %s", got, `"replaced"`)
+	}
+
+	select {
+	case err := <-cause:
+		if !errors.Is(err, errStreamClosed) {
+			t.Errorf("context cause = %v, want %v", err, errStreamClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler context is not canceled")
+	}
+}
+
+func TestFromHTTPHandler_Hijack(t *testing.T) {
+	srv := httptest.NewServer(WrapHandler(Chain(FromHTTPHandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack() error = %v", err)
+
+			return
+		}
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+
+		line, _ := rw.ReadString('\n')
+		_, _ = rw.WriteString(line)
+		_ = rw.Flush()
+	}), markMiddleware), newBridgeFactory()))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, _ = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	_, _ = conn.Write([]byte("ping\n"))
+	if line, err := reader.ReadString('\n'); err != nil || line != "ping\n" {
+		t.Errorf("echo = %q, %v, want %q", line, err, "ping\n")
+	}
+}
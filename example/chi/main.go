@@ -1,19 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
 
-	"github.com/go-chi/chi/v5"
 	json "github.com/json-iterator/go"
 	"github.com/raoptimus/data-response.go/pkg/chiadapter"
 	slogadapter "github.com/raoptimus/data-response.go/pkg/logger/adapter/slog"
 	dr "github.com/raoptimus/data-response.go/v2"
 	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/handler"
 	"github.com/raoptimus/data-response.go/v2/middleware"
+	"github.com/raoptimus/data-response.go/v2/param"
 	"github.com/raoptimus/data-response.go/v2/response"
 )
 
@@ -39,6 +40,12 @@ func main() {
 	// Create chi router with DataResponse support
 	r := chiadapter.NewRouter(factory)
 
+	// Create server with graceful shutdown
+	srv := dr.NewServer(r,
+		dr.WithServerAddr(":8080"),
+		dr.WithServerLogger(factory.Logger()),
+	)
+
 	// Add global middleware
 	r.WithMiddleware(
 		middleware.LoggingDefault(),
@@ -47,11 +54,11 @@ func main() {
 			Level:   middleware.CompressionLevelDefault,
 			MinSize: 1024,
 		}),
-		//dr.WrapMiddleware(
-		//	chimiddleware.BasicAuth("", map[string]string{"user": "pass"}),
-		//),
 	)
 
+	// Readiness fails once the shutdown has begun
+	r.Get("/ready", handler.ReadinessProbe(srv))
+
 	// Health check
 	r.Get("/health", func(r *http.Request, f *dr.Factory) *response.DataResponse {
 		return f.Success(r.Context(), map[string]string{
@@ -78,14 +85,19 @@ func main() {
 		// Admin routes
 		api.Route("/admin", func(admin *chiadapter.Router) {
 			// Admin-specific middleware
-			admin.WithMiddleware(authMiddleware())
+			admin.WithMiddleware(middleware.BasicAuth(middleware.BasicAuthOptions{
+				Realm:       "admin",
+				Credentials: map[string]string{"user": "pass"},
+			}))
 
 			admin.Get("/stats", getStats)
 		})
 	})
 
 	log.Println("Server starting on :8080")
-	http.ListenAndServe(":8080", r)
+	if err := srv.ListenAndServe(context.Background()); err != nil {
+		log.Println(err)
+	}
 }
 
 func listUsers(r *http.Request, f *dr.Factory) *response.DataResponse {
@@ -98,11 +110,10 @@ func listUsers(r *http.Request, f *dr.Factory) *response.DataResponse {
 }
 
 func getUser(r *http.Request, f *dr.Factory) *response.DataResponse {
-	// Get URL parameter using chi adapter
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		return f.BadRequest(r.Context(), "invalid user ID")
+	p := param.From(r)
+	id := param.Path(p, "id", param.Min(1))
+	if resp := p.ErrorResponse(r.Context(), f); resp != nil {
+		return resp
 	}
 
 	if id != 1 {
@@ -126,10 +137,10 @@ func createUser(r *http.Request, f *dr.Factory) *response.DataResponse {
 }
 
 func deleteUser(r *http.Request, f *dr.Factory) *response.DataResponse {
-	idStr := chi.URLParam(r, "id")
-	_, err := strconv.Atoi(idStr)
-	if err != nil {
-		return f.BadRequest(r.Context(), "invalid user ID")
+	p := param.From(r)
+	param.Path(p, "id", param.Min(1))
+	if resp := p.ErrorResponse(r.Context(), f); resp != nil {
+		return resp
 	}
 
 	return f.NoContent(r.Context())
@@ -144,16 +155,3 @@ func getStats(r *http.Request, f *dr.Factory) *response.DataResponse {
 
 	return f.Success(r.Context(), stats)
 }
-
-func authMiddleware() dr.Middleware {
-	return func(next dr.Handler) dr.Handler {
-		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
-			token := r.Header.Get("Authorization")
-			if token != "Bearer secret" {
-				return f.Unauthorized(r.Context(), "invalid token")
-			}
-
-			return next.Handle(r, f)
-		})
-	}
-}
@@ -16,7 +16,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/raoptimus/data-response.go/v2/response"
 )
@@ -174,7 +176,7 @@ func (f *Factory) Error(ctx context.Context, status int, message string) *respon
 
 	data := f.errorBuilder(ctx, status, message, nil)
 
-	return f.CreateDataResponse(http.StatusInternalServerError, data)
+	return f.CreateDataResponse(status, data)
 }
 
 // InternalError creates a 500 Internal Server Error response.
@@ -229,6 +231,16 @@ func (f *Factory) NotFound(ctx context.Context, message string) *response.DataRe
 	return f.Error(ctx, http.StatusNotFound, message)
 }
 
+// MethodNotAllowed creates a 405 Method Not Allowed response with Allow header.
+func (f *Factory) MethodNotAllowed(ctx context.Context, message string, allowed ...string) *response.DataResponse {
+	resp := f.Error(ctx, http.StatusMethodNotAllowed, message)
+	if len(allowed) > 0 {
+		resp.SetHeader(response.HeaderAllow, strings.Join(allowed, ", "))
+	}
+
+	return resp
+}
+
 // Conflict creates a 409 Conflict response.
 func (f *Factory) Conflict(ctx context.Context, message string) *response.DataResponse {
 	return f.Error(ctx, http.StatusConflict, message)
@@ -294,6 +306,17 @@ func (f *Factory) File(ctx context.Context, filename string) *response.DataRespo
 	return resp
 }
 
+// Multipart creates a 200 OK multipart response of the parts,
+// e.g. a JSON manifest and files or sub-responses of a batch request.
+func (f *Factory) Multipart(ctx context.Context, m *response.Multipart) *response.DataResponse {
+	if f.debugMode {
+		f.logger.Debug(ctx, "multipart response", "parts", m.Len(), "content_type", m.ContentType())
+	}
+
+	return response.NewDataResponse(http.StatusOK, nil).
+		WithMultipart(m)
+}
+
 // Formatter returns the current default formatter for this factory.
 //
 //nolint:ireturn,nolintlint // its ok
@@ -325,11 +348,17 @@ func defaultErrorBuilder(_ context.Context, status int, message string, details
 	}
 }
 
-// defaultValidationErrorBuilder creates simple validation error structure.
+// defaultValidationErrorBuilder creates simple validation error structure, errors are ordered by pointer.
 func defaultValidationErrorBuilder(_ context.Context, message string, attributeErrors map[string][]string) any {
+	pointers := make([]string, 0, len(attributeErrors))
+	for k := range attributeErrors {
+		pointers = append(pointers, k)
+	}
+	slices.Sort(pointers)
+
 	errorsData := make(TemplateErrors, 0, len(attributeErrors))
-	for k, v := range attributeErrors {
-		for _, m := range v {
+	for _, k := range pointers {
+		for _, m := range attributeErrors[k] {
 			errorsData = append(errorsData, TemplateError{
 				Pointer: k,
 				Detail:  m,
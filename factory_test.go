@@ -0,0 +1,140 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func TestFactory_Error(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		message     string
+		wantMessage string
+	}{
+		{name: "bad request", status: http.StatusBadRequest, message: "bad input", wantMessage: "bad input"},
+		{name: "forbidden", status: http.StatusForbidden, message: "denied", wantMessage: "denied"},
+		{name: "status text by default", status: http.StatusConflict, wantMessage: "Conflict"},
+		{name: "server error", status: http.StatusBadGateway, message: "upstream", wantMessage: "upstream"},
+	}
+
+	f := New(WithFormatter(formatter.NewJSON()))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := f.Error(context.Background(), tt.status, tt.message)
+			if resp.StatusCode() != tt.status {
+				t.Fatalf("StatusCode() = %d, want %d", resp.StatusCode(), tt.status)
+			}
+
+			w := httptest.NewRecorder()
+			if err := Write(w, resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantMessage) {
+				t.Errorf("body = %q, want message %q", w.Body.String(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestFactory_Multipart(t *testing.T) {
+	tests := []struct {
+		name      string
+		subtype   string
+		build     func(r *http.Request, f *Factory, m *response.Multipart)
+		formatter response.Formatter
+		wantParts []string
+	}{
+		{
+			name:    "manifest and file",
+			subtype: response.MultipartMixed,
+			build: func(r *http.Request, f *Factory, m *response.Multipart) {
+				m.Add(f.Success(r.Context(), map[string]int{"files": 1})).
+					Add(f.Binary(r.Context(), io.NopCloser(strings.NewReader("id\n1\n")), "users.csv", 5))
+			},
+			wantParts: []string{`{"files":1}`, "id\n1\n"},
+		},
+		{
+			name:    "batch of sub-responses",
+			subtype: response.MultipartMixed,
+			build: func(r *http.Request, f *Factory, m *response.Multipart) {
+				m.AddResponse(f.Success(r.Context(), "ok")).
+					AddResponse(f.NotFound(r.Context(), "user not found"))
+			},
+			wantParts: []string{"HTTP/1.1 200 OK", "HTTP/1.1 404 Not Found"},
+		},
+		{
+			name:    "formatter set later keeps the boundary",
+			subtype: response.MultipartFormData,
+			build: func(r *http.Request, f *Factory, m *response.Multipart) {
+				m.AddField("manifest", f.Success(r.Context(), "manifest"))
+			},
+			formatter: formatter.NewXML(),
+			wantParts: []string{`"manifest"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := WrapHandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+				m := response.NewMultipart(tt.subtype)
+				tt.build(r, f, m)
+
+				resp := f.Multipart(r.Context(), m)
+				if tt.formatter != nil {
+					resp = resp.WithFormatter(tt.formatter)
+				}
+
+				return resp
+			}, New(WithFormatter(formatter.NewJSON())))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			mediaType, params, err := mime.ParseMediaType(w.Header().Get(response.HeaderContentType))
+			if err != nil || mediaType != "multipart/"+tt.subtype {
+				t.Fatalf("Content-Type = %q, want multipart/%s", w.Header().Get(response.HeaderContentType), tt.subtype)
+			}
+
+			reader := multipart.NewReader(w.Body, params["boundary"])
+			for i, want := range tt.wantParts {
+				part, err := reader.NextRawPart()
+				if err != nil {
+					t.Fatalf("part %d: %v", i, err)
+				}
+				if body, _ := io.ReadAll(part); !strings.Contains(string(body), want) {
+					t.Errorf("part %d = %q, want containing %q", i, body, want)
+				}
+			}
+			if _, err := reader.NextRawPart(); !errors.Is(err, io.EOF) {
+				t.Errorf("extra part or error after %d parts: %v", len(tt.wantParts), err)
+			}
+		})
+	}
+}
@@ -16,10 +16,26 @@ import (
 	"github.com/raoptimus/data-response.go/v2/response"
 )
 
+// CSPNonceFuncName is the template function name returning the per-request CSP nonce.
+const CSPNonceFuncName = "cspNonce"
+
+// HTMLFuncs returns template functions provided by the HTML formatter.
+// They must be registered before parsing, e.g.:
+//
+//	template.New("page").Funcs(formatter.HTMLFuncs()).Parse(`<script nonce="{{cspNonce}}">`)
+func HTMLFuncs() template.FuncMap {
+	return template.FuncMap{
+		CSPNonceFuncName: func() string { return "" },
+	}
+}
+
 // HTML is an HTML response formatter.
 type HTML struct {
 	response.BaseFormatter
 	template *template.Template
+
+	// base is a never executed copy of template, used to bind per-request functions.
+	base *template.Template
 }
 
 // NewHTML creates a new HTML formatter.
@@ -29,6 +45,13 @@ func NewHTML() *HTML {
 
 func (f *HTML) WithTemplate(tmpl *template.Template) *HTML {
 	f.template = tmpl
+	f.base = nil
+
+	if tmpl != nil && tmpl.Lookup(tmpl.Name()) != nil {
+		if base, err := tmpl.Clone(); err == nil {
+			f.base = base
+		}
+	}
 
 	return f
 }
@@ -42,8 +65,13 @@ func (f *HTML) Format(resp *response.DataResponse) (response.FormattedResponse,
 	var buf bytes.Buffer
 
 	if f.template != nil {
+		tmpl, err := f.bindTemplate(resp)
+		if err != nil {
+			return response.FormattedResponse{}, err
+		}
+
 		// Pass resp.Data() to template
-		if err := f.template.Execute(&buf, resp.Data()); err != nil {
+		if err := tmpl.Execute(&buf, resp.Data()); err != nil {
 			return response.FormattedResponse{}, response.WrapError(errCode500, err, "failed to execute template")
 		}
 	} else {
@@ -59,6 +87,23 @@ func (f *HTML) Format(resp *response.DataResponse) (response.FormattedResponse,
 	}, nil
 }
 
+// bindTemplate returns the template with per-request functions bound to the response.
+func (f *HTML) bindTemplate(resp *response.DataResponse) (*template.Template, error) {
+	nonce := resp.CSPNonce()
+	if nonce == "" || f.base == nil {
+		return f.template, nil
+	}
+
+	tmpl, err := f.base.Clone()
+	if err != nil {
+		return nil, response.WrapError(errCode500, err, "failed to clone template")
+	}
+
+	return tmpl.Funcs(template.FuncMap{
+		CSPNonceFuncName: func() string { return nonce },
+	}), nil
+}
+
 func (f *HTML) defaultTemplate(buf *bytes.Buffer, resp *response.DataResponse) error {
 	dataBytes, err := conv.DataToString(resp.Data())
 	if err != nil {
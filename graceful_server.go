@@ -0,0 +1,323 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultServerAddr              = ":8080"
+	defaultServerReadTimeout       = 30 * time.Second
+	defaultServerReadHeaderTimeout = 10 * time.Second
+	defaultServerWriteTimeout      = 60 * time.Second
+	defaultServerIdleTimeout       = 120 * time.Second
+	defaultServerDrainPeriod       = 5 * time.Second
+	defaultServerShutdownTimeout   = 30 * time.Second
+	defaultShutdownHookTimeout     = 10 * time.Second
+)
+
+var (
+	// ErrServerNotStarted is returned by Server.Ready before the server accepts connections.
+	ErrServerNotStarted = errors.New("server is not started")
+
+	// ErrServerShuttingDown is returned by Server.Ready once the shutdown has begun.
+	ErrServerShuttingDown = errors.New("server is shutting down")
+)
+
+// Server states.
+const (
+	serverStateNew int32 = iota
+	serverStateRunning
+	serverStateDraining
+	serverStateStopped
+)
+
+// ShutdownHook releases a resource on shutdown, e.g. closes a database pool.
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	name    string
+	timeout time.Duration
+	hook    ShutdownHook
+}
+
+// ServerOption configures Server.
+type ServerOption func(s *Server)
+
+// WithServerAddr sets the TCP address to listen on (default: ":8080").
+func WithServerAddr(addr string) ServerOption {
+	return func(s *Server) {
+		s.httpServer.Addr = addr
+	}
+}
+
+// WithServerTimeouts sets http.Server timeouts, zero values keep the defaults.
+func WithServerTimeouts(read, readHeader, write, idle time.Duration) ServerOption {
+	return func(s *Server) {
+		if read > 0 {
+			s.httpServer.ReadTimeout = read
+		}
+		if readHeader > 0 {
+			s.httpServer.ReadHeaderTimeout = readHeader
+		}
+		if write > 0 {
+			s.httpServer.WriteTimeout = write
+		}
+		if idle > 0 {
+			s.httpServer.IdleTimeout = idle
+		}
+	}
+}
+
+// WithDrainPeriod sets how long the server keeps serving with failing readiness
+// before it stops accepting connections, so load balancers can stop routing to it (default: 5s).
+func WithDrainPeriod(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.drainPeriod = d
+	}
+}
+
+// WithShutdownTimeout sets how long in-flight requests are waited for on shutdown (default: 30s).
+func WithShutdownTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+// WithShutdownSignals sets signals starting the graceful shutdown (default: SIGTERM, SIGINT).
+func WithShutdownSignals(signals ...os.Signal) ServerOption {
+	return func(s *Server) {
+		s.signals = signals
+	}
+}
+
+// WithServerLogger sets the lifecycle logger.
+func WithServerLogger(logger Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithHTTPServer customizes the underlying http.Server, e.g. sets TLSConfig or ErrorLog.
+func WithHTTPServer(configure func(srv *http.Server)) ServerOption {
+	return func(s *Server) {
+		configure(s.httpServer)
+	}
+}
+
+// Server runs an http.Handler (ServeMux, chiadapter.Router) with graceful shutdown.
+// On a shutdown signal the readiness fails (so ReadinessProbe(server) returns 503),
+// the server keeps serving during the drain period, then stops accepting connections,
+// waits for in-flight requests and runs the shutdown hooks.
+type Server struct {
+	httpServer      *http.Server
+	logger          Logger
+	drainPeriod     time.Duration
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+
+	state atomic.Int32
+
+	mu           sync.Mutex
+	hooks        []shutdownHook
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewServer creates a new Server with sane timeouts.
+func NewServer(handler http.Handler, opts ...ServerOption) *Server {
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:              defaultServerAddr,
+			Handler:           handler,
+			ReadTimeout:       defaultServerReadTimeout,
+			ReadHeaderTimeout: defaultServerReadHeaderTimeout,
+			WriteTimeout:      defaultServerWriteTimeout,
+			IdleTimeout:       defaultServerIdleTimeout,
+		},
+		logger:          NoOpLogger{},
+		drainPeriod:     defaultServerDrainPeriod,
+		shutdownTimeout: defaultServerShutdownTimeout,
+		signals:         []os.Signal{syscall.SIGTERM, os.Interrupt},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// HTTPServer returns the underlying http.Server.
+func (s *Server) HTTPServer() *http.Server {
+	return s.httpServer
+}
+
+// OnShutdown registers a hook run after the server has stopped, in registration order.
+// Each hook gets its own deadline (default: 10s if timeout is zero).
+func (s *Server) OnShutdown(name string, timeout time.Duration, hook ShutdownHook) *Server {
+	if timeout <= 0 {
+		timeout = defaultShutdownHookTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, shutdownHook{name: name, timeout: timeout, hook: hook})
+
+	return s
+}
+
+// Ready implements handler.ReadinessService, it fails before start and once the shutdown has begun.
+func (s *Server) Ready(_ context.Context) error {
+	switch s.state.Load() {
+	case serverStateRunning:
+		return nil
+	case serverStateNew:
+		return ErrServerNotStarted
+	default:
+		return ErrServerShuttingDown
+	}
+}
+
+// ListenAndServe listens on the configured address and serves until ctx is done
+// or a shutdown signal is received, then shuts down gracefully.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.httpServer.Addr, err)
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on the listener until ctx is done or a shutdown signal is received,
+// then shuts down gracefully. It returns serve and shutdown errors joined.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	if !s.state.CompareAndSwap(serverStateNew, serverStateRunning) {
+		return errors.New("server is already started")
+	}
+
+	if len(s.signals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, s.signals...)
+		defer stop()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info(ctx, "server started", "addr", ln.Addr().String())
+		serveErr <- s.httpServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// The server has failed by itself, nothing to drain
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+
+		return errors.Join(err, s.shutdownOnceWith(context.WithoutCancel(ctx), 0))
+	case <-ctx.Done():
+		s.logger.Info(ctx, "shutdown signal received")
+
+		return s.Shutdown(context.WithoutCancel(ctx))
+	}
+}
+
+// Shutdown fails the readiness, waits the drain period, stops the server waiting for
+// in-flight requests up to the shutdown timeout and runs the shutdown hooks.
+// It may be called once, subsequent calls return the result of the first one.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.shutdownOnceWith(ctx, s.drainPeriod)
+}
+
+func (s *Server) shutdownOnceWith(ctx context.Context, drainPeriod time.Duration) error {
+	s.shutdownOnce.Do(func() {
+		s.shutdownErr = s.shutdown(ctx, drainPeriod)
+	})
+
+	return s.shutdownErr
+}
+
+func (s *Server) shutdown(ctx context.Context, drainPeriod time.Duration) error {
+	s.state.Store(serverStateDraining)
+
+	if drainPeriod > 0 {
+		s.logger.Info(ctx, "draining server", "period", drainPeriod.String())
+
+		timer := time.NewTimer(drainPeriod)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	var errs []error
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		s.logger.Error(ctx, "server shutdown failed", "error", err.Error())
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+
+	s.mu.Lock()
+	hooks := append([]shutdownHook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	for _, h := range hooks {
+		if err := s.runHook(ctx, h); err != nil {
+			s.logger.Error(ctx, "shutdown hook failed", "hook", h.name, "error", err.Error())
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	s.state.Store(serverStateStopped)
+	s.logger.Info(ctx, "server stopped")
+
+	return errors.Join(errs...)
+}
+
+// runHook runs the hook with its deadline, a hook ignoring the deadline is abandoned.
+func (s *Server) runHook(ctx context.Context, h shutdownHook) error {
+	hookCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+
+		done <- h.hook(hookCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-hookCtx.Done():
+		return hookCtx.Err()
+	}
+}
@@ -0,0 +1,367 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServer serves on a random local port, returns the base URL and the Serve result.
+func startServer(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.Serve(ctx, ln)
+	}()
+
+	waitReady(t, s)
+
+	return "http://" + ln.Addr().String(), result
+}
+
+func waitReady(t *testing.T, s *Server) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for s.Ready(t.Context()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("server is not started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blockingHandler signals started and waits for release before responding.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		_, _ = io.WriteString(w, "ok")
+	}
+}
+
+func get(url string) (int, string, error) {
+	resp, err := http.Get(url) //nolint:noctx // Test request
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+
+	return resp.StatusCode, string(body), err
+}
+
+func TestServer_Ready(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s := NewServer(blockingHandler(started, release), WithShutdownSignals(), WithDrainPeriod(time.Hour))
+
+	if err := s.Ready(t.Context()); !errors.Is(err, ErrServerNotStarted) {
+		t.Errorf("Ready() before start = %v, want %v", err, ErrServerNotStarted)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	_, result := startServer(t, ctx, s)
+
+	if err := s.Ready(t.Context()); err != nil {
+		t.Errorf("Ready() while running = %v, want nil", err)
+	}
+
+	shutdownCtx, stopDrain := context.WithCancel(t.Context())
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown(shutdownCtx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for s.state.Load() != serverStateDraining {
+		if time.Now().After(deadline) {
+			t.Fatal("server is not draining")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Ready(t.Context()); !errors.Is(err, ErrServerShuttingDown) {
+		t.Errorf("Ready() while draining = %v, want %v", err, ErrServerShuttingDown)
+	}
+
+	// Canceling the context cuts the drain period short
+	stopDrain()
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() = %v, want nil", err)
+	}
+	if err := s.Ready(t.Context()); !errors.Is(err, ErrServerShuttingDown) {
+		t.Errorf("Ready() after stop = %v, want %v", err, ErrServerShuttingDown)
+	}
+
+	cancel()
+	if err := <-result; err != nil {
+		t.Errorf("Serve() = %v, want nil", err)
+	}
+}
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	const drainPeriod = 100 * time.Millisecond
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s := NewServer(blockingHandler(started, release), WithShutdownSignals(), WithDrainPeriod(drainPeriod))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	url, result := startServer(t, ctx, s)
+
+	type reply struct {
+		status int
+		body   string
+		err    error
+	}
+	inFlight := make(chan reply, 1)
+	go func() {
+		status, body, err := get(url + "/slow")
+		inFlight <- reply{status: status, body: body, err: err}
+	}()
+	<-started
+
+	cancel()
+
+	// New requests are served during the drain period
+	time.Sleep(drainPeriod / 4)
+	if status, _, err := get(url + "/fast"); err != nil || status != http.StatusOK {
+		t.Errorf("request while draining = %d, %v, want %d", status, err, http.StatusOK)
+	}
+
+	// Shutdown waits for the in-flight request
+	select {
+	case err := <-result:
+		t.Fatalf("Serve() returned before the in-flight request completed: %v", err)
+	case <-time.After(2 * drainPeriod):
+	}
+
+	close(release)
+
+	got := <-inFlight
+	if got.err != nil || got.status != http.StatusOK || got.body != "ok" {
+		t.Errorf("in-flight request = %d %q, %v, want %d %q", got.status, got.body, got.err, http.StatusOK, "ok")
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Serve() = %v, want nil", err)
+	}
+
+	// The listener is closed after the shutdown
+	if _, _, err := get(url + "/fast"); err == nil {
+		t.Error("request after shutdown succeeded")
+	}
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	s := NewServer(blockingHandler(started, release),
+		WithShutdownSignals(),
+		WithDrainPeriod(0),
+		WithShutdownTimeout(50*time.Millisecond),
+	)
+
+	url, result := startServer(t, t.Context(), s)
+	go func() {
+		_, _, _ = get(url + "/slow")
+	}()
+	<-started
+
+	err := s.Shutdown(t.Context())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Subsequent calls return the result of the first one
+	if again := s.Shutdown(t.Context()); !errors.Is(again, context.DeadlineExceeded) {
+		t.Errorf("second Shutdown() = %v, want %v", again, context.DeadlineExceeded)
+	}
+
+	// Serve reports the shutdown result as well
+	if err := <-result; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Serve() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestServer_ShutdownHooks(t *testing.T) {
+	errClose := errors.New("close failed")
+
+	tests := []struct {
+		name     string
+		hooks    []ShutdownHook
+		timeouts []time.Duration
+		wantErrs []string
+	}{
+		{
+			name: "success",
+			hooks: []ShutdownHook{
+				func(context.Context) error { return nil },
+				func(context.Context) error { return nil },
+			},
+		},
+		{
+			name: "failed hook does not stop the others",
+			hooks: []ShutdownHook{
+				func(context.Context) error { return errClose },
+				func(context.Context) error { return nil },
+			},
+			wantErrs: []string{"hook0: close failed"},
+		},
+		{
+			name: "hook ignoring the deadline is abandoned",
+			hooks: []ShutdownHook{
+				func(context.Context) error {
+					time.Sleep(time.Second)
+
+					return nil
+				},
+				func(context.Context) error { return nil },
+			},
+			timeouts: []time.Duration{20 * time.Millisecond},
+			wantErrs: []string{"hook0: " + context.DeadlineExceeded.Error()},
+		},
+		{
+			name: "panicking hook",
+			hooks: []ShutdownHook{
+				func(context.Context) error { panic("boom") },
+				func(context.Context) error { return errClose },
+			},
+			wantErrs: []string{"hook0: panic: boom", "hook1: close failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(http.NotFoundHandler(), WithShutdownSignals(), WithDrainPeriod(0))
+
+			var (
+				mu    sync.Mutex
+				order []string
+			)
+			for i, hook := range tt.hooks {
+				name := "hook" + strconv.Itoa(i)
+				var timeout time.Duration
+				if i < len(tt.timeouts) {
+					timeout = tt.timeouts[i]
+				}
+
+				s.OnShutdown(name, timeout, func(ctx context.Context) error {
+					mu.Lock()
+					order = append(order, name)
+					mu.Unlock()
+
+					return hook(ctx)
+				})
+			}
+
+			_, result := startServer(t, t.Context(), s)
+			err := s.Shutdown(t.Context())
+			<-result
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(order) != len(tt.hooks) {
+				t.Fatalf("hooks run = %v, want %d hooks", order, len(tt.hooks))
+			}
+			for i, name := range order {
+				if want := "hook" + strconv.Itoa(i); name != want {
+					t.Errorf("hook %d = %q, want %q", i, name, want)
+				}
+			}
+
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("Shutdown() = %v, want nil", err)
+				}
+
+				return
+			}
+			for _, want := range tt.wantErrs {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("Shutdown() = %v, want containing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	t.Run("already started", func(t *testing.T) {
+		s := NewServer(http.NotFoundHandler(), WithShutdownSignals(), WithDrainPeriod(0))
+		_, result := startServer(t, t.Context(), s)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		if err := s.Serve(t.Context(), ln); err == nil {
+			t.Error("second Serve() = nil, want error")
+		}
+
+		if err := s.Shutdown(t.Context()); err != nil {
+			t.Errorf("Shutdown() = %v", err)
+		}
+		<-result
+	})
+
+	t.Run("closed listener runs hooks without draining", func(t *testing.T) {
+		s := NewServer(http.NotFoundHandler(), WithShutdownSignals(), WithDrainPeriod(time.Hour))
+
+		hookRun := false
+		s.OnShutdown("pool", 0, func(context.Context) error {
+			hookRun = true
+
+			return nil
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln.Close()
+
+		if err := s.Serve(t.Context(), ln); err == nil {
+			t.Error("Serve() = nil, want the accept error")
+		}
+		if !hookRun {
+			t.Error("shutdown hook is not run")
+		}
+	})
+
+	t.Run("listen error", func(t *testing.T) {
+		s := NewServer(http.NotFoundHandler(), WithServerAddr("256.0.0.1:0"))
+
+		if err := s.ListenAndServe(t.Context()); err == nil || !strings.Contains(err.Error(), "listen 256.0.0.1:0") {
+			t.Errorf("ListenAndServe() = %v, want listen error", err)
+		}
+	})
+}
@@ -31,12 +31,12 @@ func (hf HandlerFunc) Handle(r *http.Request, f *Factory) *response.DataResponse
 // WrapHandler converts DataResponse Handler to http.Handler.
 func WrapHandler(h Handler, f *Factory) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rw := &responseRecorder{ResponseWriter: w}
+		rr, rw := newResponseRecorder(w)
 		ctx := response.WithRequestStartTime(r.Context())
 		resp := h.Handle(r.WithContext(ctx), f)
 
 		if err := Write(rw, resp); err != nil {
-			if rw.Written() { // already written
+			if rr.Written() { // already written
 				f.logger.Error(ctx, "failed to write response", "error", err.Error())
 				return
 			}
@@ -46,7 +46,7 @@ func WrapHandler(h Handler, f *Factory) http.Handler {
 				f.logger.Error(ctx, "failed to write error response", "error", err.Error())
 
 				// last chance
-				if !rw.Written() {
+				if !rr.Written() {
 					w.Header().Set(response.HeaderContentType, response.MimeTypePlainText.String())
 					w.WriteHeader(http.StatusInternalServerError)
 					_, _ = w.Write([]byte("Internal Server Error"))
@@ -60,42 +60,3 @@ func WrapHandler(h Handler, f *Factory) http.Handler {
 func WrapHandlerFunc(hf HandlerFunc, f *Factory) http.HandlerFunc {
 	return WrapHandler(hf, f).ServeHTTP
 }
-
-// responseRecorder captures response data.
-type responseRecorder struct {
-	http.ResponseWriter
-	statusCode int
-	written    bool
-}
-
-// WriteHeader captures the status code.
-func (rr *responseRecorder) WriteHeader(statusCode int) {
-	if rr.written {
-		return
-	}
-
-	rr.statusCode = statusCode
-	rr.ResponseWriter.WriteHeader(statusCode)
-	rr.written = true
-}
-
-// Write marks response as written.
-func (rr *responseRecorder) Write(b []byte) (int, error) {
-	if !rr.written {
-		if rr.statusCode == 0 {
-			rr.statusCode = http.StatusOK
-		}
-		rr.WriteHeader(rr.statusCode)
-	}
-
-	return rr.ResponseWriter.Write(b)
-}
-
-// Written returns true if response was written.
-func (rr *responseRecorder) Written() bool {
-	return rr.written
-}
-
-func (rr *responseRecorder) StatusCode() int {
-	return rr.statusCode
-}
@@ -1,19 +1,114 @@
 package handler
 
 import (
+	"encoding/xml"
 	"fmt"
 	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
 
 	dr "github.com/raoptimus/data-response.go/v2"
 	"github.com/raoptimus/data-response.go/v2/response"
 )
 
+// BuildInfoMetricName is the name of the info metric reported by ReportBuildInfo.
+const BuildInfoMetricName = "build_info"
+
+const develVersion = "(devel)"
+
 type VersionData struct {
-	GitCommit string
-	GitBranch string
-	Version   string
-	BuildDate string
-	Name      string
+	XMLName xml.Name `json:"-" xml:"version"`
+
+	GitCommit   string       `json:"gitCommit,omitempty" xml:"gitCommit,omitempty"`
+	GitBranch   string       `json:"gitBranch,omitempty" xml:"gitBranch,omitempty"`
+	GitModified bool         `json:"gitModified,omitempty" xml:"gitModified,omitempty"`
+	Version     string       `json:"version,omitempty" xml:"version,omitempty"`
+	BuildDate   string       `json:"buildDate,omitempty" xml:"buildDate,omitempty"`
+	Name        string       `json:"name,omitempty" xml:"name,omitempty"`
+	GoVersion   string       `json:"goVersion,omitempty" xml:"goVersion,omitempty"`
+	Deps        []ModuleData `json:"deps,omitempty" xml:"deps>module,omitempty"`
+}
+
+// ModuleData is a module dependency of the build.
+type ModuleData struct {
+	Path    string `json:"path" xml:"path"`
+	Version string `json:"version" xml:"version"`
+	Replace string `json:"replace,omitempty" xml:"replace,omitempty"`
+}
+
+// VersionFromBuildInfo creates VersionData from the build info embedded by the Go toolchain.
+func VersionFromBuildInfo(withDeps bool) *VersionData {
+	return (&VersionData{}).WithBuildInfo(withDeps)
+}
+
+// WithBuildInfo fills empty fields from debug.ReadBuildInfo (module path and version, vcs.revision,
+// vcs.time, vcs.modified, Go version), values set via ldflags are kept.
+func (d *VersionData) WithBuildInfo(withDeps bool) *VersionData {
+	if d.GoVersion == "" {
+		d.GoVersion = runtime.Version()
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return d
+	}
+
+	if d.Name == "" {
+		d.Name = info.Main.Path
+	}
+
+	if d.Version == "" && info.Main.Version != develVersion {
+		d.Version = info.Main.Version
+	}
+
+	d.fillSettings(info.Settings)
+
+	if withDeps {
+		d.Deps = make([]ModuleData, 0, len(info.Deps))
+		for _, dep := range info.Deps {
+			module := ModuleData{Path: dep.Path, Version: dep.Version}
+			if dep.Replace != nil {
+				module.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+			}
+			d.Deps = append(d.Deps, module)
+		}
+	}
+
+	return d
+}
+
+// fillSettings fills empty fields from the vcs build settings, GitModified set via ldflags is kept.
+func (d *VersionData) fillSettings(settings []debug.BuildSetting) {
+	for _, setting := range settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if d.GitCommit == "" {
+				d.GitCommit = setting.Value
+			}
+		case "vcs.time":
+			if d.BuildDate == "" {
+				d.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			if !d.GitModified {
+				d.GitModified, _ = strconv.ParseBool(setting.Value)
+			}
+		}
+	}
+}
+
+// Labels returns the info metric labels.
+func (d *VersionData) Labels() map[string]string {
+	return map[string]string{
+		"name":       d.Name,
+		"version":    d.Version,
+		"revision":   d.GitCommit,
+		"branch":     d.GitBranch,
+		"build_date": d.BuildDate,
+		"goversion":  d.GoVersion,
+		"modified":   strconv.FormatBool(d.GitModified),
+	}
 }
 
 func (d *VersionData) String() string {
@@ -26,14 +121,36 @@ func (d *VersionData) String() string {
 	)
 }
 
+// InfoMetricsService exposes Prometheus-style info metrics (a gauge with value 1 and labels).
+//
+//go:generate mockery
+type InfoMetricsService interface {
+	Info(name string, labels map[string]string)
+}
+
+// ReportBuildInfo reports the build info metric.
+func ReportBuildInfo(serv InfoMetricsService, data *VersionData) {
+	serv.Info(BuildInfoMetricName, data.Labels())
+}
+
+// AppVersion responds with the version data, its empty fields are filled from the build info
+// (see VersionData.WithBuildInfo), nil data is built from the build info only.
+//
 //nolint:ireturn,nolintlint // its ok
 func AppVersion(data *VersionData) dr.Handler {
 	return AppVersionFunc(data)
 }
 
+// AppVersionFunc is AppVersion returning dr.HandlerFunc.
 func AppVersionFunc(data *VersionData) dr.HandlerFunc {
+	filled := VersionData{}
+	if data != nil {
+		filled = *data
+	}
+	data = filled.WithBuildInfo(false)
+
 	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
-		return f.Success(r.Context(), data.String()).
+		return f.Success(r.Context(), data).
 			WithHeader(response.HeaderXContentTypeOptions, response.ContentTypeOptionsNoSniff)
 	}
 }
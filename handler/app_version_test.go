@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	json "github.com/json-iterator/go"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// infoMetrics records reported info metrics.
+type infoMetrics struct {
+	name   string
+	labels map[string]string
+}
+
+func (m *infoMetrics) Info(name string, labels map[string]string) {
+	m.name = name
+	m.labels = labels
+}
+
+func TestVersionData_WithBuildInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     *VersionData
+		withDeps bool
+		check    func(t *testing.T, d *VersionData)
+	}{
+		{
+			name: "fills go version",
+			data: &VersionData{},
+			check: func(t *testing.T, d *VersionData) {
+				if d.GoVersion != runtime.Version() {
+					t.Errorf("GoVersion = %q, want %q", d.GoVersion, runtime.Version())
+				}
+				if d.Deps != nil {
+					t.Errorf("Deps = %v, want nil", d.Deps)
+				}
+			},
+		},
+		{
+			name: "keeps ldflags values",
+			data: &VersionData{
+				Name:      "orders",
+				Version:   "v1.2.3",
+				GitCommit: "abc123",
+				BuildDate: "2026-01-01",
+				GoVersion: "go1.0",
+			},
+			check: func(t *testing.T, d *VersionData) {
+				want := VersionData{
+					Name:      "orders",
+					Version:   "v1.2.3",
+					GitCommit: "abc123",
+					BuildDate: "2026-01-01",
+					GoVersion: "go1.0",
+				}
+				if d.Name != want.Name || d.Version != want.Version || d.GitCommit != want.GitCommit ||
+					d.BuildDate != want.BuildDate || d.GoVersion != want.GoVersion {
+					t.Errorf("data = %+v, want %+v", *d, want)
+				}
+			},
+		},
+		{
+			name:     "with dependencies",
+			data:     &VersionData{},
+			withDeps: true,
+			check: func(t *testing.T, d *VersionData) {
+				for _, dep := range d.Deps {
+					if dep.Path == "github.com/pkg/errors" {
+						if dep.Version == "" {
+							t.Errorf("dependency %q has no version", dep.Path)
+						}
+
+						return
+					}
+				}
+				t.Errorf("Deps = %v, want containing github.com/pkg/errors", d.Deps)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, tt.data.WithBuildInfo(tt.withDeps))
+		})
+	}
+}
+
+func TestVersionData_FillSettings(t *testing.T) {
+	settings := func(modified string) []debug.BuildSetting {
+		return []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "def456"},
+			{Key: "vcs.time", Value: "2026-02-02T00:00:00Z"},
+			{Key: "vcs.modified", Value: modified},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		data     VersionData
+		settings []debug.BuildSetting
+		want     VersionData
+	}{
+		{
+			name:     "fills empty fields",
+			settings: settings("true"),
+			want:     VersionData{GitCommit: "def456", BuildDate: "2026-02-02T00:00:00Z", GitModified: true},
+		},
+		{
+			name:     "keeps ldflags values",
+			data:     VersionData{GitCommit: "abc123", BuildDate: "2026-01-01", GitModified: true},
+			settings: settings("false"),
+			want:     VersionData{GitCommit: "abc123", BuildDate: "2026-01-01", GitModified: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.data.fillSettings(tt.settings)
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("data = %+v, want %+v", tt.data, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportBuildInfo(t *testing.T) {
+	metrics := &infoMetrics{}
+	ReportBuildInfo(metrics, &VersionData{
+		Name:        "orders",
+		Version:     "v1.2.3",
+		GitCommit:   "abc123",
+		GitBranch:   "main",
+		GitModified: true,
+		BuildDate:   "2026-01-01",
+		GoVersion:   "go1.25",
+	})
+
+	want := map[string]string{
+		"name":       "orders",
+		"version":    "v1.2.3",
+		"revision":   "abc123",
+		"branch":     "main",
+		"build_date": "2026-01-01",
+		"goversion":  "go1.25",
+		"modified":   "true",
+	}
+
+	if metrics.name != BuildInfoMetricName {
+		t.Errorf("metric = %q, want %q", metrics.name, BuildInfoMetricName)
+	}
+	if len(metrics.labels) != len(want) {
+		t.Errorf("labels = %v, want %v", metrics.labels, want)
+	}
+	for key, value := range want {
+		if metrics.labels[key] != value {
+			t.Errorf("label %q = %q, want %q", key, metrics.labels[key], value)
+		}
+	}
+}
+
+func TestAppVersion(t *testing.T) {
+	data := &VersionData{
+		Name:      "orders",
+		Version:   "v1.2.3",
+		GitCommit: "abc123",
+		Deps:      []ModuleData{{Path: "github.com/pkg/errors", Version: "v0.9.1"}},
+	}
+
+	tests := []struct {
+		name      string
+		formatter response.Formatter
+		wantBody  []string
+	}{
+		{
+			name:      "json",
+			formatter: formatter.NewJSON(),
+			wantBody: []string{
+				`"name":"orders"`,
+				`"version":"v1.2.3"`,
+				`"gitCommit":"abc123"`,
+				`"deps":[{"path":"github.com/pkg/errors","version":"v0.9.1"}]`,
+			},
+		},
+		{
+			name:      "xml",
+			formatter: formatter.NewXML(),
+			wantBody: []string{
+				"<version>",
+				"<name>orders</name>",
+				"<version>v1.2.3</version>",
+				"<deps><module><path>github.com/pkg/errors</path><version>v0.9.1</version></module></deps>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			f := dr.New(dr.WithFormatter(tt.formatter))
+			dr.WrapHandler(AppVersion(data), f).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get(response.HeaderXContentTypeOptions); got != response.ContentTypeOptionsNoSniff {
+				t.Errorf("X-Content-Type-Options = %q, want %q", got, response.ContentTypeOptionsNoSniff)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("body = %s, want containing %s", w.Body.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestAppVersion_BuildInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     *VersionData
+		wantName string
+	}{
+		{name: "fills empty fields", data: &VersionData{Name: "orders"}, wantName: "orders"},
+		{name: "nil data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(AppVersionFunc(tt.data), httptest.NewRequest(http.MethodGet, "/version", nil))
+
+			var got map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode %q: %v", w.Body.String(), err)
+			}
+			if got["goVersion"] != runtime.Version() {
+				t.Errorf("goVersion = %v, want %q", got["goVersion"], runtime.Version())
+			}
+			if name, _ := got["name"].(string); tt.wantName != "" && name != tt.wantName {
+				t.Errorf("name = %q, want %q", name, tt.wantName)
+			}
+			if _, ok := got["gitBranch"]; ok {
+				t.Errorf("body = %v, want empty fields omitted", got)
+			}
+		})
+	}
+
+	data := &VersionData{Name: "orders"}
+	AppVersionFunc(data)
+	if data.GoVersion != "" {
+		t.Error("AppVersion modifies the given data")
+	}
+}
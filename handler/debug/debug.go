@@ -0,0 +1,92 @@
+// Package debug provides runtime diagnostics endpoints: pprof, expvar and a runtime summary.
+package debug
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	defaultPrefix = "/debug"
+
+	// pprofIndexPrefix is the path prefix pprof.Index expects.
+	pprofIndexPrefix = "/debug/pprof/"
+)
+
+// Options configures the diagnostics handler.
+type Options struct {
+	// Prefix is the path the handler is mounted on (default: "/debug").
+	Prefix string
+
+	// Guards protect all endpoints, e.g. IPAllowlist, Token or auth middlewares
+	// (default: Loopback, only local requests are allowed).
+	Guards []dr.Middleware
+}
+
+// Handler returns the diagnostics handler serving:
+//
+//	{prefix}/pprof/    pprof index and profiles
+//	{prefix}/vars      expvar
+//	{prefix}/runtime   runtime summary formatted by the factory formatter
+//
+// Mount it on the prefix, e.g. router.Mount("/debug", h) for chi or mux.ServeMux.Handle("/debug/", h).
+func Handler(f *dr.Factory, opts Options) http.Handler {
+	if opts.Prefix == "" {
+		opts.Prefix = defaultPrefix
+	}
+	opts.Prefix = strings.TrimSuffix(opts.Prefix, "/")
+
+	if len(opts.Guards) == 0 {
+		opts.Guards = []dr.Middleware{Loopback()}
+	}
+
+	pprofPrefix := opts.Prefix + "/pprof/"
+
+	mux := http.NewServeMux()
+	mux.Handle(pprofPrefix, guard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// pprof.Index resolves profiles by the fixed /debug/pprof/ prefix
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = pprofIndexPrefix + strings.TrimPrefix(r.URL.Path, pprofPrefix)
+		pprof.Index(w, r2)
+	}), f, opts.Guards))
+	mux.Handle(pprofPrefix+"cmdline", guard(http.HandlerFunc(pprof.Cmdline), f, opts.Guards))
+	mux.Handle(pprofPrefix+"profile", guard(http.HandlerFunc(pprof.Profile), f, opts.Guards))
+	mux.Handle(pprofPrefix+"symbol", guard(http.HandlerFunc(pprof.Symbol), f, opts.Guards))
+	mux.Handle(pprofPrefix+"trace", guard(http.HandlerFunc(pprof.Trace), f, opts.Guards))
+	mux.Handle(opts.Prefix+"/vars", guard(expvar.Handler(), f, opts.Guards))
+	mux.Handle(opts.Prefix+"/runtime", dr.WrapHandler(dr.Chain(Summary(), opts.Guards...), f))
+
+	return mux
+}
+
+// guard runs the guards in front of a std handler.
+// The guards wrap a sentinel handler: if it is reached, the std handler serves the request
+// (with the context set by the guards), otherwise the guard response is written.
+func guard(h http.Handler, f *dr.Factory, guards []dr.Middleware) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		passed := response.NewDataResponse(http.StatusOK, nil)
+
+		var passedReq *http.Request
+		sentinel := dr.HandlerFunc(func(r *http.Request, _ *dr.Factory) *response.DataResponse {
+			passedReq = r
+
+			return passed
+		})
+
+		resp := dr.Chain(sentinel, guards...).Handle(r, f)
+		if resp == passed && passedReq != nil {
+			h.ServeHTTP(w, passedReq)
+
+			return
+		}
+
+		dr.WrapHandler(dr.HandlerFunc(func(_ *http.Request, _ *dr.Factory) *response.DataResponse {
+			return resp
+		}), f).ServeHTTP(w, r)
+	})
+}
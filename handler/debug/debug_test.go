@@ -0,0 +1,189 @@
+package debug
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestFactory() *dr.Factory {
+	return dr.New(dr.WithFormatter(formatter.NewJSON()))
+}
+
+func TestHandler(t *testing.T) {
+	const token = "secret"
+
+	tests := []struct {
+		name       string
+		opts       Options
+		target     string
+		remoteAddr string
+		header     map[string]string
+		wantStatus int
+		wantBody   string
+		wantHeader map[string]string
+	}{
+		{
+			name:       "runtime from loopback",
+			target:     "/debug/runtime",
+			remoteAddr: "127.0.0.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   `"goVersion"`,
+			wantHeader: map[string]string{response.HeaderCacheControl: response.CacheControlNoStore},
+		},
+		{
+			name:       "runtime from ipv6 loopback",
+			target:     "/debug/runtime",
+			remoteAddr: "[::1]:1234",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "runtime from remote address",
+			target:     "/debug/runtime",
+			remoteAddr: "10.0.0.1:1234",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "pprof index",
+			target:     "/debug/pprof/",
+			remoteAddr: "127.0.0.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   "goroutine",
+		},
+		{
+			name:       "pprof profile",
+			target:     "/debug/pprof/goroutine?debug=1",
+			remoteAddr: "127.0.0.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   "goroutine profile",
+		},
+		{
+			name:       "pprof from remote address",
+			target:     "/debug/pprof/goroutine?debug=1",
+			remoteAddr: "10.0.0.1:1234",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "pprof cmdline from remote address",
+			target:     "/debug/pprof/cmdline",
+			remoteAddr: "10.0.0.1:1234",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "expvar",
+			target:     "/debug/vars",
+			remoteAddr: "127.0.0.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   `"memstats"`,
+		},
+		{
+			name:       "expvar from remote address",
+			target:     "/debug/vars",
+			remoteAddr: "10.0.0.1:1234",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "custom prefix",
+			opts:       Options{Prefix: "/ops/"},
+			target:     "/ops/pprof/heap?debug=1",
+			remoteAddr: "127.0.0.1:1234",
+			wantStatus: http.StatusOK,
+			wantBody:   "heap profile",
+		},
+		{
+			name:       "token is missing",
+			opts:       Options{Guards: []dr.Middleware{Token(token)}},
+			target:     "/debug/pprof/goroutine?debug=1",
+			remoteAddr: "10.0.0.1:1234",
+			wantStatus: http.StatusUnauthorized,
+			wantHeader: map[string]string{response.HeaderWWWAuthenticate: "Bearer"},
+		},
+		{
+			name:       "token is invalid",
+			opts:       Options{Guards: []dr.Middleware{Token(token)}},
+			target:     "/debug/runtime",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{TokenHeader: "wrong"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "token header",
+			opts:       Options{Guards: []dr.Middleware{Token(token)}},
+			target:     "/debug/pprof/goroutine?debug=1",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{TokenHeader: token},
+			wantStatus: http.StatusOK,
+			wantBody:   "goroutine profile",
+		},
+		{
+			name:       "bearer token",
+			opts:       Options{Guards: []dr.Middleware{Token(token)}},
+			target:     "/debug/runtime",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{response.HeaderAuthorization: "Bearer " + token},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "all guards must pass",
+			opts:       Options{Guards: []dr.Middleware{Loopback(), Token(token)}},
+			target:     "/debug/vars",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{TokenHeader: token},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			Handler(newTestFactory(), tt.opts).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %.200s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+			for name, want := range tt.wantHeader {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGuard_PassesGuardContext(t *testing.T) {
+	type ctxKey struct{}
+
+	withValue := func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			return next.Handle(r.WithContext(context.WithValue(r.Context(), ctxKey{}, "guard")), f)
+		})
+	}
+
+	h := guard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, _ := r.Context().Value(ctxKey{}).(string)
+		_, _ = io.WriteString(w, value)
+	}), newTestFactory(), []dr.Middleware{withValue})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+
+	if w.Body.String() != "guard" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "guard")
+	}
+}
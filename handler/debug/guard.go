@@ -0,0 +1,109 @@
+package debug
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// TokenHeader is the header checked by Token guard in addition to Authorization: Bearer.
+const TokenHeader = "X-Debug-Token"
+
+// IPAllowlist creates a guard allowing requests from the listed IPs or CIDR ranges.
+// It checks http.Request.RemoteAddr, use a real IP middleware in front of it behind a proxy.
+func IPAllowlist(entries ...string) (dr.Middleware, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid CIDR %q", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid IP %q", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return ipGuard(func(addr netip.Addr) bool {
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+// Loopback creates a guard allowing local requests only.
+func Loopback() dr.Middleware {
+	return ipGuard(netip.Addr.IsLoopback)
+}
+
+func ipGuard(allowed func(addr netip.Addr) bool) dr.Middleware {
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			addr, ok := remoteAddr(r)
+			if !ok || !allowed(addr) {
+				f.Logger().Warn(r.Context(), "debug endpoint access denied",
+					"remote_addr", r.RemoteAddr,
+					"path", r.URL.Path,
+				)
+
+				return f.Forbidden(r.Context(), "access denied")
+			}
+
+			return next.Handle(r, f)
+		})
+	}
+}
+
+// Token creates a guard requiring the token in Authorization: Bearer or X-Debug-Token header.
+func Token(token string) dr.Middleware {
+	expected := sha256.Sum256([]byte(token))
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			given := r.Header.Get(TokenHeader)
+			if given == "" {
+				given, _ = strings.CutPrefix(r.Header.Get(response.HeaderAuthorization), "Bearer ")
+			}
+
+			givenHash := sha256.Sum256([]byte(given))
+			if given == "" || subtle.ConstantTimeCompare(givenHash[:], expected[:]) != 1 {
+				return f.Unauthorized(r.Context(), "invalid debug token").
+					SetHeader(response.HeaderWWWAuthenticate, "Bearer")
+			}
+
+			return next.Handle(r, f)
+		})
+	}
+}
+
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
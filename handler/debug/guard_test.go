@@ -0,0 +1,74 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func TestIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    []string
+		remoteAddr string
+		want       bool
+	}{
+		{name: "exact ip", entries: []string{"10.0.0.1"}, remoteAddr: "10.0.0.1:1234", want: true},
+		{name: "other ip", entries: []string{"10.0.0.1"}, remoteAddr: "10.0.0.2:1234", want: false},
+		{name: "cidr", entries: []string{"10.0.0.0/8"}, remoteAddr: "10.20.30.40:1234", want: true},
+		{name: "outside cidr", entries: []string{"10.0.0.0/8"}, remoteAddr: "11.0.0.1:1234", want: false},
+		{name: "unmasked cidr", entries: []string{"192.168.1.7/24"}, remoteAddr: "192.168.1.200:1234", want: true},
+		{name: "ipv6", entries: []string{"2001:db8::/32"}, remoteAddr: "[2001:db8::1]:1234", want: true},
+		{name: "ipv4 mapped ipv6", entries: []string{"10.0.0.1"}, remoteAddr: "[::ffff:10.0.0.1]:1234", want: true},
+		{name: "address without port", entries: []string{"10.0.0.1"}, remoteAddr: "10.0.0.1", want: true},
+		{name: "invalid remote address", entries: []string{"10.0.0.0/8"}, remoteAddr: "unknown", want: false},
+		{name: "empty allowlist", remoteAddr: "127.0.0.1:1234", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := IPAllowlist(tt.entries...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			r.RemoteAddr = tt.remoteAddr
+
+			w := httptest.NewRecorder()
+			dr.WrapHandler(m(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+				return f.Success(r.Context(), "ok")
+			})), newTestFactory()).ServeHTTP(w, r)
+
+			wantStatus := http.StatusForbidden
+			if tt.want {
+				wantStatus = http.StatusOK
+			}
+			if w.Code != wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, wantStatus)
+			}
+		})
+	}
+}
+
+func TestIPAllowlist_InvalidEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+	}{
+		{name: "ip", entry: "10.0.0.256"},
+		{name: "cidr", entry: "10.0.0.0/33"},
+		{name: "hostname", entry: "localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := IPAllowlist(tt.entry); err == nil {
+				t.Errorf("IPAllowlist(%q) error = nil", tt.entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package debug
+
+import (
+	"encoding/xml"
+	"net/http"
+	"runtime"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+var startedAt = time.Now()
+
+// RuntimeSummary is a snapshot of the Go runtime state.
+type RuntimeSummary struct {
+	XMLName xml.Name `json:"-" xml:"runtime"`
+
+	GoVersion  string        `json:"goVersion" xml:"goVersion"`
+	GOOS       string        `json:"goos" xml:"goos"`
+	GOARCH     string        `json:"goarch" xml:"goarch"`
+	NumCPU     int           `json:"numCpu" xml:"numCpu"`
+	GOMAXPROCS int           `json:"gomaxprocs" xml:"gomaxprocs"`
+	Goroutines int           `json:"goroutines" xml:"goroutines"`
+	NumCgoCall int64         `json:"numCgoCall" xml:"numCgoCall"`
+	StartedAt  time.Time     `json:"startedAt" xml:"startedAt"`
+	Uptime     string        `json:"uptime" xml:"uptime"`
+	Memory     MemorySummary `json:"memory" xml:"memory"`
+	GC         GCSummary     `json:"gc" xml:"gc"`
+}
+
+// MemorySummary is a subset of runtime.MemStats in bytes.
+type MemorySummary struct {
+	Alloc        uint64 `json:"alloc" xml:"alloc"`
+	TotalAlloc   uint64 `json:"totalAlloc" xml:"totalAlloc"`
+	Sys          uint64 `json:"sys" xml:"sys"`
+	HeapAlloc    uint64 `json:"heapAlloc" xml:"heapAlloc"`
+	HeapSys      uint64 `json:"heapSys" xml:"heapSys"`
+	HeapIdle     uint64 `json:"heapIdle" xml:"heapIdle"`
+	HeapInuse    uint64 `json:"heapInuse" xml:"heapInuse"`
+	HeapReleased uint64 `json:"heapReleased" xml:"heapReleased"`
+	HeapObjects  uint64 `json:"heapObjects" xml:"heapObjects"`
+	StackInuse   uint64 `json:"stackInuse" xml:"stackInuse"`
+	Mallocs      uint64 `json:"mallocs" xml:"mallocs"`
+	Frees        uint64 `json:"frees" xml:"frees"`
+}
+
+// GCSummary describes the garbage collector state.
+type GCSummary struct {
+	NumGC         uint32    `json:"numGc" xml:"numGc"`
+	NumForcedGC   uint32    `json:"numForcedGc" xml:"numForcedGc"`
+	NextGC        uint64    `json:"nextGc" xml:"nextGc"`
+	LastGC        time.Time `json:"lastGc" xml:"lastGc"`
+	LastPause     string    `json:"lastPause" xml:"lastPause"`
+	PauseTotal    string    `json:"pauseTotal" xml:"pauseTotal"`
+	GCCPUFraction float64   `json:"gcCpuFraction" xml:"gcCpuFraction"`
+}
+
+// ReadRuntimeSummary collects the runtime summary.
+// It calls runtime.ReadMemStats, which stops the world briefly.
+func ReadRuntimeSummary() *RuntimeSummary {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	summary := &RuntimeSummary{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		NumCgoCall: runtime.NumCgoCall(),
+		StartedAt:  startedAt,
+		Uptime:     time.Since(startedAt).Round(time.Second).String(),
+		Memory: MemorySummary{
+			Alloc:        mem.Alloc,
+			TotalAlloc:   mem.TotalAlloc,
+			Sys:          mem.Sys,
+			HeapAlloc:    mem.HeapAlloc,
+			HeapSys:      mem.HeapSys,
+			HeapIdle:     mem.HeapIdle,
+			HeapInuse:    mem.HeapInuse,
+			HeapReleased: mem.HeapReleased,
+			HeapObjects:  mem.HeapObjects,
+			StackInuse:   mem.StackInuse,
+			Mallocs:      mem.Mallocs,
+			Frees:        mem.Frees,
+		},
+		GC: GCSummary{
+			NumGC:         mem.NumGC,
+			NumForcedGC:   mem.NumForcedGC,
+			NextGC:        mem.NextGC,
+			PauseTotal:    time.Duration(mem.PauseTotalNs).String(),
+			GCCPUFraction: mem.GCCPUFraction,
+		},
+	}
+
+	if mem.NumGC > 0 {
+		summary.GC.LastGC = time.Unix(0, int64(mem.LastGC))
+		summary.GC.LastPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256]).String()
+	}
+
+	return summary
+}
+
+// Summary returns the runtime summary formatted by the factory formatter.
+func Summary() dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return f.Success(r.Context(), ReadRuntimeSummary()).
+			WithCacheControl(response.CacheControlNoStore)
+	}
+}
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	defaultHealthCheckTimeout = 5 * time.Second
+	healthObservedUnit        = "ms"
+)
+
+// HealthStatus is a status of the health report (application/health+json draft).
+type HealthStatus string
+
+const (
+	// HealthPass means healthy.
+	HealthPass HealthStatus = "pass"
+
+	// HealthWarn means healthy with concerns, e.g. a non-critical check failed (degraded).
+	HealthWarn HealthStatus = "warn"
+
+	// HealthFail means unhealthy.
+	HealthFail HealthStatus = "fail"
+)
+
+// HealthCheckOptions configures a single check.
+type HealthCheckOptions struct {
+	// Timeout is the check deadline (default: HealthCheckerOptions.Timeout).
+	Timeout time.Duration
+
+	// Optional marks the check as non-critical, its failure makes the report warn instead of fail.
+	Optional bool
+}
+
+// HealthCheckerOptions configures HealthChecker.
+type HealthCheckerOptions struct {
+	// Timeout is the default check deadline (default: 5s).
+	Timeout time.Duration
+
+	// RefreshInterval is how long results are cached (default: 0, checks run on every request).
+	RefreshInterval time.Duration
+
+	// ServiceID, Version, ReleaseID and Description are reported as is.
+	ServiceID   string
+	Version     string
+	ReleaseID   string
+	Description string
+}
+
+// HealthCheckResult is the result of a single check.
+type HealthCheckResult struct {
+	ComponentID   string       `json:"componentId"`
+	Status        HealthStatus `json:"status"`
+	Critical      bool         `json:"critical"`
+	ObservedValue int64        `json:"observedValue"`
+	ObservedUnit  string       `json:"observedUnit"`
+	Time          time.Time    `json:"time"`
+	Output        string       `json:"output,omitempty"`
+	LastError     string       `json:"lastError,omitempty"`
+	LastErrorTime *time.Time   `json:"lastErrorTime,omitempty"`
+}
+
+// HealthReport is the health report compatible with application/health+json draft.
+type HealthReport struct {
+	Status      HealthStatus                   `json:"status"`
+	Version     string                         `json:"version,omitempty"`
+	ReleaseID   string                         `json:"releaseId,omitempty"`
+	ServiceID   string                         `json:"serviceId,omitempty"`
+	Description string                         `json:"description,omitempty"`
+	Checks      map[string][]HealthCheckResult `json:"checks,omitempty"`
+}
+
+type healthCheck struct {
+	name          string
+	check         LivenessHandleFunc
+	opts          HealthCheckOptions
+	lastError     string
+	lastErrorTime *time.Time
+}
+
+// HealthChecker runs registered checks in parallel and builds the health report.
+type HealthChecker struct {
+	opts HealthCheckerOptions
+
+	mu     sync.Mutex
+	checks []*healthCheck
+
+	reportMu   sync.Mutex
+	report     *HealthReport
+	checkedAt  time.Time
+	refreshing chan struct{} // Closed when the running checks complete, concurrent probes share the run
+}
+
+// NewHealthChecker creates a new health checker.
+func NewHealthChecker(opts HealthCheckerOptions) *HealthChecker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultHealthCheckTimeout
+	}
+
+	return &HealthChecker{opts: opts}
+}
+
+// RegisterFunc registers a check function.
+func (h *HealthChecker) RegisterFunc(name string, check LivenessHandleFunc, opts HealthCheckOptions) *HealthChecker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = h.opts.Timeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, &healthCheck{name: name, check: check, opts: opts})
+
+	return h
+}
+
+// Register registers a liveness service as a check.
+func (h *HealthChecker) Register(name string, serv LivenessService, opts HealthCheckOptions) *HealthChecker {
+	return h.RegisterFunc(name, serv.Alive, opts)
+}
+
+// Check returns the health report, running the checks if the cached report is outdated.
+// The checks run detached from the request cancellation, limited by their timeouts,
+// so a disconnected client does not make them fail. While a cached report is refreshed,
+// the last one is returned.
+func (h *HealthChecker) Check(ctx context.Context) *HealthReport {
+	h.reportMu.Lock()
+
+	if h.report != nil && time.Since(h.checkedAt) < h.opts.RefreshInterval {
+		defer h.reportMu.Unlock()
+
+		return h.report
+	}
+
+	if h.refreshing == nil {
+		h.refreshing = make(chan struct{})
+		go h.refresh(context.WithoutCancel(ctx), h.refreshing)
+	}
+
+	report, refreshing := h.report, h.refreshing
+	h.reportMu.Unlock()
+
+	if report != nil && h.opts.RefreshInterval > 0 {
+		return report
+	}
+
+	<-refreshing
+
+	h.reportMu.Lock()
+	defer h.reportMu.Unlock()
+
+	return h.report
+}
+
+// refresh runs the checks and stores the report.
+func (h *HealthChecker) refresh(ctx context.Context, done chan struct{}) {
+	report := h.run(ctx)
+
+	h.reportMu.Lock()
+	defer h.reportMu.Unlock()
+
+	h.report = report
+	h.checkedAt = time.Now()
+	h.refreshing = nil
+	close(done)
+}
+
+// Alive implements LivenessService, it fails if any critical check fails.
+func (h *HealthChecker) Alive(ctx context.Context) error {
+	report := h.Check(ctx)
+	if report.Status != HealthFail {
+		return nil
+	}
+
+	result := NewDeadStackedErrors()
+	for _, results := range report.Checks {
+		for _, r := range results {
+			if r.Critical && r.Status == HealthFail {
+				result.Add(errors.New(r.ComponentID + ": " + r.Output))
+			}
+		}
+	}
+
+	return result
+}
+
+// run executes all checks in parallel.
+func (h *HealthChecker) run(ctx context.Context) *HealthReport {
+	h.mu.Lock()
+	checks := append([]*healthCheck(nil), h.checks...)
+	h.mu.Unlock()
+
+	results := make([]HealthCheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := &HealthReport{
+		Status:      HealthPass,
+		Version:     h.opts.Version,
+		ReleaseID:   h.opts.ReleaseID,
+		ServiceID:   h.opts.ServiceID,
+		Description: h.opts.Description,
+		Checks:      make(map[string][]HealthCheckResult, len(checks)),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, check := range checks {
+		result := results[i]
+
+		if result.Status == HealthFail {
+			check.lastError = result.Output
+			check.lastErrorTime = &result.Time
+
+			if check.opts.Optional {
+				result.Status = HealthWarn
+			}
+		}
+		result.LastError = check.lastError
+		result.LastErrorTime = check.lastErrorTime
+
+		switch {
+		case result.Status == HealthFail:
+			report.Status = HealthFail
+		case result.Status == HealthWarn && report.Status == HealthPass:
+			report.Status = HealthWarn
+		}
+
+		report.Checks[check.name] = append(report.Checks[check.name], result)
+	}
+
+	return report
+}
+
+// runHealthCheck runs the check with its deadline, a check ignoring the deadline is abandoned.
+func runHealthCheck(ctx context.Context, check *healthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+
+		done <- check.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "check timed out")
+	}
+
+	result := HealthCheckResult{
+		ComponentID:   check.name,
+		Status:        HealthPass,
+		Critical:      !check.opts.Optional,
+		ObservedValue: time.Since(start).Milliseconds(),
+		ObservedUnit:  healthObservedUnit,
+		Time:          start.UTC(),
+	}
+
+	if err != nil {
+		result.Status = HealthFail
+		result.Output = err.Error()
+	}
+
+	return result
+}
+
+// HealthProbe returns the health report, 200 OK if it passes or warns, 503 Service Unavailable if it fails.
+// The report is formatted by the factory formatter, JSON is sent as application/health+json.
+func HealthProbe(checker *HealthChecker) dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		report := checker.Check(r.Context())
+
+		statusCode := http.StatusOK
+		if report.Status == HealthFail {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		resp := f.CreateDataResponse(statusCode, report).
+			WithCacheControl(response.CacheControlNoStore)
+
+		if resp.ContentType() == response.ContentTypeJSON {
+			resp.WithContentType(response.ContentTypeHealthJSON)
+		}
+
+		return resp
+	}
+}
@@ -0,0 +1,420 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestFactory() *dr.Factory {
+	return dr.New(dr.WithFormatter(formatter.NewJSON()))
+}
+
+// serve runs the request through the handler.
+func serve(h dr.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	dr.WrapHandler(h, newTestFactory()).ServeHTTP(w, r)
+
+	return w
+}
+
+// testCheck is a check registered in HealthChecker.
+type testCheck struct {
+	name  string
+	check LivenessHandleFunc
+	opts  HealthCheckOptions
+}
+
+func passingCheck(context.Context) error { return nil }
+
+func failingCheck(context.Context) error { return errors.New("connection refused") }
+
+func TestHealthProbe(t *testing.T) {
+	tests := []struct {
+		name         string
+		checks       []testCheck
+		wantStatus   int
+		wantReport   HealthStatus
+		wantChecks   map[string]HealthStatus
+		wantOutput   map[string]string
+		wantCritical map[string]bool
+	}{
+		{
+			name:       "no checks",
+			wantStatus: http.StatusOK,
+			wantReport: HealthPass,
+		},
+		{
+			name: "all checks pass",
+			checks: []testCheck{
+				{name: "db", check: passingCheck},
+				{name: "cache", check: passingCheck, opts: HealthCheckOptions{Optional: true}},
+			},
+			wantStatus:   http.StatusOK,
+			wantReport:   HealthPass,
+			wantChecks:   map[string]HealthStatus{"db": HealthPass, "cache": HealthPass},
+			wantCritical: map[string]bool{"db": true, "cache": false},
+		},
+		{
+			name: "critical check fails",
+			checks: []testCheck{
+				{name: "db", check: failingCheck},
+				{name: "cache", check: passingCheck, opts: HealthCheckOptions{Optional: true}},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: HealthFail,
+			wantChecks: map[string]HealthStatus{"db": HealthFail, "cache": HealthPass},
+			wantOutput: map[string]string{"db": "connection refused"},
+		},
+		{
+			name: "optional check fails",
+			checks: []testCheck{
+				{name: "db", check: passingCheck},
+				{name: "cache", check: failingCheck, opts: HealthCheckOptions{Optional: true}},
+			},
+			wantStatus: http.StatusOK,
+			wantReport: HealthWarn,
+			wantChecks: map[string]HealthStatus{"db": HealthPass, "cache": HealthWarn},
+			wantOutput: map[string]string{"cache": "connection refused"},
+		},
+		{
+			name: "check times out",
+			checks: []testCheck{
+				{
+					name: "db",
+					check: func(context.Context) error {
+						time.Sleep(time.Second)
+
+						return nil
+					},
+					opts: HealthCheckOptions{Timeout: 20 * time.Millisecond},
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: HealthFail,
+			wantChecks: map[string]HealthStatus{"db": HealthFail},
+			wantOutput: map[string]string{"db": "check timed out: context deadline exceeded"},
+		},
+		{
+			name: "check panics",
+			checks: []testCheck{
+				{name: "db", check: func(context.Context) error { panic("boom") }},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: HealthFail,
+			wantChecks: map[string]HealthStatus{"db": HealthFail},
+			wantOutput: map[string]string{"db": "panic: boom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewHealthChecker(HealthCheckerOptions{ServiceID: "orders", Version: "1"})
+			for _, c := range tt.checks {
+				checker.RegisterFunc(c.name, c.check, c.opts)
+			}
+
+			w := serve(HealthProbe(checker), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, response.ContentTypeHealthJSON) {
+				t.Errorf("Content-Type = %q, want %q", got, response.ContentTypeHealthJSON)
+			}
+			if got := w.Header().Get(response.HeaderCacheControl); got != response.CacheControlNoStore {
+				t.Errorf("Cache-Control = %q, want %q", got, response.CacheControlNoStore)
+			}
+
+			var report HealthReport
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decode report %q: %v", w.Body.String(), err)
+			}
+
+			if report.Status != tt.wantReport {
+				t.Errorf("report status = %q, want %q", report.Status, tt.wantReport)
+			}
+			if report.ServiceID != "orders" || report.Version != "1" {
+				t.Errorf("report service = %q %q, want %q %q", report.ServiceID, report.Version, "orders", "1")
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks = %v, want %d", report.Checks, len(tt.wantChecks))
+			}
+
+			for name, want := range tt.wantChecks {
+				results := report.Checks[name]
+				if len(results) != 1 {
+					t.Fatalf("check %q results = %v", name, results)
+				}
+
+				result := results[0]
+				if result.Status != want {
+					t.Errorf("check %q status = %q, want %q", name, result.Status, want)
+				}
+				if result.ComponentID != name || result.ObservedUnit != "ms" {
+					t.Errorf("check %q = %+v", name, result)
+				}
+				if result.Output != tt.wantOutput[name] {
+					t.Errorf("check %q output = %q, want %q", name, result.Output, tt.wantOutput[name])
+				}
+				if critical, ok := tt.wantCritical[name]; ok && result.Critical != critical {
+					t.Errorf("check %q critical = %v, want %v", name, result.Critical, critical)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthChecker_RunsChecksInParallel(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	checker := NewHealthChecker(HealthCheckerOptions{})
+	for _, name := range []string{"db", "cache", "queue"} {
+		checker.RegisterFunc(name, func(context.Context) error {
+			time.Sleep(delay)
+
+			return nil
+		}, HealthCheckOptions{})
+	}
+
+	start := time.Now()
+	report := checker.Check(t.Context())
+
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Errorf("checks took %v, want less than %v", elapsed, 2*delay)
+	}
+	if report.Status != HealthPass || len(report.Checks) != 3 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestHealthChecker_LastError(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+
+	checker := NewHealthChecker(HealthCheckerOptions{})
+	checker.RegisterFunc("db", func(context.Context) error {
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+
+		return nil
+	}, HealthCheckOptions{})
+
+	failed := checker.Check(t.Context()).Checks["db"][0]
+	failing.Store(false)
+	recovered := checker.Check(t.Context()).Checks["db"][0]
+
+	if recovered.Status != HealthPass {
+		t.Errorf("status = %q, want %q", recovered.Status, HealthPass)
+	}
+	if recovered.LastError != "connection refused" {
+		t.Errorf("last error = %q, want %q", recovered.LastError, "connection refused")
+	}
+	if recovered.LastErrorTime == nil || !recovered.LastErrorTime.Equal(failed.Time) {
+		t.Errorf("last error time = %v, want %v", recovered.LastErrorTime, failed.Time)
+	}
+}
+
+func TestHealthChecker_Refresh(t *testing.T) {
+	t.Run("report is cached within the refresh interval", func(t *testing.T) {
+		var calls atomic.Int32
+		checker := NewHealthChecker(HealthCheckerOptions{RefreshInterval: time.Hour})
+		checker.RegisterFunc("db", func(context.Context) error {
+			calls.Add(1)
+
+			return nil
+		}, HealthCheckOptions{})
+
+		for range 3 {
+			checker.Check(t.Context())
+		}
+
+		if got := calls.Load(); got != 1 {
+			t.Errorf("check calls = %d, want 1", got)
+		}
+	})
+
+	t.Run("outdated report is served while refreshing", func(t *testing.T) {
+		const interval = 20 * time.Millisecond
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		checker := NewHealthChecker(HealthCheckerOptions{RefreshInterval: interval})
+		checker.RegisterFunc("db", func(context.Context) error {
+			if calls.Add(1) == 2 {
+				<-release
+			}
+
+			return nil
+		}, HealthCheckOptions{})
+
+		first := checker.Check(t.Context())
+		time.Sleep(2 * interval)
+
+		for range 3 {
+			if got := checker.Check(t.Context()); got != first {
+				t.Error("outdated report is not served while refreshing")
+			}
+		}
+		close(release)
+
+		deadline := time.Now().Add(time.Second)
+		for checker.Check(t.Context()) == first {
+			if time.Now().After(deadline) {
+				t.Fatal("report is not refreshed")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		if got := calls.Load(); got != 2 {
+			t.Errorf("check calls = %d, want 2", got)
+		}
+	})
+
+	t.Run("concurrent probes share the run", func(t *testing.T) {
+		var calls atomic.Int32
+		checker := NewHealthChecker(HealthCheckerOptions{})
+		checker.RegisterFunc("db", func(context.Context) error {
+			calls.Add(1)
+			time.Sleep(50 * time.Millisecond)
+
+			return nil
+		}, HealthCheckOptions{})
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Go(func() {
+				if report := checker.Check(t.Context()); report.Status != HealthPass {
+					t.Errorf("report status = %q, want %q", report.Status, HealthPass)
+				}
+			})
+		}
+		wg.Wait()
+
+		if got := calls.Load(); got != 1 {
+			t.Errorf("check calls = %d, want 1", got)
+		}
+	})
+}
+
+func TestHealthChecker_DetachedFromRequest(t *testing.T) {
+	checker := NewHealthChecker(HealthCheckerOptions{})
+	checker.RegisterFunc("db", func(ctx context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+
+		return ctx.Err()
+	}, HealthCheckOptions{})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	time.AfterFunc(5*time.Millisecond, cancel)
+
+	if report := checker.Check(ctx); report.Status != HealthPass {
+		t.Errorf("report status = %q, want %q: %+v", report.Status, HealthPass, report.Checks)
+	}
+}
+
+func TestHealthChecker_Alive(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  []testCheck
+		wantErr string
+	}{
+		{
+			name: "healthy",
+			checks: []testCheck{
+				{name: "db", check: passingCheck},
+			},
+		},
+		{
+			name: "optional failure is alive",
+			checks: []testCheck{
+				{name: "db", check: passingCheck},
+				{name: "cache", check: failingCheck, opts: HealthCheckOptions{Optional: true}},
+			},
+		},
+		{
+			name: "critical failure",
+			checks: []testCheck{
+				{name: "db", check: failingCheck},
+				{name: "cache", check: failingCheck, opts: HealthCheckOptions{Optional: true}},
+			},
+			wantErr: "db: connection refused\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewHealthChecker(HealthCheckerOptions{})
+			for _, c := range tt.checks {
+				checker.RegisterFunc(c.name, c.check, c.opts)
+			}
+
+			err := checker.Alive(t.Context())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Alive() = %v, want nil", err)
+				}
+
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Alive() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLivenessProbe(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []testCheck
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "alive",
+			checks:     []testCheck{{name: "db", check: passingCheck}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "failures in registration order",
+			checks: []testCheck{
+				{name: "queue", check: failingCheck},
+				{name: "db", check: passingCheck},
+				{name: "cache", check: failingCheck},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `queue: connection refused\ncache: connection refused\n`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewLivenessServiceRegistry()
+			for _, c := range tt.checks {
+				registry.RegisterFunc(c.name, c.check)
+			}
+
+			w := serve(LivenessProbe(registry), httptest.NewRequest(http.MethodGet, "/live", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
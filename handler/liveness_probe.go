@@ -44,7 +44,9 @@ type LivenessService interface {
 	Alive(ctx context.Context) error
 }
 
+// LivenessServiceRegistry runs registered checks in registration order.
 type LivenessServiceRegistry struct {
+	names   []string
 	handles map[string]LivenessHandleFunc
 }
 
@@ -55,22 +57,22 @@ func NewLivenessServiceRegistry() *LivenessServiceRegistry {
 }
 
 func (l *LivenessServiceRegistry) RegisterFunc(name string, serv LivenessHandleFunc) *LivenessServiceRegistry {
+	if _, ok := l.handles[name]; !ok {
+		l.names = append(l.names, name)
+	}
 	l.handles[name] = serv
 	return l
 }
 
 func (l *LivenessServiceRegistry) Register(name string, serv LivenessService) *LivenessServiceRegistry {
-	l.handles[name] = func(ctx context.Context) error {
-		return serv.Alive(ctx)
-	}
-	return l
+	return l.RegisterFunc(name, serv.Alive)
 }
 
 func (l *LivenessServiceRegistry) Alive(ctx context.Context) error {
 	result := NewDeadStackedErrors()
 
-	for name, h := range l.handles {
-		if err := h(ctx); err != nil {
+	for _, name := range l.names {
+		if err := l.handles[name](ctx); err != nil {
 			result.Add(errors.Wrap(err, name))
 		}
 	}
@@ -1,27 +1,84 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"sync/atomic"
 
+	"github.com/pkg/errors"
 	dr "github.com/raoptimus/data-response.go/v2"
 	"github.com/raoptimus/data-response.go/v2/response"
 )
 
+// ErrNotReady is returned when the readiness is switched off manually.
+var ErrNotReady = errors.New("not ready")
+
 type ReadinessService interface {
-	Ready() error
+	Ready(ctx context.Context) error
 }
 
 type dummyReadinessService struct{}
 
-func (s *dummyReadinessService) Ready() error {
+func (s *dummyReadinessService) Ready(_ context.Context) error {
 	return nil
 }
 
 var DummyReadinessService = &dummyReadinessService{}
 
+type ReadinessHandleFunc func(ctx context.Context) error
+
+// ReadinessServiceRegistry runs registered checks in registration order.
+// The readiness may be switched off manually, e.g. during warm-up or drain.
+type ReadinessServiceRegistry struct {
+	names    []string
+	handles  map[string]ReadinessHandleFunc
+	notReady atomic.Bool
+}
+
+func NewReadinessServiceRegistry() *ReadinessServiceRegistry {
+	return &ReadinessServiceRegistry{handles: make(map[string]ReadinessHandleFunc)}
+}
+
+func (l *ReadinessServiceRegistry) RegisterFunc(name string, serv ReadinessHandleFunc) *ReadinessServiceRegistry {
+	if _, ok := l.handles[name]; !ok {
+		l.names = append(l.names, name)
+	}
+	l.handles[name] = serv
+	return l
+}
+
+func (l *ReadinessServiceRegistry) Register(name string, serv ReadinessService) *ReadinessServiceRegistry {
+	return l.RegisterFunc(name, serv.Ready)
+}
+
+// SetReady switches the readiness on or off regardless of the registered checks.
+func (l *ReadinessServiceRegistry) SetReady(ready bool) {
+	l.notReady.Store(!ready)
+}
+
+func (l *ReadinessServiceRegistry) Ready(ctx context.Context) error {
+	if l.notReady.Load() {
+		return ErrNotReady
+	}
+
+	result := NewDeadStackedErrors()
+
+	for _, name := range l.names {
+		if err := l.handles[name](ctx); err != nil {
+			result.Add(errors.Wrap(err, name))
+		}
+	}
+
+	if result.HasErrors() {
+		return result
+	}
+
+	return nil
+}
+
 func ReadinessProbe(serv ReadinessService) dr.HandlerFunc {
 	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
-		if err := serv.Ready(); err != nil {
+		if err := serv.Ready(r.Context()); err != nil {
 			return f.ServiceUnavailable(r.Context(), err.Error())
 		} else {
 			return f.Success(r.Context(), nil)
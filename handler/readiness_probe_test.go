@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+)
+
+func TestReadinessProbe(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []testCheck
+		notReady   bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "no checks",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "ready",
+			checks:     []testCheck{{name: "db", check: passingCheck}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "failures in registration order",
+			checks: []testCheck{
+				{name: "queue", check: failingCheck},
+				{name: "db", check: passingCheck},
+				{name: "cache", check: failingCheck},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `queue: connection refused\ncache: connection refused\n`,
+		},
+		{
+			name:       "switched off",
+			checks:     []testCheck{{name: "db", check: passingCheck}},
+			notReady:   true,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   ErrNotReady.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewReadinessServiceRegistry()
+			for _, c := range tt.checks {
+				registry.RegisterFunc(c.name, ReadinessHandleFunc(c.check))
+			}
+			registry.SetReady(!tt.notReady)
+
+			w := serve(ReadinessProbe(registry), httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestReadinessServiceRegistry_SetReady(t *testing.T) {
+	registry := NewReadinessServiceRegistry().RegisterFunc("db", passingCheck)
+
+	registry.SetReady(false)
+	if err := registry.Ready(t.Context()); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Ready() = %v, want %v", err, ErrNotReady)
+	}
+
+	registry.SetReady(true)
+	if err := registry.Ready(t.Context()); err != nil {
+		t.Errorf("Ready() = %v, want nil", err)
+	}
+}
+
+func TestReadinessProbe_RequestContext(t *testing.T) {
+	type ctxKey struct{}
+
+	var got any
+	registry := NewReadinessServiceRegistry().RegisterFunc("db", func(ctx context.Context) error {
+		got = ctx.Value(ctxKey{})
+
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(t.Context(), ctxKey{}, "request"))
+	cancel()
+
+	w := serve(ReadinessProbe(registry), httptest.NewRequestWithContext(ctx, http.MethodGet, "/ready", nil))
+
+	if got != "request" {
+		t.Errorf("check context value = %v, want %q", got, "request")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestReadinessProbe_Server(t *testing.T) {
+	server := dr.NewServer(http.NotFoundHandler())
+
+	w := serve(ReadinessProbe(server), httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(w.Body.String(), dr.ErrServerNotStarted.Error()) {
+		t.Errorf("body = %s, want containing %q", w.Body.String(), dr.ErrServerNotStarted.Error())
+	}
+}
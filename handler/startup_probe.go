@@ -1,14 +1,90 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 
+	"github.com/pkg/errors"
 	dr "github.com/raoptimus/data-response.go/v2"
 	"github.com/raoptimus/data-response.go/v2/response"
 )
 
-func StartupProbe() dr.HandlerFunc {
+type StartupService interface {
+	Started(ctx context.Context) error
+}
+
+// StartupGate becomes started once all registered startup tasks complete successfully.
+type StartupGate struct {
+	mu      sync.Mutex
+	pending []string
+	failed  *DeadStackedError
+}
+
+func NewStartupGate() *StartupGate {
+	return &StartupGate{failed: NewDeadStackedErrors()}
+}
+
+// Track registers a startup task and returns the function to call when it completes.
+// A non-nil error keeps the gate failed.
+func (g *StartupGate) Track(name string) (done func(err error)) {
+	g.mu.Lock()
+	g.pending = append(g.pending, name)
+	g.mu.Unlock()
+
+	var once sync.Once
+
+	return func(err error) {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+
+			for i, pending := range g.pending {
+				if pending == name {
+					g.pending = append(g.pending[:i], g.pending[i+1:]...)
+					break
+				}
+			}
+
+			g.failed.Add(errors.Wrap(err, name))
+		})
+	}
+}
+
+// Go registers a startup task and runs it in a goroutine.
+func (g *StartupGate) Go(ctx context.Context, name string, task func(ctx context.Context) error) {
+	done := g.Track(name)
+
+	go func() {
+		done(task(ctx))
+	}()
+}
+
+// Started returns nil once all tasks have completed successfully.
+func (g *StartupGate) Started(_ context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.failed.HasErrors() {
+		// A copy, failing tasks may still add errors while the returned one is read
+		return &DeadStackedError{errors: slices.Clone(g.failed.errors)}
+	}
+
+	if len(g.pending) > 0 {
+		return errors.New("startup tasks are pending: " + strings.Join(g.pending, ", "))
+	}
+
+	return nil
+}
+
+func StartupProbe(serv StartupService) dr.HandlerFunc {
 	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
-		return f.Success(r.Context(), nil)
+		if err := serv.Started(r.Context()); err != nil {
+			return f.ServiceUnavailable(r.Context(), err.Error())
+		} else {
+			return f.Success(r.Context(), nil)
+		}
 	}
 }
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestStartupProbe(t *testing.T) {
+	errMigrate := errors.New("migration failed")
+
+	tests := []struct {
+		name       string
+		tasks      []string
+		done       map[string]error // Completed tasks and their results
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "no tasks",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "all tasks completed",
+			tasks:      []string{"migrations", "cache warm-up"},
+			done:       map[string]error{"migrations": nil, "cache warm-up": nil},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "pending tasks",
+			tasks:      []string{"migrations", "cache warm-up", "index"},
+			done:       map[string]error{"cache warm-up": nil},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "startup tasks are pending: migrations, index",
+		},
+		{
+			name:       "failed task",
+			tasks:      []string{"migrations", "cache warm-up"},
+			done:       map[string]error{"migrations": errMigrate},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "migrations: migration failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := NewStartupGate()
+
+			dones := make(map[string]func(error), len(tt.tasks))
+			for _, name := range tt.tasks {
+				dones[name] = gate.Track(name)
+			}
+			for name, err := range tt.done {
+				dones[name](err)
+				// Subsequent calls are ignored
+				dones[name](nil)
+			}
+
+			w := serve(StartupProbe(gate), httptest.NewRequest(http.MethodGet, "/startup", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestStartupGate_Go(t *testing.T) {
+	gate := NewStartupGate()
+	release := make(chan struct{})
+
+	gate.Go(t.Context(), "migrations", func(context.Context) error {
+		<-release
+
+		return nil
+	})
+
+	if err := gate.Started(t.Context()); err == nil {
+		t.Fatal("Started() = nil while the task is running")
+	}
+
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for gate.Started(t.Context()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Started() = %v after the task completed", gate.Started(t.Context()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
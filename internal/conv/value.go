@@ -0,0 +1,119 @@
+package conv
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// SetValues sets v from string values, slices take all values, other types take the first one.
+func SetValues(v reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if v.Kind() == reflect.Slice && !isTextUnmarshaler(v.Type()) && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := SetString(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+		return nil
+	}
+
+	return SetString(v, values[0])
+}
+
+// SetString parses s into v, pointers are allocated.
+// Returned errors are human-readable, e.g. "must be an integer".
+func SetString(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return SetString(v.Elem(), s)
+	}
+
+	if v.CanAddr() && isTextUnmarshaler(v.Type()) {
+		//nolint:forcetypeassert,errcheck // checked by isTextUnmarshaler
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return errors.Errorf("must be a valid %s", typeDescription(v.Type()))
+		}
+
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("must be a duration")
+		}
+		v.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a non-negative integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		v.SetFloat(n)
+	default:
+		return errors.Wrapf(ErrUnsupportedType, "%s", v.Type())
+	}
+
+	return nil
+}
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func typeDescription(t reflect.Type) string {
+	if t == timeType {
+		return "RFC 3339 date-time"
+	}
+
+	if t.Name() != "" {
+		return t.Name()
+	}
+
+	return "value"
+}
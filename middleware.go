@@ -9,66 +9,95 @@
 package dataresponse
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/raoptimus/data-response.go/v2/response"
 )
 
+// WrapMiddlewareOption configures WrapMiddleware.
+type WrapMiddlewareOption func(o *wrapMiddlewareOptions)
+
+type wrapMiddlewareOptions struct {
+	factoryErrors bool
+}
+
+// WithFactoryErrors re-formats error responses written by the std middleware itself
+// (e.g. 401 of basic auth) through Factory.Error, keeping their headers except the content ones.
+func WithFactoryErrors() WrapMiddlewareOption {
+	return func(o *wrapMiddlewareOptions) {
+		o.factoryErrors = true
+	}
+}
+
 // WrapMiddleware converts std middleware to DataResponse middleware.
-// The chi middleware will be executed, but DataResponse will be returned from handler.
-func WrapMiddleware(stdM func(http.Handler) http.Handler) Middleware {
+//
+// The std middleware runs with a writer capturing what it writes, supporting http.Flusher and http.Hijacker.
+// If it calls next with that writer, the DataResponse of next is returned as is with the headers
+// the middleware has set, and next gets the request passed by the middleware (with its context values).
+// If it calls next with its own writer wrapping the given one (e.g. compression), the DataResponse
+// is written through it and the result is captured. If it writes a response without calling next,
+// the status, headers and body are preserved.
+// Like FromHTTPHandler, the std middleware runs in its own goroutine and a streaming one is canceled
+// when its response is closed without being written.
+func WrapMiddleware(stdM func(http.Handler) http.Handler, opts ...WrapMiddlewareOption) Middleware {
+	o := &wrapMiddlewareOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	return func(next Handler) Handler {
 		return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
-			var captured bool
-			capturedWriter := &capturedResponse{
-				capturedResp: f.CreateDataResponse(0, nil).
-					WithFormatter(f.formatter),
-			}
+			bw := newBridgeWriter(0)
 
-			// Create a dummy handler that captures the response
-			dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				capturedResp := next.Handle(r, f).
-					WithStatusCode(capturedWriter.capturedResp.StatusCode()).
-					WithHeaders(capturedWriter.capturedResp.Header())
-				capturedWriter.capturedResp = capturedResp
-				captured = true
-			})
+			var (
+				passed *response.DataResponse
+				called bool
+			)
+
+			// Next handler is called by the std middleware
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				resp := next.Handle(r, f)
 
-			// Execute chi middleware
-			stdM(dummyHandler).ServeHTTP(capturedWriter, r)
+				if w == http.ResponseWriter(bw) {
+					passed = resp
 
-			if !captured {
-				statusCode := capturedWriter.capturedResp.StatusCode()
-				if statusCode == http.StatusOK {
-					return f.Success(r.Context(), nil).
-						WithHeaders(capturedWriter.capturedResp.Header())
+					return
 				}
-				if statusCode == 0 {
-					statusCode = http.StatusInternalServerError
+
+				if err := Write(w, resp); err != nil {
+					f.logger.Error(r.Context(), "failed to write response to std middleware", "error", err.Error())
 				}
+			})
+
+			bw.run(stdM(nextHandler), r)
 
-				return f.Error(r.Context(), statusCode, http.StatusText(statusCode)).
-					WithHeaders(capturedWriter.capturedResp.Header())
+			// Streaming middleware keeps running, the rest is written to the client
+			if bw.streaming {
+				return bw.response()
 			}
 
-			return capturedWriter.capturedResp
-		})
-	}
-}
+			if passed != nil {
+				return passed.WithHeaders(withoutFraming(bw.header))
+			}
 
-type capturedResponse struct {
-	capturedResp *response.DataResponse
-}
+			if !called && o.factoryErrors && bw.statusCode >= http.StatusBadRequest {
+				return factoryError(r.Context(), f, bw.statusCode, bw.header)
+			}
 
-func (wr *capturedResponse) Header() http.Header {
-	return wr.capturedResp.Header()
+			return bw.response()
+		})
+	}
 }
 
-func (wr *capturedResponse) Write(b []byte) (int, error) {
-	// Silently ignore writes - body will be managed by DataResponse
-	return len(b), nil
-}
+// factoryError creates the error response by the factory with the headers written by the std middleware.
+func factoryError(ctx context.Context, f *Factory, statusCode int, header http.Header) *response.DataResponse {
+	header = header.Clone()
+	header.Del(response.HeaderContentType)
+	header.Del(response.HeaderContentLength)
+	header.Del(response.HeaderXContentTypeOptions)
 
-func (wr *capturedResponse) WriteHeader(statusCode int) {
-	wr.capturedResp = wr.capturedResp.WithStatusCode(statusCode)
+	return f.Error(ctx, statusCode, http.StatusText(statusCode)).
+		WithHeaders(header)
 }
@@ -0,0 +1,136 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"slices"
+	"strings"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// Authentication schemes.
+const (
+	AuthSchemeBasic  = "Basic"
+	AuthSchemeBearer = "Bearer"
+	AuthSchemeAPIKey = "ApiKey"
+)
+
+const defaultAuthRealm = "api"
+
+type (
+	principalContextKey       struct{}
+	principalHolderContextKey struct{}
+)
+
+// principalHolder exposes the principal to outer middlewares (e.g. Logging),
+// which do not see the request context created by auth middlewares.
+type principalHolder struct {
+	principal *Principal
+}
+
+// Principal is an authenticated identity set to the request context by auth middlewares.
+type Principal struct {
+	// Subject identifies the principal (username, token subject, API key owner).
+	Subject string
+
+	// Scheme is the authentication scheme the principal was authenticated with.
+	Scheme string
+
+	// Roles granted to the principal.
+	Roles []string
+
+	// Scopes granted to the principal (OAuth scopes).
+	Scopes []string
+
+	// Claims contains all token claims or custom attributes.
+	Claims map[string]any
+}
+
+// HasRole returns true if the principal has the given role.
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && slices.Contains(p.Roles, role)
+}
+
+// HasScope returns true if the principal has the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+// WithPrincipal returns a copy of ctx with the principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	if holder, ok := ctx.Value(principalHolderContextKey{}).(*principalHolder); ok {
+		holder.principal = p
+	}
+
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext retrieves the principal from context.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if p, ok := ctx.Value(principalContextKey{}).(*Principal); ok && p != nil {
+		return p, true
+	}
+
+	if holder, ok := ctx.Value(principalHolderContextKey{}).(*principalHolder); ok && holder.principal != nil {
+		return holder.principal, true
+	}
+
+	return nil, false
+}
+
+// withPrincipalHolder returns a copy of ctx with an empty principal holder.
+func withPrincipalHolder(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(principalHolderContextKey{}).(*principalHolder); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, principalHolderContextKey{}, &principalHolder{})
+}
+
+// challenge builds WWW-Authenticate header value, e.g. `Bearer realm="api", error="invalid_token"`.
+func challenge(scheme string, params ...string) string {
+	var sb strings.Builder
+	sb.WriteString(scheme)
+
+	separator := " "
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] == "" {
+			continue
+		}
+
+		sb.WriteString(separator)
+		separator = ", "
+		sb.WriteString(params[i])
+		sb.WriteString(`="`)
+		sb.WriteString(strings.ReplaceAll(params[i+1], `"`, `'`))
+		sb.WriteString(`"`)
+	}
+
+	return sb.String()
+}
+
+// unauthorized creates 401 response with WWW-Authenticate challenge.
+func unauthorized(ctx context.Context, f *dr.Factory, message, wwwAuthenticate string) *response.DataResponse {
+	return f.Unauthorized(ctx, message).
+		SetHeader(response.HeaderWWWAuthenticate, wwwAuthenticate)
+}
+
+// secureCompare compares two strings in constant time.
+// Values are hashed first, so the comparison time does not leak the length.
+func secureCompare(given, expected string) bool {
+	givenHash := sha256.Sum256([]byte(given))
+	expectedHash := sha256.Sum256([]byte(expected))
+
+	return subtle.ConstantTimeCompare(givenHash[:], expectedHash[:]) == 1
+}
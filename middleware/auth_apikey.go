@@ -0,0 +1,114 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const defaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyValidator validates the key and returns the authenticated principal.
+// It returns nil principal for unknown keys.
+type APIKeyValidator func(ctx context.Context, key string) (*Principal, error)
+
+// APIKeyOptions configures API key authentication middleware.
+type APIKeyOptions struct {
+	// Realm is sent in WWW-Authenticate challenge (default: "api").
+	Realm string
+
+	// Header is the request header containing the key (default: "X-API-Key").
+	Header string
+
+	// QueryParam is the query parameter containing the key (disabled if empty).
+	// The header takes precedence over the query parameter.
+	QueryParam string
+
+	// Keys maps API keys to principals. Keys are compared in constant time.
+	Keys map[string]*Principal
+
+	// Validator is a custom key validator. It is used when Keys is empty.
+	Validator APIKeyValidator
+}
+
+// APIKeyAuth creates a middleware that authenticates requests by API key
+// taken from a header or a query parameter.
+func APIKeyAuth(opts APIKeyOptions) dr.Middleware {
+	if opts.Realm == "" {
+		opts.Realm = defaultAuthRealm
+	}
+
+	if opts.Header == "" {
+		opts.Header = defaultAPIKeyHeader
+	}
+
+	if opts.Validator == nil {
+		opts.Validator = keysValidator(opts.Keys)
+	}
+
+	wwwAuthenticate := challenge(AuthSchemeAPIKey, "realm", opts.Realm, "header", opts.Header)
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			ctx := r.Context()
+
+			key := r.Header.Get(opts.Header)
+			if key == "" && opts.QueryParam != "" {
+				key = r.URL.Query().Get(opts.QueryParam)
+			}
+
+			if key == "" {
+				return unauthorized(ctx, f, "API key required", wwwAuthenticate)
+			}
+
+			principal, err := opts.Validator(ctx, key)
+			if err != nil {
+				return f.InternalError(ctx, response.WrapError(http.StatusInternalServerError, err, "failed to validate api key"))
+			}
+
+			if principal == nil {
+				f.Logger().Warn(ctx, "api key authentication failed", "path", r.URL.Path)
+
+				return unauthorized(ctx, f, "Invalid API key", wwwAuthenticate)
+			}
+
+			if principal.Scheme == "" {
+				principal.Scheme = AuthSchemeAPIKey
+			}
+
+			return next.Handle(r.WithContext(WithPrincipal(ctx, principal)), f)
+		})
+	}
+}
+
+// keysValidator validates keys against the static map.
+// All keys are compared, so the timing does not depend on the matched key position.
+func keysValidator(keys map[string]*Principal) APIKeyValidator {
+	return func(_ context.Context, key string) (*Principal, error) {
+		var found *Principal
+		for expected, principal := range keys {
+			if secureCompare(key, expected) {
+				found = principal
+			}
+		}
+
+		if found == nil {
+			return nil, nil
+		}
+
+		// Copy to keep the configured principal immutable
+		principal := *found
+
+		return &principal, nil
+	}
+}
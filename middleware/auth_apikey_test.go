@@ -0,0 +1,126 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	opts := APIKeyOptions{
+		QueryParam: "api_key",
+		Keys: map[string]*Principal{
+			"key-1": {Subject: "service-a"},
+			"key-2": {Subject: "service-b", Scheme: "Internal"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		opts       APIKeyOptions
+		target     string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "header",
+			opts:       opts,
+			target:     "/",
+			header:     "key-1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"ApiKey:service-a"`,
+		},
+		{
+			name:       "query parameter",
+			opts:       opts,
+			target:     "/?api_key=key-2",
+			wantStatus: http.StatusOK,
+			wantBody:   `"Internal:service-b"`,
+		},
+		{
+			name:       "header takes precedence",
+			opts:       opts,
+			target:     "/?api_key=key-2",
+			header:     "key-1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"ApiKey:service-a"`,
+		},
+		{
+			name:       "query parameter disabled",
+			opts:       APIKeyOptions{Keys: opts.Keys},
+			target:     "/?api_key=key-1",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing key",
+			opts:       opts,
+			target:     "/",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown key",
+			opts:       opts,
+			target:     "/",
+			header:     "key-3",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "prefix of a key",
+			opts:       opts,
+			target:     "/",
+			header:     "key-",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set("X-API-Key", tt.header)
+			}
+
+			w := serve(APIKeyAuth(tt.opts), principalHandler, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusUnauthorized {
+				want := `ApiKey realm="api", header="X-API-Key"`
+				if got := w.Header().Get(response.HeaderWWWAuthenticate); got != want {
+					t.Errorf("WWW-Authenticate = %q, want %q", got, want)
+				}
+			}
+
+			if tt.wantBody != "" && strings.TrimSpace(w.Body.String()) != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_PrincipalIsCopied(t *testing.T) {
+	configured := &Principal{Subject: "service-a"}
+	m := APIKeyAuth(APIKeyOptions{Keys: map[string]*Principal{"key-1": configured}})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-Key", "key-1")
+	serve(m, principalHandler, r)
+
+	if configured.Scheme != "" {
+		t.Errorf("configured principal is modified: %+v", configured)
+	}
+}
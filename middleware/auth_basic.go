@@ -0,0 +1,94 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// BasicAuthValidator validates credentials and returns the authenticated principal.
+// It returns nil principal for invalid credentials.
+type BasicAuthValidator func(ctx context.Context, username, password string) (*Principal, error)
+
+// BasicAuthOptions configures Basic authentication middleware.
+type BasicAuthOptions struct {
+	// Realm is sent in WWW-Authenticate challenge (default: "api").
+	Realm string
+
+	// Credentials maps usernames to passwords. Passwords are compared in constant time.
+	Credentials map[string]string
+
+	// Validator is a custom credentials validator. It is used when Credentials is empty.
+	Validator BasicAuthValidator
+}
+
+// BasicAuth creates a middleware that implements HTTP Basic authentication (RFC 7617).
+// It returns 401 Unauthorized with WWW-Authenticate challenge when credentials are missing or invalid.
+func BasicAuth(opts BasicAuthOptions) dr.Middleware {
+	if opts.Realm == "" {
+		opts.Realm = defaultAuthRealm
+	}
+
+	if opts.Validator == nil {
+		opts.Validator = credentialsValidator(opts.Credentials)
+	}
+
+	wwwAuthenticate := challenge(AuthSchemeBasic, "realm", opts.Realm, "charset", "UTF-8")
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			ctx := r.Context()
+
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				return unauthorized(ctx, f, "Authentication required", wwwAuthenticate)
+			}
+
+			principal, err := opts.Validator(ctx, username, password)
+			if err != nil {
+				return f.InternalError(ctx, response.WrapError(http.StatusInternalServerError, err, "failed to validate credentials"))
+			}
+
+			if principal == nil {
+				f.Logger().Warn(ctx, "basic authentication failed",
+					"username", username,
+					"path", r.URL.Path,
+				)
+
+				return unauthorized(ctx, f, "Invalid credentials", wwwAuthenticate)
+			}
+
+			if principal.Scheme == "" {
+				principal.Scheme = AuthSchemeBasic
+			}
+
+			return next.Handle(r.WithContext(WithPrincipal(ctx, principal)), f)
+		})
+	}
+}
+
+// credentialsValidator validates credentials against the static map.
+func credentialsValidator(credentials map[string]string) BasicAuthValidator {
+	return func(_ context.Context, username, password string) (*Principal, error) {
+		expected, ok := credentials[username]
+		// Compare anyway to keep timing equal for unknown users
+		if !secureCompare(password, expected) || !ok {
+			return nil, nil
+		}
+
+		return &Principal{
+			Subject: username,
+			Scheme:  AuthSchemeBasic,
+		}, nil
+	}
+}
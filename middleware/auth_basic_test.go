@@ -0,0 +1,133 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// principalHandler responds with the subject of the authenticated principal.
+func principalHandler(r *http.Request, f *dr.Factory) *response.DataResponse {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return f.InternalError(r.Context(), errors.New("no principal"))
+	}
+
+	return f.Success(r.Context(), principal.Scheme+":"+principal.Subject)
+}
+
+func TestBasicAuth(t *testing.T) {
+	credentials := BasicAuthOptions{
+		Realm:       "admin",
+		Credentials: map[string]string{"alice": "secret"},
+	}
+
+	tests := []struct {
+		name          string
+		opts          BasicAuthOptions
+		username      string
+		password      string
+		noCredentials bool
+		wantStatus    int
+		wantBody      string
+	}{
+		{
+			name:       "valid credentials",
+			opts:       credentials,
+			username:   "alice",
+			password:   "secret",
+			wantStatus: http.StatusOK,
+			wantBody:   `"Basic:alice"`,
+		},
+		{
+			name:          "missing credentials",
+			opts:          credentials,
+			noCredentials: true,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong password",
+			opts:       credentials,
+			username:   "alice",
+			password:   "secret2",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown user with empty password",
+			opts:       credentials,
+			username:   "bob",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "custom validator",
+			opts: BasicAuthOptions{
+				Realm: "admin",
+				Validator: func(_ context.Context, username, password string) (*Principal, error) {
+					if password != "token" {
+						return nil, nil
+					}
+
+					return &Principal{Subject: username, Scheme: "Custom"}, nil
+				},
+			},
+			username:   "bob",
+			password:   "token",
+			wantStatus: http.StatusOK,
+			wantBody:   `"Custom:bob"`,
+		},
+		{
+			name: "validator failure",
+			opts: BasicAuthOptions{
+				Realm: "admin",
+				Validator: func(context.Context, string, string) (*Principal, error) {
+					return nil, errors.New("store is down")
+				},
+			},
+			username:   "bob",
+			password:   "token",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if !tt.noCredentials {
+				r.SetBasicAuth(tt.username, tt.password)
+			}
+
+			w := serve(BasicAuth(tt.opts), principalHandler, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			challenge := w.Header().Get(response.HeaderWWWAuthenticate)
+			if tt.wantStatus == http.StatusUnauthorized {
+				if want := `Basic realm="admin", charset="UTF-8"`; challenge != want {
+					t.Errorf("WWW-Authenticate = %q, want %q", challenge, want)
+				}
+			} else if challenge != "" {
+				t.Errorf("WWW-Authenticate = %q, want none", challenge)
+			}
+
+			if tt.wantBody != "" && strings.TrimSpace(w.Body.String()) != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
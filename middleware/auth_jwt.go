@@ -0,0 +1,412 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+
+	// Register hash functions used by JWT algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// JWT signing algorithms.
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgHS384 = "HS384"
+	JWTAlgHS512 = "HS512"
+	JWTAlgRS256 = "RS256"
+	JWTAlgRS384 = "RS384"
+	JWTAlgRS512 = "RS512"
+	JWTAlgES256 = "ES256"
+	JWTAlgES384 = "ES384"
+	JWTAlgES512 = "ES512"
+)
+
+const (
+	jwtSegments = 3
+
+	defaultRolesClaim  = "roles"
+	defaultScopesClaim = "scope"
+
+	bearerErrorInvalidRequest = "invalid_request"
+	bearerErrorInvalidToken   = "invalid_token"
+)
+
+var (
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrTokenUnverifiable     = errors.New("token algorithm is not allowed")
+	ErrTokenSignatureInvalid = errors.New("token signature is invalid")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenInvalidIssuer    = errors.New("token has invalid issuer")
+	ErrTokenInvalidAudience  = errors.New("token has invalid audience")
+	ErrTokenMissingExp       = errors.New("token has no expiration")
+)
+
+// tokenErrors are errors caused by the token itself (reported as 401 invalid_token).
+var tokenErrors = []error{
+	ErrTokenMalformed,
+	ErrTokenUnverifiable,
+	ErrTokenSignatureInvalid,
+	ErrTokenExpired,
+	ErrTokenNotValidYet,
+	ErrTokenInvalidIssuer,
+	ErrTokenInvalidAudience,
+	ErrTokenMissingExp,
+	ErrKeyNotFound,
+	ErrKeyAlgMismatch,
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	JWTAlgHS256: crypto.SHA256,
+	JWTAlgHS384: crypto.SHA384,
+	JWTAlgHS512: crypto.SHA512,
+	JWTAlgRS256: crypto.SHA256,
+	JWTAlgRS384: crypto.SHA384,
+	JWTAlgRS512: crypto.SHA512,
+	JWTAlgES256: crypto.SHA256,
+	JWTAlgES384: crypto.SHA384,
+	JWTAlgES512: crypto.SHA512,
+}
+
+// JWTOptions configures JWT bearer authentication middleware.
+type JWTOptions struct {
+	// Realm is sent in WWW-Authenticate challenge (default: "api").
+	Realm string
+
+	// Keys resolves verification keys (StaticKey, JWKS, RemoteJWKS).
+	Keys KeyProvider
+
+	// Algorithms lists allowed signing algorithms (default: all HS, RS and ES algorithms).
+	Algorithms []string
+
+	// Issuer is the expected "iss" claim (not checked if empty).
+	Issuer string
+
+	// Audience lists accepted "aud" values, token must contain at least one (not checked if empty).
+	Audience []string
+
+	// ClockSkew is the leeway for "exp", "nbf" and "iat" checks.
+	ClockSkew time.Duration
+
+	// AllowMissingExpiration accepts tokens without "exp" claim, by default they are rejected.
+	AllowMissingExpiration bool
+
+	// RolesClaim is the claim containing principal roles (default: "roles").
+	RolesClaim string
+
+	// ScopesClaim is the claim containing space separated or array of scopes (default: "scope").
+	ScopesClaim string
+
+	// Now returns the current time (default: time.Now).
+	Now func() time.Time
+}
+
+// JWTVerifier verifies JSON Web Tokens (RFC 7519) signed with HS, RS or ES algorithms.
+type JWTVerifier struct {
+	opts       JWTOptions
+	algorithms map[string]bool
+}
+
+// NewJWTVerifier creates a new JWT verifier.
+func NewJWTVerifier(opts JWTOptions) *JWTVerifier {
+	if opts.Realm == "" {
+		opts.Realm = defaultAuthRealm
+	}
+
+	if len(opts.Algorithms) == 0 {
+		for alg := range jwtHashes {
+			opts.Algorithms = append(opts.Algorithms, alg)
+		}
+	}
+
+	if opts.RolesClaim == "" {
+		opts.RolesClaim = defaultRolesClaim
+	}
+
+	if opts.ScopesClaim == "" {
+		opts.ScopesClaim = defaultScopesClaim
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	algorithms := make(map[string]bool, len(opts.Algorithms))
+	for _, alg := range opts.Algorithms {
+		if _, ok := jwtHashes[alg]; ok {
+			algorithms[alg] = true
+		}
+	}
+
+	return &JWTVerifier{opts: opts, algorithms: algorithms}
+}
+
+// Verify verifies the token signature and registered claims and returns the principal.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != jwtSegments {
+		return nil, errors.WithStack(ErrTokenMalformed)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	if !v.algorithms[header.Alg] {
+		return nil, errors.Wrapf(ErrTokenUnverifiable, "alg %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.WithStack(ErrTokenMalformed)
+	}
+
+	key, err := v.opts.Keys.Key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]any)
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return &Principal{
+		Subject: subject,
+		Scheme:  AuthSchemeBearer,
+		Roles:   claimStrings(claims[v.opts.RolesClaim]),
+		Scopes:  claimStrings(claims[v.opts.ScopesClaim]),
+		Claims:  claims,
+	}, nil
+}
+
+// validateClaims validates exp, nbf, iat, iss and aud claims.
+func (v *JWTVerifier) validateClaims(claims map[string]any) error {
+	now := v.opts.Now()
+	skew := v.opts.ClockSkew
+
+	exp, ok, err := claimTime(claims, "exp")
+	switch {
+	case err != nil:
+		return err
+	case ok && !now.Before(exp.Add(skew)):
+		return errors.WithStack(ErrTokenExpired)
+	case !ok && !v.opts.AllowMissingExpiration:
+		return errors.WithStack(ErrTokenMissingExp)
+	}
+
+	for _, name := range []string{"nbf", "iat"} {
+		notBefore, ok, err := claimTime(claims, name)
+		if err != nil {
+			return err
+		}
+
+		if ok && now.Add(skew).Before(notBefore) {
+			return errors.WithStack(ErrTokenNotValidYet)
+		}
+	}
+
+	if v.opts.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.opts.Issuer {
+			return errors.WithStack(ErrTokenInvalidIssuer)
+		}
+	}
+
+	if len(v.opts.Audience) > 0 {
+		audience := claimAudience(claims["aud"])
+		if !slices.ContainsFunc(audience, func(aud string) bool {
+			return slices.Contains(v.opts.Audience, aud)
+		}) {
+			return errors.WithStack(ErrTokenInvalidAudience)
+		}
+	}
+
+	return nil
+}
+
+// JWTAuth creates a middleware that authenticates requests by JWT bearer token (RFC 6750).
+// Invalid tokens are rejected with 401 and WWW-Authenticate `Bearer error="invalid_token"` challenge.
+func JWTAuth(opts JWTOptions) dr.Middleware {
+	verifier := NewJWTVerifier(opts)
+	realm := verifier.opts.Realm
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			ctx := r.Context()
+
+			authorization := r.Header.Get(response.HeaderAuthorization)
+			if authorization == "" {
+				return unauthorized(ctx, f, "Authentication required", challenge(AuthSchemeBearer, "realm", realm))
+			}
+
+			scheme, token, ok := strings.Cut(authorization, " ")
+			if !ok || !strings.EqualFold(scheme, AuthSchemeBearer) || token == "" {
+				return unauthorized(ctx, f, "Invalid authorization header", challenge(AuthSchemeBearer,
+					"realm", realm,
+					"error", bearerErrorInvalidRequest,
+				))
+			}
+
+			principal, err := verifier.Verify(ctx, strings.TrimSpace(token))
+			if err != nil {
+				if !isTokenError(err) {
+					return f.InternalError(ctx, response.WrapError(http.StatusInternalServerError, err, "failed to verify token"))
+				}
+
+				f.Logger().Warn(ctx, "jwt authentication failed",
+					"error", err.Error(),
+					"path", r.URL.Path,
+				)
+
+				return unauthorized(ctx, f, "Invalid token", challenge(AuthSchemeBearer,
+					"realm", realm,
+					"error", bearerErrorInvalidToken,
+					"error_description", err.Error(),
+				))
+			}
+
+			return next.Handle(r.WithContext(WithPrincipal(ctx, principal)), f)
+		})
+	}
+}
+
+// verifySignature verifies the signature of the signing input.
+func verifySignature(alg string, key any, signingInput string, signature []byte) error {
+	if err := checkKeyAlg(key, alg); err != nil {
+		return err
+	}
+
+	hash := jwtHashes[alg]
+	hasher := hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.WithStack(ErrTokenSignatureInvalid)
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return errors.WithStack(ErrTokenSignatureInvalid)
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.WithStack(ErrTokenSignatureInvalid)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.WithStack(ErrTokenSignatureInvalid)
+		}
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.WithStack(ErrTokenMalformed)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.WithStack(ErrTokenMalformed)
+	}
+
+	return nil
+}
+
+// claimTime converts NumericDate claim to time, returns false if the claim is missing.
+// A claim that is not a number makes the token malformed.
+func claimTime(claims map[string]any, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false, errors.Wrapf(ErrTokenMalformed, "claim %q must be a number", name)
+	}
+
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// claimAudience converts "aud" claim, a single StringOrURI or an array of them, to strings.
+func claimAudience(value any) []string {
+	if aud, ok := value.(string); ok {
+		return []string{aud}
+	}
+
+	return claimStrings(value)
+}
+
+// claimStrings converts a space separated string or an array claim to strings.
+func claimStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
+
+func isTokenError(err error) bool {
+	for _, target := range tokenErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,418 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+var (
+	testHMACSecret = []byte("0123456789abcdef0123456789abcdef")
+	testJWTNow     = time.Unix(1_700_000_000, 0)
+)
+
+// signJWT creates a token signed with the key, alg "none" creates an unsigned token.
+func signJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+
+	header := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	input := encodeJWTSegment(t, header) + "." + encodeJWTSegment(t, claims)
+	if alg == "none" {
+		return input + "."
+	}
+
+	hash := jwtHashes[alg]
+	hasher := hash.New()
+	hasher.Write([]byte(input))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	default:
+		t.Fatalf("unsupported key %T", key)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeJWTSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := func(extra map[string]any) map[string]any {
+		claims := map[string]any{
+			"sub": "alice",
+			"iss": "https://issuer.example.com",
+			"aud": []string{"api", "web"},
+			"exp": testJWTNow.Add(time.Hour).Unix(),
+			"iat": testJWTNow.Add(-time.Minute).Unix(),
+		}
+		for name, value := range extra {
+			if value == nil {
+				delete(claims, name)
+
+				continue
+			}
+			claims[name] = value
+		}
+
+		return claims
+	}
+
+	baseOpts := JWTOptions{
+		Keys:     StaticKey(testHMACSecret),
+		Issuer:   "https://issuer.example.com",
+		Audience: []string{"api"},
+		Now:      func() time.Time { return testJWTNow },
+	}
+	withOpts := func(modify func(o *JWTOptions)) JWTOptions {
+		o := baseOpts
+		modify(&o)
+
+		return o
+	}
+
+	tests := []struct {
+		name    string
+		opts    JWTOptions
+		token   string
+		wantErr error
+	}{
+		{
+			name:  "HS256",
+			opts:  baseOpts,
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(nil)),
+		},
+		{
+			name:  "HS512",
+			opts:  baseOpts,
+			token: signJWT(t, JWTAlgHS512, "", testHMACSecret, valid(nil)),
+		},
+		{
+			name:  "RS256",
+			opts:  withOpts(func(o *JWTOptions) { o.Keys = StaticKey(&rsaKey.PublicKey) }),
+			token: signJWT(t, JWTAlgRS256, "", rsaKey, valid(nil)),
+		},
+		{
+			name:  "ES256",
+			opts:  withOpts(func(o *JWTOptions) { o.Keys = StaticKey(&ecKey.PublicKey) }),
+			token: signJWT(t, JWTAlgES256, "", ecKey, valid(nil)),
+		},
+		{
+			name:    "wrong secret",
+			opts:    baseOpts,
+			token:   signJWT(t, JWTAlgHS256, "", []byte("another secret"), valid(nil)),
+			wantErr: ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "tampered claims",
+			opts:    baseOpts,
+			token:   tamperClaims(t, signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(nil)), valid(map[string]any{"sub": "root"})),
+			wantErr: ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "alg none",
+			opts:    baseOpts,
+			token:   signJWT(t, "none", "", nil, valid(nil)),
+			wantErr: ErrTokenUnverifiable,
+		},
+		{
+			name:    "algorithm not allowed",
+			opts:    withOpts(func(o *JWTOptions) { o.Algorithms = []string{JWTAlgHS512} }),
+			token:   signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(nil)),
+			wantErr: ErrTokenUnverifiable,
+		},
+		{
+			name:    "HMAC signed with the RSA public key",
+			opts:    withOpts(func(o *JWTOptions) { o.Keys = StaticKey(&rsaKey.PublicKey) }),
+			token:   signJWT(t, JWTAlgHS256, "", rsaPublicDER, valid(nil)),
+			wantErr: ErrKeyAlgMismatch,
+		},
+		{
+			name:    "expired",
+			opts:    baseOpts,
+			token:   signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"exp": testJWTNow.Unix()})),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "expired within clock skew",
+			opts: withOpts(func(o *JWTOptions) { o.ClockSkew = time.Minute }),
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret,
+				valid(map[string]any{"exp": testJWTNow.Add(-30 * time.Second).Unix()})),
+		},
+		{
+			name:    "missing exp",
+			opts:    baseOpts,
+			token:   signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"exp": nil})),
+			wantErr: ErrTokenMissingExp,
+		},
+		{
+			name:  "missing exp allowed",
+			opts:  withOpts(func(o *JWTOptions) { o.AllowMissingExpiration = true }),
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"exp": nil})),
+		},
+		{
+			name: "exp is not a number",
+			opts: baseOpts,
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret,
+				valid(map[string]any{"exp": "2100-01-01T00:00:00Z"})),
+			wantErr: ErrTokenMalformed,
+		},
+		{
+			name: "not valid yet",
+			opts: baseOpts,
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret,
+				valid(map[string]any{"nbf": testJWTNow.Add(time.Minute).Unix()})),
+			wantErr: ErrTokenNotValidYet,
+		},
+		{
+			name: "issued in the future",
+			opts: baseOpts,
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret,
+				valid(map[string]any{"iat": testJWTNow.Add(time.Minute).Unix()})),
+			wantErr: ErrTokenNotValidYet,
+		},
+		{
+			name:    "invalid issuer",
+			opts:    baseOpts,
+			token:   signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"iss": "https://evil.example.com"})),
+			wantErr: ErrTokenInvalidIssuer,
+		},
+		{
+			name:  "string audience",
+			opts:  baseOpts,
+			token: signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"aud": "api"})),
+		},
+		{
+			name:    "string audience is not split",
+			opts:    baseOpts,
+			token:   signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"aud": "web api"})),
+			wantErr: ErrTokenInvalidAudience,
+		},
+		{
+			name:    "missing audience",
+			opts:    baseOpts,
+			token:   signJWT(t, JWTAlgHS256, "", testHMACSecret, valid(map[string]any{"aud": nil})),
+			wantErr: ErrTokenInvalidAudience,
+		},
+		{
+			name:    "malformed",
+			opts:    baseOpts,
+			token:   "not.a-token",
+			wantErr: ErrTokenMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := NewJWTVerifier(tt.opts).Verify(t.Context(), tt.token)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.Subject != "alice" || principal.Scheme != AuthSchemeBearer {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_RolesAndScopes(t *testing.T) {
+	token := signJWT(t, JWTAlgHS256, "", testHMACSecret, map[string]any{
+		"sub":   "alice",
+		"exp":   testJWTNow.Add(time.Hour).Unix(),
+		"roles": []string{"admin", "editor"},
+		"scope": "read:users write:users",
+		"perms": []string{"x"},
+	})
+
+	tests := []struct {
+		name       string
+		opts       JWTOptions
+		wantRoles  []string
+		wantScopes []string
+	}{
+		{
+			name:       "default claims",
+			opts:       JWTOptions{},
+			wantRoles:  []string{"admin", "editor"},
+			wantScopes: []string{"read:users", "write:users"},
+		},
+		{
+			name:       "custom claims",
+			opts:       JWTOptions{RolesClaim: "perms", ScopesClaim: "missing"},
+			wantRoles:  []string{"x"},
+			wantScopes: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Keys = StaticKey(testHMACSecret)
+			tt.opts.Now = func() time.Time { return testJWTNow }
+
+			principal, err := NewJWTVerifier(tt.opts).Verify(t.Context(), token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(principal.Roles, ",") != strings.Join(tt.wantRoles, ",") {
+				t.Errorf("Roles = %q, want %q", principal.Roles, tt.wantRoles)
+			}
+			if strings.Join(principal.Scopes, ",") != strings.Join(tt.wantScopes, ",") {
+				t.Errorf("Scopes = %q, want %q", principal.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	m := JWTAuth(JWTOptions{
+		Keys:  StaticKey(testHMACSecret),
+		Realm: "api",
+		Now:   func() time.Time { return testJWTNow },
+	})
+	valid := signJWT(t, JWTAlgHS256, "", testHMACSecret, map[string]any{
+		"sub": "alice",
+		"exp": testJWTNow.Add(time.Hour).Unix(),
+	})
+	expired := signJWT(t, JWTAlgHS256, "", testHMACSecret, map[string]any{
+		"sub": "alice",
+		"exp": testJWTNow.Add(-time.Hour).Unix(),
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantChallenge string
+	}{
+		{
+			name:          "valid token",
+			authorization: "Bearer " + valid,
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "case insensitive scheme",
+			authorization: "bearer " + valid,
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "missing header",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="api"`,
+		},
+		{
+			name:          "other scheme",
+			authorization: "Basic YWxpY2U6c2VjcmV0",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="api", error="invalid_request"`,
+		},
+		{
+			name:          "expired token",
+			authorization: "Bearer " + expired,
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="api", error="invalid_token", error_description="token is expired"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set(response.HeaderAuthorization, tt.authorization)
+			}
+
+			w := serve(m, principalHandler, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderWWWAuthenticate); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+// tamperClaims replaces the claims of the signed token keeping its signature.
+func tamperClaims(t *testing.T, token string, claims map[string]any) string {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	parts[1] = encodeJWTSegment(t, claims)
+
+	return strings.Join(parts, ".")
+}
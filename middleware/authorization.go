@@ -0,0 +1,217 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// AuthorizationReason is a machine-readable reason code of the authorization decision.
+type AuthorizationReason string
+
+// Authorization reason codes.
+const (
+	ReasonGranted         AuthorizationReason = "granted"
+	ReasonUnauthenticated AuthorizationReason = "unauthenticated"
+	ReasonMissingRole     AuthorizationReason = "missing_role"
+	ReasonMissingScope    AuthorizationReason = "missing_scope"
+	ReasonPolicyDenied    AuthorizationReason = "policy_denied"
+)
+
+// String returns the string representation of AuthorizationReason.
+func (r AuthorizationReason) String() string {
+	return string(r)
+}
+
+// Decision is the result of the authorization.
+type Decision struct {
+	Allowed bool
+	Reason  AuthorizationReason
+}
+
+// Allow creates an allowing decision.
+func Allow() Decision {
+	return Decision{Allowed: true, Reason: ReasonGranted}
+}
+
+// Deny creates a denying decision with the reason code.
+func Deny(reason AuthorizationReason) Decision {
+	return Decision{Allowed: false, Reason: reason}
+}
+
+// Predicate is an attribute-based rule over the request and the principal.
+type Predicate func(r *http.Request, p *Principal) bool
+
+// Requirement declares what a route requires from the principal.
+type Requirement struct {
+	// Roles lists roles, the principal must have at least one of them.
+	Roles []string
+
+	// Scopes lists OAuth scopes, the principal must have all of them.
+	Scopes []string
+
+	// Predicates lists attribute-based rules, all of them must be satisfied.
+	Predicates []Predicate
+}
+
+// Authorizer decides whether the principal satisfies the requirement.
+//
+//go:generate mockery
+type Authorizer interface {
+	Authorize(r *http.Request, p *Principal, req Requirement) Decision
+}
+
+// AuthorizerFunc is a function adapter for Authorizer interface.
+type AuthorizerFunc func(r *http.Request, p *Principal, req Requirement) Decision
+
+// Authorize calls f(r, p, req).
+func (f AuthorizerFunc) Authorize(r *http.Request, p *Principal, req Requirement) Decision {
+	return f(r, p, req)
+}
+
+// RBAC is a role-based Authorizer with optional role hierarchy.
+// Predicates of the requirement are evaluated too, so RBAC and ABAC rules can be combined.
+type RBAC struct {
+	// Inherits maps a role to roles it includes, e.g. {"admin": {"editor"}, "editor": {"viewer"}}.
+	Inherits map[string][]string
+}
+
+// NewRBAC creates a role-based authorizer.
+func NewRBAC(inherits map[string][]string) *RBAC {
+	return &RBAC{Inherits: inherits}
+}
+
+// Authorize checks roles, scopes and predicates of the requirement.
+func (a *RBAC) Authorize(r *http.Request, p *Principal, req Requirement) Decision {
+	if p == nil {
+		return Deny(ReasonUnauthenticated)
+	}
+
+	if len(req.Roles) > 0 && !slices.ContainsFunc(req.Roles, func(role string) bool {
+		return a.hasRole(p, role)
+	}) {
+		return Deny(ReasonMissingRole)
+	}
+
+	for _, scope := range req.Scopes {
+		if !p.HasScope(scope) {
+			return Deny(ReasonMissingScope)
+		}
+	}
+
+	for _, predicate := range req.Predicates {
+		if !predicate(r, p) {
+			return Deny(ReasonPolicyDenied)
+		}
+	}
+
+	return Allow()
+}
+
+// hasRole checks the role directly or through the role hierarchy.
+func (a *RBAC) hasRole(p *Principal, role string) bool {
+	visited := make(map[string]bool)
+	stack := slices.Clone(p.Roles)
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if current == role {
+			return true
+		}
+
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		stack = append(stack, a.Inherits[current]...)
+	}
+
+	return false
+}
+
+// defaultAuthorizer is RBAC without role hierarchy.
+var defaultAuthorizer Authorizer = &RBAC{}
+
+// AuthorizationOptions configures Authorization middleware.
+type AuthorizationOptions struct {
+	// Authorizer evaluates the requirement (default: RBAC without role hierarchy).
+	Authorizer Authorizer
+
+	// Requirement declares what the route requires.
+	Requirement Requirement
+}
+
+// Authorization creates a middleware that authorizes the principal set by auth middlewares.
+// Requests without principal get 401 Unauthorized, denied requests get 403 Forbidden with the reason code.
+// Every decision is logged with the route pattern for auditing.
+func Authorization(opts AuthorizationOptions) dr.Middleware {
+	if opts.Authorizer == nil {
+		opts.Authorizer = defaultAuthorizer
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			ctx := r.Context()
+			principal, _ := PrincipalFromContext(ctx)
+
+			decision := opts.Authorizer.Authorize(r, principal, opts.Requirement)
+
+			var subject string
+			if principal != nil {
+				subject = principal.Subject
+			}
+
+			f.Logger().Info(ctx, "authorization decision",
+				"route", r.Pattern,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"subject", subject,
+				"allowed", decision.Allowed,
+				"reason", decision.Reason.String(),
+			)
+
+			if decision.Allowed {
+				return next.Handle(r, f)
+			}
+
+			if decision.Reason == ReasonUnauthenticated {
+				return f.Unauthorized(ctx, decision.Reason.String())
+			}
+
+			return f.Forbidden(ctx, decision.Reason.String())
+		})
+	}
+}
+
+// RequireRoles creates a middleware that requires any of the roles.
+func RequireRoles(roles ...string) dr.Middleware {
+	return Authorization(AuthorizationOptions{
+		Requirement: Requirement{Roles: roles},
+	})
+}
+
+// RequireScopes creates a middleware that requires all the scopes.
+func RequireScopes(scopes ...string) dr.Middleware {
+	return Authorization(AuthorizationOptions{
+		Requirement: Requirement{Scopes: scopes},
+	})
+}
+
+// RequirePolicy creates a middleware that requires all the predicates to be satisfied.
+func RequirePolicy(predicates ...Predicate) dr.Middleware {
+	return Authorization(AuthorizationOptions{
+		Requirement: Requirement{Predicates: predicates},
+	})
+}
@@ -0,0 +1,222 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+)
+
+func TestRBAC_Authorize(t *testing.T) {
+	rbac := NewRBAC(map[string][]string{
+		"admin":  {"editor"},
+		"editor": {"viewer", "admin"}, // Cycles are allowed
+	})
+	ownResource := func(r *http.Request, p *Principal) bool {
+		return r.URL.Query().Get("owner") == p.Subject
+	}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		req       Requirement
+		target    string
+		want      Decision
+	}{
+		{
+			name: "unauthenticated",
+			req:  Requirement{Roles: []string{"viewer"}},
+			want: Deny(ReasonUnauthenticated),
+		},
+		{
+			name:      "empty requirement",
+			principal: &Principal{Subject: "alice"},
+			want:      Allow(),
+		},
+		{
+			name:      "any of roles",
+			principal: &Principal{Roles: []string{"viewer"}},
+			req:       Requirement{Roles: []string{"editor", "viewer"}},
+			want:      Allow(),
+		},
+		{
+			name:      "inherited role",
+			principal: &Principal{Roles: []string{"admin"}},
+			req:       Requirement{Roles: []string{"viewer"}},
+			want:      Allow(),
+		},
+		{
+			name:      "role is not inherited upwards",
+			principal: &Principal{Roles: []string{"viewer"}},
+			req:       Requirement{Roles: []string{"editor"}},
+			want:      Deny(ReasonMissingRole),
+		},
+		{
+			name:      "all scopes",
+			principal: &Principal{Scopes: []string{"read", "write"}},
+			req:       Requirement{Scopes: []string{"read", "write"}},
+			want:      Allow(),
+		},
+		{
+			name:      "missing scope",
+			principal: &Principal{Scopes: []string{"read"}},
+			req:       Requirement{Scopes: []string{"read", "write"}},
+			want:      Deny(ReasonMissingScope),
+		},
+		{
+			name:      "predicate satisfied",
+			principal: &Principal{Subject: "alice"},
+			req:       Requirement{Predicates: []Predicate{ownResource}},
+			target:    "/?owner=alice",
+			want:      Allow(),
+		},
+		{
+			name:      "predicate denied",
+			principal: &Principal{Subject: "alice"},
+			req:       Requirement{Predicates: []Predicate{ownResource}},
+			target:    "/?owner=bob",
+			want:      Deny(ReasonPolicyDenied),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+
+			got := rbac.Authorize(httptest.NewRequest(http.MethodGet, target, nil), tt.principal, tt.req)
+			if got != tt.want {
+				t.Errorf("Authorize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name       string
+		m          dr.Middleware
+		principal  *Principal
+		wantStatus int
+		wantReason string
+	}{
+		{
+			name:       "no principal",
+			m:          RequireRoles("admin"),
+			wantStatus: http.StatusUnauthorized,
+			wantReason: "unauthenticated",
+		},
+		{
+			name:       "role granted",
+			m:          RequireRoles("admin"),
+			principal:  &Principal{Subject: "alice", Roles: []string{"admin"}},
+			wantStatus: http.StatusOK,
+			wantReason: "granted",
+		},
+		{
+			name:       "role missing",
+			m:          RequireRoles("admin"),
+			principal:  &Principal{Subject: "alice", Roles: []string{"viewer"}},
+			wantStatus: http.StatusForbidden,
+			wantReason: "missing_role",
+		},
+		{
+			name:       "scope missing",
+			m:          RequireScopes("write"),
+			principal:  &Principal{Subject: "alice", Scopes: []string{"read"}},
+			wantStatus: http.StatusForbidden,
+			wantReason: "missing_scope",
+		},
+		{
+			name: "policy satisfied",
+			m: RequirePolicy(func(r *http.Request, _ *Principal) bool {
+				return r.Method == http.MethodGet
+			}),
+			principal:  &Principal{Subject: "alice"},
+			wantStatus: http.StatusOK,
+			wantReason: "granted",
+		},
+		{
+			name: "custom authorizer",
+			m: Authorization(AuthorizationOptions{
+				Authorizer: AuthorizerFunc(func(*http.Request, *Principal, Requirement) Decision {
+					return Deny("outside_business_hours")
+				}),
+			}),
+			principal:  &Principal{Subject: "alice"},
+			wantStatus: http.StatusForbidden,
+			wantReason: "outside_business_hours",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &testLogger{}
+			f := dr.New(dr.WithFormatter(formatter.NewJSON()), dr.WithLogger(logger))
+
+			mux := dr.NewServeMux(f)
+			mux.With(tt.m).HandleFunc("GET /reports/{id}", okHandler)
+
+			r := httptest.NewRequest(http.MethodGet, "/reports/1", nil)
+			if tt.principal != nil {
+				r = r.WithContext(WithPrincipal(r.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK && !strings.Contains(w.Body.String(), tt.wantReason) {
+				t.Errorf("body = %q, want reason %q", w.Body.String(), tt.wantReason)
+			}
+
+			entry, ok := logger.find("authorization decision")
+			if !ok {
+				t.Fatal("decision is not logged")
+			}
+			if entry.args["route"] != "GET /reports/{id}" || entry.args["reason"] != tt.wantReason {
+				t.Errorf("logged %v, want route %q and reason %q", entry.args, "GET /reports/{id}", tt.wantReason)
+			}
+		})
+	}
+}
+
+// Authorization of one route must not affect other routes of the mux.
+func TestAuthorization_ScopedToRoute(t *testing.T) {
+	mux := dr.NewServeMux(newTestFactory())
+	mux.With(RequireRoles("admin")).HandleFunc("GET /admin", okHandler)
+	mux.HandleFunc("GET /public", okHandler)
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/admin", wantStatus: http.StatusUnauthorized},
+		{target: "/public", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	// BodyLimitUnlimited disables the limit.
+	BodyLimitUnlimited int64 = -1
+
+	defaultBodyLimit = 1 << 20 // 1 MB
+)
+
+// BodyLimitOptions configures BodyLimit middleware.
+type BodyLimitOptions struct {
+	// Limit is the default maximum body size in bytes (default: 1 MB, BodyLimitUnlimited disables).
+	Limit int64
+
+	// Routes maps route patterns (http.Request.Pattern) to limits.
+	// Route limit takes precedence over content type and default limits.
+	Routes map[string]int64
+
+	// ContentTypes maps media types to limits, case-insensitive. Supports wildcards like "image/*".
+	// Content type limit takes precedence over the default limit.
+	ContentTypes map[string]int64
+
+	// Message is the error message (default: status text).
+	Message string
+}
+
+// BodyLimit creates a middleware that limits the request body size.
+// Requests with Content-Length over the limit are rejected before the handler is called,
+// bodies overflowing the limit while read by the handler produce 413 regardless of the handler response.
+func BodyLimit(opts BodyLimitOptions) dr.Middleware {
+	if opts.Limit == 0 {
+		opts.Limit = defaultBodyLimit
+	}
+
+	if opts.Message == "" {
+		opts.Message = http.StatusText(http.StatusRequestEntityTooLarge)
+	}
+
+	// Media types are looked up lowercased
+	if len(opts.ContentTypes) > 0 {
+		contentTypes := make(map[string]int64, len(opts.ContentTypes))
+		for contentType, limit := range opts.ContentTypes {
+			contentTypes[strings.ToLower(strings.TrimSpace(contentType))] = limit
+		}
+		opts.ContentTypes = contentTypes
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			limit := bodyLimitFor(r, opts)
+			if limit < 0 || r.Body == nil || r.Body == http.NoBody {
+				return next.Handle(r, f)
+			}
+
+			ctx := r.Context()
+
+			if r.ContentLength > limit {
+				f.Logger().Warn(ctx, "request body too large",
+					"content_length", r.ContentLength,
+					"limit", limit,
+					"path", r.URL.Path,
+				)
+
+				return f.Error(ctx, http.StatusRequestEntityTooLarge, opts.Message)
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(nil, r.Body, limit)}
+			r.Body = body
+
+			resp := next.Handle(r, f)
+			if !body.exceeded.Load() {
+				return resp
+			}
+
+			// The handler has hit the limit, replace whatever it returned (e.g. InternalError)
+			if err := resp.Close(); err != nil {
+				f.Logger().Warn(ctx, "failed to close response", "error", err.Error())
+			}
+
+			f.Logger().Warn(ctx, "request body too large",
+				"limit", limit,
+				"path", r.URL.Path,
+			)
+
+			return f.Error(ctx, http.StatusRequestEntityTooLarge, opts.Message)
+		})
+	}
+}
+
+// bodyLimitFor resolves the limit for the request.
+func bodyLimitFor(r *http.Request, opts BodyLimitOptions) int64 {
+	if limit, ok := opts.Routes[r.Pattern]; ok && r.Pattern != "" {
+		return limit
+	}
+
+	if len(opts.ContentTypes) > 0 {
+		contentType := r.Header.Get(response.HeaderContentType)
+		if idx := strings.Index(contentType, ";"); idx > -1 {
+			contentType = contentType[:idx]
+		}
+		contentType = strings.TrimSpace(strings.ToLower(contentType))
+
+		if limit, ok := opts.ContentTypes[contentType]; ok {
+			return limit
+		}
+
+		if idx := strings.Index(contentType, "/"); idx > -1 {
+			if limit, ok := opts.ContentTypes[contentType[:idx]+"/*"]; ok {
+				return limit
+			}
+		}
+	}
+
+	return opts.Limit
+}
+
+// limitedBody tracks whether the body limit was exceeded.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded atomic.Bool
+}
+
+// Read reads from the limited body and records the overflow.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	var maxBytesErr *http.MaxBytesError
+	if err != nil && errors.As(err, &maxBytesErr) {
+		b.exceeded.Store(true)
+	}
+
+	return n, err
+}
@@ -0,0 +1,169 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// readBodyHandler reads the whole body, failing with 500 on read errors.
+func readBodyHandler(called *bool) dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		*called = true
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return f.InternalError(r.Context(), err)
+		}
+
+		return f.Success(r.Context(), len(data))
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	opts := BodyLimitOptions{
+		Limit: 10,
+		ContentTypes: map[string]int64{
+			"application/json": 20,
+			"image/*":          BodyLimitUnlimited,
+			"Text/CSV":         30,
+			"Video/*":          BodyLimitUnlimited,
+		},
+		Routes: map[string]int64{
+			"POST /upload": 100,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		size        int
+		chunked     bool
+		wantStatus  int
+		wantCalled  bool
+	}{
+		{name: "under default limit", target: "/data", size: 10, wantStatus: http.StatusOK, wantCalled: true},
+		{name: "content length over limit", target: "/data", size: 11, wantStatus: http.StatusRequestEntityTooLarge},
+		{
+			name:       "chunked body over limit",
+			target:     "/data",
+			size:       11,
+			chunked:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCalled: true,
+		},
+		{
+			name:        "content type limit",
+			target:      "/data",
+			contentType: "application/json; charset=utf-8",
+			size:        20,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:        "content type over limit",
+			target:      "/data",
+			contentType: "Application/JSON",
+			size:        21,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "wildcard content type unlimited",
+			target:      "/data",
+			contentType: "image/png",
+			size:        1000,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:        "mixed case content type key",
+			target:      "/data",
+			contentType: "text/csv",
+			size:        30,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:        "mixed case content type key over limit",
+			target:      "/data",
+			contentType: "text/csv",
+			size:        31,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "mixed case wildcard key",
+			target:      "/data",
+			contentType: "video/mp4",
+			size:        1000,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:        "route limit takes precedence",
+			target:      "/upload",
+			contentType: "application/json",
+			size:        100,
+			wantStatus:  http.StatusOK,
+			wantCalled:  true,
+		},
+		{
+			name:       "route over limit",
+			target:     "/upload",
+			size:       101,
+			chunked:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+
+			mux := dr.NewServeMux(newTestFactory())
+			mux.WithMiddleware(BodyLimit(opts))
+			mux.HandleFunc("POST /data", readBodyHandler(&called))
+			mux.HandleFunc("POST /upload", readBodyHandler(&called))
+
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(strings.Repeat("a", tt.size)))
+			if tt.contentType != "" {
+				r.Header.Set(response.HeaderContentType, tt.contentType)
+			}
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCalled)
+			}
+		})
+	}
+}
+
+func TestBodyLimit_NoBody(t *testing.T) {
+	w := serve(BodyLimit(BodyLimitOptions{Limit: 1}), okHandler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
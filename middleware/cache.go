@@ -0,0 +1,466 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	defaultCacheMaxEntrySize = 1 << 20 // 1 MB
+
+	cacheHit   = "HIT"
+	cacheMiss  = "MISS"
+	cacheStale = "STALE"
+)
+
+// CacheEntry is a cached response.
+// An entry with Variants set and no body is a Vary marker pointing to the response variants.
+type CacheEntry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// Variants lists request headers the response varies by.
+	Variants []string
+
+	StoredAt time.Time
+
+	// MaxAge is how long the entry is fresh.
+	MaxAge time.Duration
+
+	// StaleWhileRevalidate is how long the stale entry may be served while it is refreshed.
+	StaleWhileRevalidate time.Duration
+}
+
+// Age returns the entry age.
+func (e *CacheEntry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Fresh returns true if the entry may be served without revalidation.
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	return e.Age(now) < e.MaxAge
+}
+
+// Usable returns true if the entry is fresh or may be served while it is revalidated.
+func (e *CacheEntry) Usable(now time.Time) bool {
+	return e.Age(now) < e.MaxAge+e.StaleWhileRevalidate
+}
+
+// TTL returns how long the entry should be kept by the store.
+func (e *CacheEntry) TTL() time.Duration {
+	return e.MaxAge + e.StaleWhileRevalidate
+}
+
+// size returns approximate memory size of the entry.
+func (e *CacheEntry) size() int64 {
+	size := int64(len(e.Body))
+	for key, values := range e.Header {
+		size += int64(len(key))
+		for _, value := range values {
+			size += int64(len(value))
+		}
+	}
+	for _, variant := range e.Variants {
+		size += int64(len(variant))
+	}
+
+	return size
+}
+
+// CacheStore persists cached responses.
+// Get returns nil entry without error if the key is not found or expired.
+//
+//go:generate mockery
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Set(ctx context.Context, key string, entry *CacheEntry) error
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheOptions configures Cache middleware.
+type CacheOptions struct {
+	// Store persists responses (default: in-memory LRU store with 1000 entries and 64 MB).
+	Store CacheStore
+
+	// Methods lists cacheable methods (default: GET, HEAD).
+	Methods []string
+
+	// StatusCodes lists cacheable status codes (default: 200).
+	StatusCodes []int
+
+	// DefaultTTL is used for responses without Cache-Control max-age (default: 0, not cached).
+	DefaultTTL time.Duration
+
+	// DefaultStaleWhileRevalidate is used for responses without Cache-Control stale-while-revalidate.
+	DefaultStaleWhileRevalidate time.Duration
+
+	// MaxEntrySize is the maximum body size of a cached response (default: 1 MB).
+	MaxEntrySize int64
+
+	// KeyFunc returns the base cache key (default: method, path and query).
+	KeyFunc func(r *http.Request) string
+}
+
+// Cache creates a middleware that caches formatted responses of read endpoints.
+// Responses are cached according to Cache-Control header set by the handler (see DataResponse.WithCacheControl):
+// no-store and private responses are never cached, s-maxage and max-age set freshness,
+// stale-while-revalidate allows serving the stale response while it is refreshed in background
+// by a single request per key. Responses are keyed by method, path, query and the request headers
+// listed in the response Vary header.
+//
+// Like a shared cache (RFC 9111, section 3.5), responses to requests with Authorization header
+// are stored and served only if they are marked public, s-maxage or must-revalidate.
+//
+// The background refresh handles a copy of the request with its path values, detached from its cancellation.
+// Router state reused after the request completes must not be read by the handler,
+// e.g. use http.Request.PathValue (mirrored by chiadapter) instead of chi.URLParam with plain chi.
+//
+// Register Cache before Compression to store compressed bodies, one per Accept-Encoding.
+func Cache(opts CacheOptions) dr.Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemoryCacheStore(MemoryCacheOptions{})
+	}
+
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+
+	if len(opts.StatusCodes) == 0 {
+		opts.StatusCodes = []int{http.StatusOK}
+	}
+
+	if opts.MaxEntrySize == 0 {
+		opts.MaxEntrySize = defaultCacheMaxEntrySize
+	}
+
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = defaultCacheKey
+	}
+
+	methodMap := make(map[string]bool, len(opts.Methods))
+	for _, method := range opts.Methods {
+		methodMap[strings.ToUpper(method)] = true
+	}
+
+	refreshes := &refreshGroup{inFlight: make(map[string]bool)}
+
+	return func(next dr.Handler) dr.Handler {
+		c := &cache{next: next, opts: opts}
+
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			if !methodMap[r.Method] {
+				return next.Handle(r, f)
+			}
+
+			ctx := r.Context()
+			baseKey := opts.KeyFunc(r)
+
+			entry, key, err := c.lookup(ctx, r, baseKey)
+			if err != nil {
+				f.Logger().Warn(ctx, "failed to get cached response",
+					"key", baseKey,
+					"error", err.Error(),
+				)
+			}
+
+			if entry != nil && !sharedAuthorized(r, entry.Header) {
+				entry = nil // Not allowed to be served to the request with Authorization
+			}
+
+			now := time.Now()
+			if entry != nil && entry.Usable(now) {
+				if entry.Fresh(now) {
+					return cachedResponse(entry, now, cacheHit)
+				}
+
+				if refreshes.start(key) {
+					// The request is cloned before the handler returns and the router reuses its state
+					detached := r.Clone(context.WithoutCancel(ctx))
+
+					go func() {
+						defer refreshes.done(key)
+						c.refresh(detached, f, baseKey)
+					}()
+				}
+
+				return cachedResponse(entry, now, cacheStale)
+			}
+
+			resp := next.Handle(r, f)
+			c.store(r, f, baseKey, resp)
+
+			return resp.SetHeader(response.HeaderXCache, cacheMiss)
+		})
+	}
+}
+
+// cache implements lookups and stores of Cache middleware.
+type cache struct {
+	next dr.Handler
+	opts CacheOptions
+}
+
+// lookup returns the entry for the request resolving the Vary marker.
+func (c *cache) lookup(ctx context.Context, r *http.Request, baseKey string) (*CacheEntry, string, error) {
+	entry, err := c.opts.Store.Get(ctx, baseKey)
+	if err != nil || entry == nil {
+		return nil, baseKey, err
+	}
+
+	if len(entry.Variants) == 0 {
+		return entry, baseKey, nil
+	}
+
+	key := variantKey(baseKey, entry.Variants, r.Header)
+	entry, err = c.opts.Store.Get(ctx, key)
+
+	return entry, key, err
+}
+
+// refresh handles the request in background and stores the fresh response.
+func (c *cache) refresh(r *http.Request, f *dr.Factory, baseKey string) {
+	ctx := r.Context()
+
+	defer func() {
+		if p := recover(); p != nil {
+			f.Logger().Error(ctx, "cache refresh panicked", "key", baseKey, "panic", p)
+		}
+	}()
+
+	resp := c.next.Handle(r, f)
+	defer func() {
+		if err := resp.Close(); err != nil {
+			f.Logger().Warn(ctx, "failed to close refreshed response", "error", err.Error())
+		}
+	}()
+
+	c.store(r, f, baseKey, resp)
+
+	f.Logger().Debug(ctx, "cached response refreshed", "key", baseKey)
+}
+
+// store caches the response if it is cacheable.
+func (c *cache) store(r *http.Request, f *dr.Factory, baseKey string, resp *response.DataResponse) {
+	ctx := r.Context()
+
+	if !slices.Contains(c.opts.StatusCodes, resp.StatusCode()) || resp.HasHeader(response.HeaderSetCookie) || resp.IsStream() {
+		return
+	}
+
+	if !sharedAuthorized(r, resp.Header()) {
+		return
+	}
+
+	directives := parseCacheControl(resp.HeaderLine(response.HeaderCacheControl))
+	maxAge, staleWhileRevalidate, ok := cacheLifetime(directives, c.opts)
+	if !ok {
+		return
+	}
+
+	variants := parseHeaderList(strings.Join(resp.HeaderValues(response.HeaderVary), ","))
+	if slices.Contains(variants, "*") {
+		return
+	}
+
+	buffered, ok, err := bufferResponse(resp, c.opts.MaxEntrySize)
+	if err != nil {
+		f.Logger().Error(ctx, "failed to format response for cache",
+			"key", baseKey,
+			"error", err.Error(),
+		)
+
+		return
+	}
+
+	if !ok {
+		f.Logger().Debug(ctx, "response is too large to be cached", "key", baseKey)
+
+		return
+	}
+
+	now := time.Now()
+	entry := &CacheEntry{
+		StatusCode:           buffered.StatusCode,
+		Header:               buffered.Header,
+		Body:                 buffered.Body,
+		StoredAt:             now,
+		MaxAge:               maxAge,
+		StaleWhileRevalidate: staleWhileRevalidate,
+	}
+
+	key := baseKey
+	if len(variants) > 0 {
+		marker := &CacheEntry{
+			Variants:             variants,
+			StoredAt:             now,
+			MaxAge:               maxAge,
+			StaleWhileRevalidate: staleWhileRevalidate,
+		}
+		if err := c.opts.Store.Set(ctx, baseKey, marker); err != nil {
+			f.Logger().Warn(ctx, "failed to store cached response", "key", baseKey, "error", err.Error())
+
+			return
+		}
+
+		key = variantKey(baseKey, variants, r.Header)
+	}
+
+	if err := c.opts.Store.Set(ctx, key, entry); err != nil {
+		f.Logger().Warn(ctx, "failed to store cached response", "key", key, "error", err.Error())
+	}
+}
+
+// cacheLifetime resolves freshness of the response, returns false if it must not be cached.
+func cacheLifetime(directives map[string]string, opts CacheOptions) (maxAge, staleWhileRevalidate time.Duration, ok bool) {
+	if _, noStore := directives["no-store"]; noStore {
+		return 0, 0, false
+	}
+
+	if _, private := directives["private"]; private {
+		return 0, 0, false
+	}
+
+	if _, noCache := directives["no-cache"]; noCache {
+		return 0, 0, false
+	}
+
+	maxAge = opts.DefaultTTL
+	if seconds, found := cacheControlSeconds(directives, "s-maxage"); found {
+		maxAge = seconds
+	} else if seconds, found := cacheControlSeconds(directives, "max-age"); found {
+		maxAge = seconds
+	}
+
+	staleWhileRevalidate = opts.DefaultStaleWhileRevalidate
+	if seconds, found := cacheControlSeconds(directives, "stale-while-revalidate"); found {
+		staleWhileRevalidate = seconds
+	}
+
+	return maxAge, staleWhileRevalidate, maxAge > 0
+}
+
+// sharedAuthorized reports whether the response with the header may be stored for or served to the request,
+// responses to requests with Authorization header must be marked public, s-maxage or must-revalidate
+// (RFC 9111, section 3.5).
+func sharedAuthorized(r *http.Request, header http.Header) bool {
+	if r.Header.Get(response.HeaderAuthorization) == "" {
+		return true
+	}
+
+	directives := parseCacheControl(strings.Join(header.Values(response.HeaderCacheControl), ","))
+	for _, name := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := directives[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCacheControl parses Cache-Control header into lower-cased directives.
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return directives
+}
+
+// cacheControlSeconds returns the delta-seconds directive value.
+func cacheControlSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	value, ok := directives[name]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// cachedResponse creates a response replaying the cache entry.
+func cachedResponse(entry *CacheEntry, now time.Time, status string) *response.DataResponse {
+	buffered := bufferedResponse{
+		StatusCode: entry.StatusCode,
+		Header:     entry.Header,
+		Body:       entry.Body,
+	}
+
+	return buffered.DataResponse().
+		SetHeader(response.HeaderAge, strconv.Itoa(int(entry.Age(now).Seconds()))).
+		SetHeader(response.HeaderXCache, status)
+}
+
+// defaultCacheKey builds the key from method, path and query.
+func defaultCacheKey(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI()
+}
+
+// variantKey extends the base key with values of the request headers the response varies by.
+func variantKey(baseKey string, variants []string, header http.Header) string {
+	var sb strings.Builder
+	sb.WriteString(baseKey)
+
+	for _, name := range variants {
+		sb.WriteString("\n")
+		sb.WriteString(strings.ToLower(name))
+		sb.WriteString(":")
+		sb.WriteString(strings.Join(header.Values(name), ","))
+	}
+
+	return sb.String()
+}
+
+// refreshGroup allows a single background refresh per key.
+type refreshGroup struct {
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+// start returns false if the key is already being refreshed.
+func (g *refreshGroup) start(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.inFlight[key] {
+		return false
+	}
+	g.inFlight[key] = true
+
+	return true
+}
+
+func (g *refreshGroup) done(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.inFlight, key)
+}
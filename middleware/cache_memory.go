@@ -0,0 +1,146 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemoryCacheMaxEntries = 1000
+	defaultMemoryCacheMaxBytes   = 64 << 20 // 64 MB
+)
+
+// MemoryCacheOptions configures MemoryCacheStore.
+type MemoryCacheOptions struct {
+	// MaxEntries is the maximum number of entries (default: 1000).
+	MaxEntries int
+
+	// MaxBytes is the maximum total size of entries (default: 64 MB).
+	MaxBytes int64
+}
+
+// MemoryCacheStore is an in-memory CacheStore evicting least recently used entries.
+type MemoryCacheStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	items      map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+	size  int64
+}
+
+// NewMemoryCacheStore creates a new in-memory LRU store.
+func NewMemoryCacheStore(opts MemoryCacheOptions) *MemoryCacheStore {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultMemoryCacheMaxEntries
+	}
+
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultMemoryCacheMaxBytes
+	}
+
+	return &MemoryCacheStore{
+		maxEntries: opts.MaxEntries,
+		maxBytes:   opts.MaxBytes,
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get returns the entry or nil if not found or expired.
+func (s *MemoryCacheStore) Get(_ context.Context, key string) (*CacheEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	item := elem.Value.(*memoryCacheItem)
+	if !item.entry.Usable(time.Now()) {
+		s.remove(elem)
+
+		return nil, nil
+	}
+
+	s.lru.MoveToFront(elem)
+
+	return item.entry, nil
+}
+
+// Set stores the entry evicting least recently used entries over the limits.
+// Entries larger than MaxBytes are not stored.
+func (s *MemoryCacheStore) Set(_ context.Context, key string, entry *CacheEntry) error {
+	size := int64(len(key)) + entry.size()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+
+	if size > s.maxBytes {
+		return nil
+	}
+
+	s.items[key] = s.lru.PushFront(&memoryCacheItem{key: key, entry: entry, size: size})
+	s.bytes += size
+
+	for s.lru.Len() > s.maxEntries || s.bytes > s.maxBytes {
+		s.remove(s.lru.Back())
+	}
+
+	return nil
+}
+
+// Delete removes the entry.
+func (s *MemoryCacheStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+
+	return nil
+}
+
+// Len returns the number of entries.
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Len()
+}
+
+// Size returns the total size of entries in bytes.
+func (s *MemoryCacheStore) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bytes
+}
+
+func (s *MemoryCacheStore) remove(elem *list.Element) {
+	item := s.lru.Remove(elem).(*memoryCacheItem)
+	delete(s.items, item.key)
+	s.bytes -= item.size
+}
@@ -0,0 +1,373 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// cacheRequest is a request sent to the cached endpoint.
+type cacheRequest struct {
+	method string
+	target string
+	header map[string]string
+}
+
+func (cr cacheRequest) build(ctx context.Context) *http.Request {
+	method, target := cr.method, cr.target
+	if method == "" {
+		method = http.MethodGet
+	}
+	if target == "" {
+		target = "/items"
+	}
+
+	r := httptest.NewRequestWithContext(ctx, method, target, nil)
+	for name, value := range cr.header {
+		r.Header.Set(name, value)
+	}
+
+	return r
+}
+
+// countingHandler responds with the calls counter and the given response headers.
+func countingHandler(calls *atomic.Int32, status int, header map[string]string) dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		n := calls.Add(1)
+
+		resp := f.CreateDataResponse(status, map[string]any{"call": n, "lang": r.Header.Get(response.HeaderAcceptLanguage)})
+		for name, value := range header {
+			resp.SetHeader(name, value)
+		}
+
+		return resp
+	}
+}
+
+func TestCache(t *testing.T) {
+	bearer := map[string]string{response.HeaderAuthorization: "Bearer token"}
+
+	tests := []struct {
+		name      string
+		opts      CacheOptions
+		status    int
+		header    map[string]string
+		requests  []cacheRequest
+		wantCache []string
+		wantCalls int32
+	}{
+		{
+			name:      "max-age",
+			header:    map[string]string{response.HeaderCacheControl: "max-age=60"},
+			requests:  []cacheRequest{{}, {}, {}},
+			wantCache: []string{cacheMiss, cacheHit, cacheHit},
+			wantCalls: 1,
+		},
+		{
+			name:      "s-maxage",
+			header:    map[string]string{response.HeaderCacheControl: "s-maxage=60, max-age=0"},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheHit},
+			wantCalls: 1,
+		},
+		{
+			name:      "no-store",
+			header:    map[string]string{response.HeaderCacheControl: "no-store, max-age=60"},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheMiss},
+			wantCalls: 2,
+		},
+		{
+			name:      "private",
+			header:    map[string]string{response.HeaderCacheControl: "private, max-age=60"},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheMiss},
+			wantCalls: 2,
+		},
+		{
+			name:      "without cache control",
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheMiss},
+			wantCalls: 2,
+		},
+		{
+			name:      "default ttl",
+			opts:      CacheOptions{DefaultTTL: time.Minute},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheHit},
+			wantCalls: 1,
+		},
+		{
+			name:      "not cacheable status",
+			status:    http.StatusNotFound,
+			header:    map[string]string{response.HeaderCacheControl: "max-age=60"},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheMiss},
+			wantCalls: 2,
+		},
+		{
+			name: "set cookie",
+			header: map[string]string{
+				response.HeaderCacheControl: "max-age=60",
+				response.HeaderSetCookie:    "session=1",
+			},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheMiss},
+			wantCalls: 2,
+		},
+		{
+			name:      "not cacheable method",
+			header:    map[string]string{response.HeaderCacheControl: "max-age=60"},
+			requests:  []cacheRequest{{method: http.MethodPost}, {method: http.MethodPost}},
+			wantCache: []string{"", ""},
+			wantCalls: 2,
+		},
+		{
+			name:      "query is part of the key",
+			header:    map[string]string{response.HeaderCacheControl: "max-age=60"},
+			requests:  []cacheRequest{{target: "/items?page=1"}, {target: "/items?page=2"}, {target: "/items?page=1"}},
+			wantCache: []string{cacheMiss, cacheMiss, cacheHit},
+			wantCalls: 2,
+		},
+		{
+			name: "vary",
+			header: map[string]string{
+				response.HeaderCacheControl: "max-age=60",
+				response.HeaderVary:         response.HeaderAcceptLanguage,
+			},
+			requests: []cacheRequest{
+				{header: map[string]string{response.HeaderAcceptLanguage: "en"}},
+				{header: map[string]string{response.HeaderAcceptLanguage: "de"}},
+				{header: map[string]string{response.HeaderAcceptLanguage: "en"}},
+				{header: map[string]string{response.HeaderAcceptLanguage: "de"}},
+			},
+			wantCache: []string{cacheMiss, cacheMiss, cacheHit, cacheHit},
+			wantCalls: 2,
+		},
+		{
+			name: "vary any",
+			header: map[string]string{
+				response.HeaderCacheControl: "max-age=60",
+				response.HeaderVary:         "*",
+			},
+			requests:  []cacheRequest{{}, {}},
+			wantCache: []string{cacheMiss, cacheMiss},
+			wantCalls: 2,
+		},
+		{
+			name:      "authorization is not stored",
+			header:    map[string]string{response.HeaderCacheControl: "max-age=60"},
+			requests:  []cacheRequest{{header: bearer}, {header: bearer}, {}},
+			wantCache: []string{cacheMiss, cacheMiss, cacheMiss},
+			wantCalls: 3,
+		},
+		{
+			name:      "authorization is not served from cache",
+			header:    map[string]string{response.HeaderCacheControl: "max-age=60"},
+			requests:  []cacheRequest{{}, {header: bearer}, {}},
+			wantCache: []string{cacheMiss, cacheMiss, cacheHit},
+			wantCalls: 2,
+		},
+		{
+			name:      "authorization with public response",
+			header:    map[string]string{response.HeaderCacheControl: "public, max-age=60"},
+			requests:  []cacheRequest{{header: bearer}, {header: bearer}, {}},
+			wantCache: []string{cacheMiss, cacheHit, cacheHit},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			var calls atomic.Int32
+			m := Cache(tt.opts)
+			h := countingHandler(&calls, status, tt.header)
+
+			bodies := make([]string, len(tt.requests))
+			for i, cr := range tt.requests {
+				w := serve(m, h, cr.build(t.Context()))
+				bodies[i] = w.Body.String()
+
+				if w.Code != status {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, status)
+				}
+				if got := w.Header().Get(response.HeaderXCache); got != tt.wantCache[i] {
+					t.Errorf("request %d: X-Cache = %q, want %q", i, got, tt.wantCache[i])
+				}
+				if tt.wantCache[i] == cacheHit && w.Header().Get(response.HeaderAge) == "" {
+					t.Errorf("request %d: Age header is missing", i)
+				}
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	store := NewMemoryCacheStore(MemoryCacheOptions{})
+	m := Cache(CacheOptions{Store: store})
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	refreshed := make(chan struct{})
+	h := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		n := calls.Add(1)
+		if n == 2 {
+			<-release
+			defer close(refreshed)
+		}
+
+		return f.Success(r.Context(), n).
+			WithCacheControl("max-age=1, stale-while-revalidate=60")
+	}
+
+	if w := serve(m, h, cacheRequest{}.build(t.Context())); w.Header().Get(response.HeaderXCache) != cacheMiss {
+		t.Fatalf("X-Cache = %q, want %q", w.Header().Get(response.HeaderXCache), cacheMiss)
+	}
+
+	// Make the entry stale
+	entry, err := store.Get(t.Context(), "GET /items")
+	if err != nil || entry == nil {
+		t.Fatalf("entry is not stored: %v", err)
+	}
+	entry.StoredAt = entry.StoredAt.Add(-2 * time.Second)
+
+	// Stale responses are served while a single refresh runs, even after the request is canceled
+	for i := range 3 {
+		ctx, cancel := context.WithCancel(t.Context())
+		w := serve(m, h, cacheRequest{}.build(ctx))
+		cancel()
+
+		if got := w.Header().Get(response.HeaderXCache); got != cacheStale {
+			t.Fatalf("request %d: X-Cache = %q, want %q", i, got, cacheStale)
+		}
+		if strings.TrimSpace(w.Body.String()) != "1" {
+			t.Errorf("request %d: body = %q, want the stale one", i, w.Body.String())
+		}
+	}
+
+	close(release)
+	<-refreshed
+
+	// The refreshed response is stored after the handler returns
+	deadline := time.Now().Add(time.Second)
+	for {
+		w := serve(m, h, cacheRequest{}.build(t.Context()))
+		if w.Header().Get(response.HeaderXCache) == cacheHit && strings.TrimSpace(w.Body.String()) == "2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("refreshed response is not served: X-Cache = %q, body = %q",
+				w.Header().Get(response.HeaderXCache), w.Body.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler calls = %d, want 2", got)
+	}
+}
+
+func TestMemoryCacheStore_Eviction(t *testing.T) {
+	entry := func(body string) *CacheEntry {
+		return &CacheEntry{Body: []byte(body), StoredAt: time.Now(), MaxAge: time.Minute}
+	}
+
+	tests := []struct {
+		name     string
+		opts     MemoryCacheOptions
+		set      []string
+		get      []string
+		setAfter []string
+		want     map[string]bool
+	}{
+		{
+			name:     "max entries evicts least recently used",
+			opts:     MemoryCacheOptions{MaxEntries: 2},
+			set:      []string{"a", "b"},
+			get:      []string{"a"},
+			setAfter: []string{"c"},
+			want:     map[string]bool{"a": true, "b": false, "c": true},
+		},
+		{
+			name:     "max bytes",
+			opts:     MemoryCacheOptions{MaxBytes: 25},
+			set:      []string{"a", "b"},
+			setAfter: []string{"c"},
+			want:     map[string]bool{"a": false, "b": true, "c": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryCacheStore(tt.opts)
+			ctx := t.Context()
+
+			for _, key := range tt.set {
+				if err := store.Set(ctx, key, entry(strings.Repeat(key, 10))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, key := range tt.get {
+				if _, err := store.Get(ctx, key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, key := range tt.setAfter {
+				if err := store.Set(ctx, key, entry(strings.Repeat(key, 10))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for key, want := range tt.want {
+				got, err := store.Get(ctx, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (got != nil) != want {
+					t.Errorf("Get(%q) found = %v, want %v", key, got != nil, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryCacheStore_Expiration(t *testing.T) {
+	store := NewMemoryCacheStore(MemoryCacheOptions{})
+	ctx := t.Context()
+
+	expired := &CacheEntry{StoredAt: time.Now().Add(-time.Minute), MaxAge: time.Second}
+	if err := store.Set(ctx, "expired", expired); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := store.Get(ctx, "expired"); err != nil || got != nil {
+		t.Errorf("Get() = %v, %v, want nil", got, err)
+	}
+	if store.Len() != 0 || store.Size() != 0 {
+		t.Errorf("Len() = %d, Size() = %d, want 0", store.Len(), store.Size())
+	}
+}
@@ -0,0 +1,84 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// bufferedResponse is a fully formatted response kept in memory.
+type bufferedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// bufferResponse formats the response and reads its body into memory.
+// The response stays writable: its formatted body is replaced with the buffered one.
+// It returns false if the body is larger than maxSize (maxSize <= 0 means unlimited).
+func bufferResponse(resp *response.DataResponse, maxSize int64) (bufferedResponse, bool, error) {
+	formattedResp, err := resp.Body()
+	if err != nil {
+		return bufferedResponse{}, false, err
+	}
+
+	var body []byte
+	if formattedResp.Stream != nil {
+		reader := formattedResp.Stream
+		if maxSize > 0 {
+			reader = io.LimitReader(reader, maxSize+1)
+		}
+
+		body, err = io.ReadAll(reader)
+		if err != nil {
+			return bufferedResponse{}, false, err
+		}
+	}
+
+	if maxSize > 0 && int64(len(body)) > maxSize {
+		// Too large, give back the read part followed by the rest of the stream
+		resp.WithFormatted(response.FormattedResponse{
+			Stream:     io.MultiReader(bytes.NewReader(body), formattedResp.Stream),
+			StreamSize: formattedResp.StreamSize,
+		})
+
+		return bufferedResponse{}, false, nil
+	}
+
+	resp.WithFormatted(response.FormattedResponse{
+		Stream:     bytes.NewReader(body),
+		StreamSize: int64(len(body)),
+	})
+
+	header := resp.Header().Clone()
+	if resp.Filename() != "" {
+		header.Set(response.HeaderContentDisposition, `attachment; filename="`+resp.Filename()+`"`)
+	}
+
+	return bufferedResponse{
+		StatusCode: resp.StatusCode(),
+		Header:     header,
+		Body:       body,
+	}, true, nil
+}
+
+// DataResponse creates a new response replaying the buffered one.
+func (b bufferedResponse) DataResponse() *response.DataResponse {
+	resp := response.NewDataResponse(b.StatusCode, nil).
+		WithHeaders(b.Header)
+
+	return resp.WithFormatted(response.FormattedResponse{
+		Stream:     bytes.NewReader(b.Body),
+		StreamSize: int64(len(b.Body)),
+	})
+}
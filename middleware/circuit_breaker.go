@@ -0,0 +1,349 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	defaultCircuitFailureRatio     = 0.5
+	defaultCircuitMinRequests      = 20
+	defaultCircuitWindow           = 10 * time.Second
+	defaultCircuitOpenTimeout      = 30 * time.Second
+	defaultCircuitHalfOpenRequests = 1
+
+	circuitWindowBuckets = 10
+)
+
+// CircuitState is a state of the circuit breaker.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	// CircuitClosed passes requests and counts failures.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects requests until the open timeout elapses.
+	CircuitOpen
+
+	// CircuitHalfOpen passes a limited number of probe requests.
+	CircuitHalfOpen
+)
+
+// String returns the string representation of CircuitState.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerMetrics receives state changes of the circuits, e.g. to export the state of every route as a gauge.
+//
+//go:generate mockery
+type CircuitBreakerMetrics interface {
+	CircuitStateChanged(route string, from, to CircuitState)
+}
+
+// CircuitBreakerOptions configures CircuitBreaker middleware.
+type CircuitBreakerOptions struct {
+	// FailureRatio is the ratio of failed requests within the window that opens the circuit (default: 0.5).
+	FailureRatio float64
+
+	// MinRequests is the minimum number of requests within the window to evaluate the ratio (default: 20).
+	MinRequests int
+
+	// Window is the rolling window of counted requests (default: 10s).
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before probing (default: 30s).
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of probe requests, all must succeed to close the circuit (default: 1).
+	HalfOpenRequests int
+
+	// IsFailure classifies the response (default: status code >= 500).
+	IsFailure func(resp *response.DataResponse) bool
+
+	// KeyFunc returns the circuit key (default: route pattern, so every route has its own circuit).
+	KeyFunc func(r *http.Request) string
+
+	// Metrics receives state changes (not reported if nil).
+	Metrics CircuitBreakerMetrics
+
+	// Message is the error message (default: status text).
+	Message string
+}
+
+// CircuitBreaker creates a middleware that stops calling failing routes.
+// When the ratio of failed responses within the window reaches FailureRatio the circuit opens
+// and requests get 503 Service Unavailable with Retry-After until OpenTimeout elapses.
+// Then probe requests are passed, their success closes the circuit, a failure opens it again.
+// State changes are logged and reported to Metrics.
+func CircuitBreaker(opts CircuitBreakerOptions) dr.Middleware {
+	if opts.FailureRatio <= 0 {
+		opts.FailureRatio = defaultCircuitFailureRatio
+	}
+
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = defaultCircuitMinRequests
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = defaultCircuitWindow
+	}
+
+	// Every bucket of the window spans at least a nanosecond
+	opts.Window = max(opts.Window, circuitWindowBuckets)
+
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = defaultCircuitOpenTimeout
+	}
+
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = defaultCircuitHalfOpenRequests
+	}
+
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(resp *response.DataResponse) bool {
+			return resp.StatusCode() >= http.StatusInternalServerError
+		}
+	}
+
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = func(r *http.Request) string {
+			return r.Pattern
+		}
+	}
+
+	if opts.Message == "" {
+		opts.Message = http.StatusText(http.StatusServiceUnavailable)
+	}
+
+	var (
+		mu       sync.Mutex
+		breakers = make(map[string]*circuitBreaker)
+	)
+
+	breakerFor := func(key string) *circuitBreaker {
+		mu.Lock()
+		defer mu.Unlock()
+
+		cb, ok := breakers[key]
+		if !ok {
+			cb = newCircuitBreaker(opts)
+			breakers[key] = cb
+		}
+
+		return cb
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			ctx := r.Context()
+			key := opts.KeyFunc(r)
+			cb := breakerFor(key)
+
+			report := func(change circuitChange) {
+				if change.from == change.to {
+					return
+				}
+
+				f.Logger().Warn(ctx, "circuit state changed",
+					"route", key,
+					"from", change.from.String(),
+					"to", change.to.String(),
+				)
+
+				if opts.Metrics != nil {
+					opts.Metrics.CircuitStateChanged(key, change.from, change.to)
+				}
+			}
+
+			allowed, retryAfter, change := cb.allow(time.Now())
+			report(change)
+
+			if !allowed {
+				f.Logger().Debug(ctx, "request rejected by open circuit", "route", key)
+
+				return f.ServiceUnavailable(ctx, opts.Message).
+					SetHeader(response.HeaderRetryAfter, retryAfterSeconds(retryAfter))
+			}
+
+			recorded := false
+			defer func() {
+				if !recorded {
+					// The handler panicked, count it as a failure so the probe slot is released
+					report(cb.record(time.Now(), true))
+				}
+			}()
+
+			resp := next.Handle(r, f)
+			recorded = true
+			report(cb.record(time.Now(), opts.IsFailure(resp)))
+
+			return resp
+		})
+	}
+}
+
+// circuitChange is a state transition, from equals to if the state has not changed.
+type circuitChange struct {
+	from CircuitState
+	to   CircuitState
+}
+
+// circuitBucket counts requests of a window slice.
+type circuitBucket struct {
+	epoch    int64
+	total    int
+	failures int
+}
+
+// circuitBreaker is the state of a single circuit.
+type circuitBreaker struct {
+	mu   sync.Mutex
+	opts CircuitBreakerOptions
+
+	state    CircuitState
+	openedAt time.Time
+	buckets  [circuitWindowBuckets]circuitBucket
+
+	probes    int
+	successes int
+}
+
+func newCircuitBreaker(opts CircuitBreakerOptions) *circuitBreaker {
+	return &circuitBreaker{opts: opts}
+}
+
+// allow decides whether the request may pass, returns the time to wait if it may not.
+func (cb *circuitBreaker) allow(now time.Time) (bool, time.Duration, circuitChange) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	change := circuitChange{from: cb.state, to: cb.state}
+
+	if cb.state == CircuitOpen {
+		reopen := cb.openedAt.Add(cb.opts.OpenTimeout)
+		if now.Before(reopen) {
+			return false, reopen.Sub(now), change
+		}
+
+		cb.setState(CircuitHalfOpen, now)
+		change.to = CircuitHalfOpen
+	}
+
+	if cb.state == CircuitHalfOpen {
+		if cb.probes >= cb.opts.HalfOpenRequests {
+			return false, 0, change
+		}
+		cb.probes++
+	}
+
+	return true, 0, change
+}
+
+// record counts the request result and trips or resets the circuit.
+func (cb *circuitBreaker) record(now time.Time, failure bool) circuitChange {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	change := circuitChange{from: cb.state, to: cb.state}
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if failure {
+			cb.setState(CircuitOpen, now)
+		} else {
+			cb.successes++
+			if cb.successes >= cb.opts.HalfOpenRequests {
+				cb.setState(CircuitClosed, now)
+			}
+		}
+	case CircuitClosed:
+		bucket := cb.bucket(now)
+		bucket.total++
+		if failure {
+			bucket.failures++
+		}
+
+		total, failures := cb.counts(now)
+		if total >= cb.opts.MinRequests && float64(failures)/float64(total) >= cb.opts.FailureRatio {
+			cb.setState(CircuitOpen, now)
+		}
+	case CircuitOpen:
+		// Late result of a request passed before the circuit opened
+	}
+
+	change.to = cb.state
+
+	return change
+}
+
+func (cb *circuitBreaker) setState(state CircuitState, now time.Time) {
+	cb.state = state
+	cb.probes = 0
+	cb.successes = 0
+
+	switch state {
+	case CircuitOpen:
+		cb.openedAt = now
+	case CircuitClosed:
+		cb.buckets = [circuitWindowBuckets]circuitBucket{}
+	case CircuitHalfOpen:
+	}
+}
+
+// bucket returns the current bucket resetting it if it belongs to an old window slice.
+func (cb *circuitBreaker) bucket(now time.Time) *circuitBucket {
+	epoch := cb.epoch(now)
+	bucket := &cb.buckets[epoch%circuitWindowBuckets]
+	if bucket.epoch != epoch {
+		*bucket = circuitBucket{epoch: epoch}
+	}
+
+	return bucket
+}
+
+// counts sums the buckets within the window.
+func (cb *circuitBreaker) counts(now time.Time) (total, failures int) {
+	epoch := cb.epoch(now)
+	for _, bucket := range cb.buckets {
+		if epoch-bucket.epoch < circuitWindowBuckets {
+			total += bucket.total
+			failures += bucket.failures
+		}
+	}
+
+	return total, failures
+}
+
+func (cb *circuitBreaker) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(cb.opts.Window/circuitWindowBuckets)
+}
+
+// retryAfterSeconds formats the duration for Retry-After header, rounding up to at least one second.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
@@ -0,0 +1,371 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// circuitTransition is a state change recorded by circuitMetrics.
+type circuitTransition struct {
+	route string
+	from  CircuitState
+	to    CircuitState
+}
+
+// circuitMetrics records circuit state changes.
+type circuitMetrics struct {
+	mu          sync.Mutex
+	transitions []circuitTransition
+}
+
+func (m *circuitMetrics) CircuitStateChanged(route string, from, to CircuitState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.transitions = append(m.transitions, circuitTransition{route: route, from: from, to: to})
+}
+
+// circuitStep is a request sent through the circuit breaker.
+type circuitStep struct {
+	status     int           // Status returned by the handler
+	wait       time.Duration // Delay before the request
+	wantStatus int
+}
+
+// statusHandler responds with the status of the current step.
+func statusHandler(calls *atomic.Int32, status *int) dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		calls.Add(1)
+
+		return f.CreateDataResponse(*status, "result")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const openTimeout = 50 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		opts      CircuitBreakerOptions
+		steps     []circuitStep
+		wantCalls int32
+	}{
+		{
+			name: "opens when failure ratio is reached",
+			opts: CircuitBreakerOptions{MinRequests: 4, FailureRatio: 0.5},
+			steps: []circuitStep{
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+				{status: http.StatusBadGateway, wantStatus: http.StatusBadGateway},
+				{status: http.StatusOK, wantStatus: http.StatusServiceUnavailable},
+				{status: http.StatusOK, wantStatus: http.StatusServiceUnavailable},
+			},
+			wantCalls: 4,
+		},
+		{
+			name: "stays closed below min requests",
+			opts: CircuitBreakerOptions{MinRequests: 4},
+			steps: []circuitStep{
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+			},
+			wantCalls: 4,
+		},
+		{
+			name: "stays closed below failure ratio",
+			opts: CircuitBreakerOptions{MinRequests: 2, FailureRatio: 0.75},
+			steps: []circuitStep{
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+			},
+			wantCalls: 4,
+		},
+		{
+			name: "window shorter than its buckets",
+			opts: CircuitBreakerOptions{MinRequests: 2, Window: 5},
+			steps: []circuitStep{
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "client errors are not failures",
+			opts: CircuitBreakerOptions{MinRequests: 2},
+			steps: []circuitStep{
+				{status: http.StatusNotFound, wantStatus: http.StatusNotFound},
+				{status: http.StatusBadRequest, wantStatus: http.StatusBadRequest},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "custom failure classifier",
+			opts: CircuitBreakerOptions{
+				MinRequests: 2,
+				IsFailure: func(resp *response.DataResponse) bool {
+					return resp.StatusCode() == http.StatusTooManyRequests
+				},
+			},
+			steps: []circuitStep{
+				{status: http.StatusTooManyRequests, wantStatus: http.StatusTooManyRequests},
+				{status: http.StatusTooManyRequests, wantStatus: http.StatusTooManyRequests},
+				{status: http.StatusOK, wantStatus: http.StatusServiceUnavailable},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "successful probe closes the circuit",
+			opts: CircuitBreakerOptions{MinRequests: 2, OpenTimeout: openTimeout},
+			steps: []circuitStep{
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusServiceUnavailable},
+				{status: http.StatusOK, wait: 2 * openTimeout, wantStatus: http.StatusOK},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusOK},
+			},
+			wantCalls: 5,
+		},
+		{
+			name: "failed probe opens the circuit again",
+			opts: CircuitBreakerOptions{MinRequests: 2, OpenTimeout: openTimeout},
+			steps: []circuitStep{
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError, wait: 2 * openTimeout, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusServiceUnavailable},
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "all probes must succeed",
+			opts: CircuitBreakerOptions{MinRequests: 1, OpenTimeout: openTimeout, HalfOpenRequests: 2},
+			steps: []circuitStep{
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wait: 2 * openTimeout, wantStatus: http.StatusOK},
+				{status: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError},
+				{status: http.StatusOK, wantStatus: http.StatusServiceUnavailable},
+			},
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				calls  atomic.Int32
+				status int
+			)
+			m := CircuitBreaker(tt.opts)
+			h := statusHandler(&calls, &status)
+
+			for i, step := range tt.steps {
+				time.Sleep(step.wait)
+				status = step.status
+
+				w := serve(m, h, httptest.NewRequest(http.MethodGet, "/", nil))
+				if w.Code != step.wantStatus {
+					t.Fatalf("step %d: status = %d, want %d", i, w.Code, step.wantStatus)
+				}
+
+				retryAfter := w.Header().Get(response.HeaderRetryAfter)
+				if (step.wantStatus == http.StatusServiceUnavailable) != (retryAfter != "") {
+					t.Errorf("step %d: Retry-After = %q", i, retryAfter)
+				}
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name        string
+		openTimeout time.Duration
+		want        string
+	}{
+		{name: "default", want: "30"},
+		{name: "rounded up", openTimeout: 1500 * time.Millisecond, want: "2"},
+		{name: "at least one second", openTimeout: 100 * time.Millisecond, want: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := CircuitBreaker(CircuitBreakerOptions{MinRequests: 1, OpenTimeout: tt.openTimeout})
+			failing := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+				return f.Error(r.Context(), http.StatusInternalServerError, "failed")
+			}
+
+			serve(m, failing, httptest.NewRequest(http.MethodGet, "/", nil))
+			w := serve(m, failing, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+			}
+			if got := w.Header().Get(response.HeaderRetryAfter); got != tt.want {
+				t.Errorf("Retry-After = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_StateChanges(t *testing.T) {
+	const openTimeout = 50 * time.Millisecond
+
+	metrics := &circuitMetrics{}
+	logger := &testLogger{}
+	f := dr.New(dr.WithFormatter(formatter.NewJSON()), dr.WithLogger(logger))
+
+	var (
+		calls  atomic.Int32
+		status int
+	)
+	mux := dr.NewServeMux(f).With(CircuitBreaker(CircuitBreakerOptions{
+		MinRequests: 1,
+		OpenTimeout: openTimeout,
+		Metrics:     metrics,
+	}))
+	mux.Handle("GET /orders", statusHandler(&calls, &status))
+
+	for _, step := range []circuitStep{
+		{status: http.StatusInternalServerError},
+		{status: http.StatusOK, wait: 2 * openTimeout},
+	} {
+		time.Sleep(step.wait)
+		status = step.status
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}
+
+	want := []circuitTransition{
+		{route: "GET /orders", from: CircuitClosed, to: CircuitOpen},
+		{route: "GET /orders", from: CircuitOpen, to: CircuitHalfOpen},
+		{route: "GET /orders", from: CircuitHalfOpen, to: CircuitClosed},
+	}
+	if len(metrics.transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", metrics.transitions, want)
+	}
+	for i := range want {
+		if metrics.transitions[i] != want[i] {
+			t.Errorf("transition %d = %v, want %v", i, metrics.transitions[i], want[i])
+		}
+	}
+
+	entry, ok := logger.find("circuit state changed")
+	if !ok {
+		t.Fatal("state change is not logged")
+	}
+	if entry.args["route"] != "GET /orders" || entry.args["from"] != "half_open" || entry.args["to"] != "closed" {
+		t.Errorf("logged args = %v", entry.args)
+	}
+}
+
+func TestCircuitBreaker_PerRoute(t *testing.T) {
+	mux := dr.NewServeMux(newTestFactory()).With(CircuitBreaker(CircuitBreakerOptions{MinRequests: 1}))
+	mux.Handle("GET /failing", dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return f.Error(r.Context(), http.StatusInternalServerError, "failed")
+	}))
+	mux.Handle("GET /healthy", dr.HandlerFunc(okHandler))
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/failing", wantStatus: http.StatusInternalServerError},
+		{target: "/failing", wantStatus: http.StatusServiceUnavailable},
+		{target: "/healthy", wantStatus: http.StatusOK},
+		{target: "/healthy", wantStatus: http.StatusOK},
+	}
+
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("request %d %s: status = %d, want %d", i, tt.target, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestCircuitBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	const openTimeout = 50 * time.Millisecond
+
+	m := CircuitBreaker(CircuitBreakerOptions{MinRequests: 1, OpenTimeout: openTimeout})
+
+	var failed atomic.Bool
+	failed.Store(true)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		if failed.Load() {
+			return f.Error(r.Context(), http.StatusInternalServerError, "failed")
+		}
+		close(started)
+		<-release
+
+		return f.Success(r.Context(), "ok")
+	}
+
+	serve(m, h, httptest.NewRequest(http.MethodGet, "/", nil))
+	failed.Store(false)
+	time.Sleep(2 * openTimeout)
+
+	var (
+		wg    sync.WaitGroup
+		probe *httptest.ResponseRecorder
+	)
+	wg.Go(func() {
+		probe = serve(m, h, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	<-started
+
+	// The probe slot is taken
+	if w := serve(m, h, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status during probe = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if probe.Code != http.StatusOK {
+		t.Errorf("probe status = %d, want %d", probe.Code, http.StatusOK)
+	}
+}
+
+func TestCircuitBreaker_PanicIsFailure(t *testing.T) {
+	m := chain(Recovery(), CircuitBreaker(CircuitBreakerOptions{MinRequests: 1}))
+	panicking := func(*http.Request, *dr.Factory) *response.DataResponse {
+		panic("boom")
+	}
+
+	if w := serve(m, panicking, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if w := serve(m, okHandler, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status after panic = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
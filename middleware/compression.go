@@ -88,6 +88,10 @@ func Compression(opts CompressionOptions) dr.Middleware {
 
 			// Execute handler
 			resp := next.Handle(r, f)
+			if resp.IsStream() {
+				return resp // Streamed as is, e.g. flushed events
+			}
+
 			formattedResp, err := resp.Body()
 			if err != nil {
 				f.Logger().Error(r.Context(), "failed to get formatted response",
@@ -132,10 +136,9 @@ func Compression(opts CompressionOptions) dr.Middleware {
 			}
 
 			// Return compressed response with appropriate headers
-			return resp.
+			return addVary(resp.
 				WithFormatted(compressedResp).
-				WithHeader("Content-Encoding", encoding).
-				WithHeader("Vary", "Accept-Encoding")
+				WithHeader("Content-Encoding", encoding), response.HeaderAcceptEncoding)
 		})
 	}
 }
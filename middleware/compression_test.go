@@ -0,0 +1,93 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// legacyText is a compressible body of a std handler.
+var legacyText = strings.Repeat("legacy response body\n", 120)
+
+// legacyHandler is a std handler setting Content-Length of its body, like http.ServeContent.
+func legacyHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(response.HeaderContentType, "text/plain")
+	w.Header().Set(response.HeaderContentLength, strconv.Itoa(len(legacyText)))
+	_, _ = io.WriteString(w, legacyText)
+}
+
+func TestCompression_BridgedHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler dr.Handler
+	}{
+		{
+			name:    "std handler setting Content-Length",
+			handler: dr.FromHTTPHandlerFunc(legacyHandler),
+		},
+		{
+			name: "std middleware wrapping the writer",
+			handler: dr.Chain(dr.FromHTTPHandlerFunc(legacyHandler), dr.WrapMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(struct{ http.ResponseWriter }{w}, r)
+				})
+			})),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := dr.Chain(tt.handler, DefaultCompression())
+			srv := httptest.NewServer(dr.WrapHandler(h, newTestFactory()))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			req.Header.Set(response.HeaderAcceptEncoding, "gzip")
+
+			// Transport rejects a response with several different Content-Length values
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Values(response.HeaderContentLength); len(got) != 1 {
+				t.Errorf("Content-Length = %q, want one value", got)
+			}
+			if got := resp.Header.Get(response.HeaderContentEncoding); got != "gzip" {
+				t.Fatalf("Content-Encoding = %q, want gzip", got)
+			}
+
+			compressed, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+			if resp.ContentLength != int64(len(compressed)) {
+				t.Errorf("Content-Length = %d, want the compressed size %d", resp.ContentLength, len(compressed))
+			}
+
+			zr, err := gzip.NewReader(strings.NewReader(string(compressed)))
+			if err != nil {
+				t.Fatalf("gzip: %v", err)
+			}
+			if body, _ := io.ReadAll(zr); string(body) != legacyText {
+				t.Errorf("body = %q, want the handler body", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	defaultConcurrencyLimit        = 100
+	defaultConcurrencyQueueTimeout = time.Second
+	defaultConcurrencyRetryAfter   = time.Second
+	defaultConcurrencyMaxLimitRate = 10
+
+	// Gradient limit smoothing factors.
+	gradientShortRTTFactor = 0.1
+	gradientLongRTTFactor  = 0.01
+	gradientLimitSmoothing = 0.2
+	gradientMin            = 0.5
+)
+
+// Load shedding reasons.
+const (
+	ShedReasonQueueFull    = "queue_full"
+	ShedReasonQueueTimeout = "queue_timeout"
+	ShedReasonCanceled     = "canceled"
+)
+
+// ConcurrencyLimitMetrics receives shed requests and changes of the adaptive limit, e.g. to export them
+// as a counter by route and reason and a gauge.
+//
+//go:generate mockery
+type ConcurrencyLimitMetrics interface {
+	RequestShed(route, reason string)
+	ConcurrencyLimitChanged(limit int)
+}
+
+// ConcurrencyLimitOptions configures ConcurrencyLimit middleware.
+type ConcurrencyLimitOptions struct {
+	// Limit is the maximum number of in-flight requests, the initial limit if Adaptive (default: 100).
+	Limit int
+
+	// QueueSize is the number of requests waiting for a slot, excess requests are shed (default: 0, no queue).
+	QueueSize int
+
+	// QueueTimeout is how long a request waits in the queue (default: 1s).
+	QueueTimeout time.Duration
+
+	// Adaptive adjusts the limit by latency using the gradient algorithm:
+	// the limit decreases when the latency grows over its long-term average and increases otherwise.
+	Adaptive bool
+
+	// MinLimit is the lower bound of the adaptive limit (default: 1).
+	MinLimit int
+
+	// MaxLimit is the upper bound of the adaptive limit (default: 10 * Limit).
+	MaxLimit int
+
+	// RetryAfter is sent in Retry-After header of shed requests (default: 1s).
+	RetryAfter time.Duration
+
+	// Metrics receives load shedding events (not reported if nil).
+	Metrics ConcurrencyLimitMetrics
+
+	// Message is the error message (default: status text).
+	Message string
+}
+
+// ConcurrencyLimit creates a load shedding middleware limiting the number of in-flight requests.
+// Requests over the limit wait in the queue up to QueueTimeout, requests that do not fit the queue
+// or time out get 503 Service Unavailable with Retry-After.
+func ConcurrencyLimit(opts ConcurrencyLimitOptions) dr.Middleware {
+	if opts.Limit <= 0 {
+		opts.Limit = defaultConcurrencyLimit
+	}
+
+	if opts.QueueTimeout <= 0 {
+		opts.QueueTimeout = defaultConcurrencyQueueTimeout
+	}
+
+	if opts.MinLimit <= 0 {
+		opts.MinLimit = 1
+	}
+
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = opts.Limit * defaultConcurrencyMaxLimitRate
+	}
+
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = defaultConcurrencyRetryAfter
+	}
+
+	if opts.Message == "" {
+		opts.Message = http.StatusText(http.StatusServiceUnavailable)
+	}
+
+	limiter := newConcurrencyLimiter(opts)
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			ctx := r.Context()
+
+			if reason := limiter.acquire(ctx); reason != "" {
+				f.Logger().Warn(ctx, "request shed",
+					"reason", reason,
+					"route", r.Pattern,
+					"limit", limiter.currentLimit(),
+				)
+
+				if opts.Metrics != nil {
+					opts.Metrics.RequestShed(r.Pattern, reason)
+				}
+
+				return f.ServiceUnavailable(ctx, opts.Message).
+					SetHeader(response.HeaderRetryAfter, retryAfterSeconds(opts.RetryAfter))
+			}
+
+			start := time.Now()
+			defer func() {
+				limit, changed := limiter.release(time.Since(start))
+				if !changed {
+					return
+				}
+
+				f.Logger().Debug(ctx, "concurrency limit changed", "limit", limit)
+
+				if opts.Metrics != nil {
+					opts.Metrics.ConcurrencyLimitChanged(limit)
+				}
+			}()
+
+			return next.Handle(r, f)
+		})
+	}
+}
+
+// concurrencyLimiter is a semaphore with FIFO queue and adjustable limit.
+type concurrencyLimiter struct {
+	mu      sync.Mutex
+	opts    ConcurrencyLimitOptions
+	limit   int
+	running int
+	waiters *list.List
+
+	// Gradient state.
+	estimated float64
+	shortRTT  float64
+	longRTT   float64
+}
+
+func newConcurrencyLimiter(opts ConcurrencyLimitOptions) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		opts:      opts,
+		limit:     opts.Limit,
+		estimated: float64(opts.Limit),
+		waiters:   list.New(),
+	}
+}
+
+// acquire takes a slot, returns the shedding reason if the request is rejected.
+func (l *concurrencyLimiter) acquire(ctx context.Context) string {
+	l.mu.Lock()
+	if l.running < l.limit {
+		l.running++
+		l.mu.Unlock()
+
+		return ""
+	}
+
+	if l.waiters.Len() >= l.opts.QueueSize {
+		l.mu.Unlock()
+
+		return ShedReasonQueueFull
+	}
+
+	ready := make(chan struct{})
+	elem := l.waiters.PushBack(ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.opts.QueueTimeout)
+	defer timer.Stop()
+
+	reason := ShedReasonQueueTimeout
+	select {
+	case <-ready:
+		return ""
+	case <-timer.C:
+	case <-ctx.Done():
+		reason = ShedReasonCanceled
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-ready:
+		// The slot was granted concurrently with the timeout, give it back
+		l.running--
+		l.dispatch()
+	default:
+		l.waiters.Remove(elem)
+	}
+
+	return reason
+}
+
+// release frees the slot, adjusts the adaptive limit by the request latency and returns the limit.
+func (l *concurrencyLimiter) release(rtt time.Duration) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.running
+	l.running--
+
+	changed := false
+	if l.opts.Adaptive {
+		changed = l.adjust(rtt, inFlight)
+	}
+
+	l.dispatch()
+
+	return l.limit, changed
+}
+
+// dispatch grants free slots to the queued requests.
+func (l *concurrencyLimiter) dispatch() {
+	for l.running < l.limit && l.waiters.Len() > 0 {
+		ready := l.waiters.Remove(l.waiters.Front()).(chan struct{})
+		l.running++
+		close(ready)
+	}
+}
+
+// adjust updates the limit using the ratio of long-term and short-term latency averages.
+func (l *concurrencyLimiter) adjust(rtt time.Duration, inFlight int) bool {
+	sample := float64(rtt)
+	if sample <= 0 {
+		return false
+	}
+
+	if l.longRTT == 0 {
+		l.longRTT = sample
+		l.shortRTT = sample
+	}
+	l.shortRTT += (sample - l.shortRTT) * gradientShortRTTFactor
+	l.longRTT += (sample - l.longRTT) * gradientLongRTTFactor
+
+	// Not enough load to judge the limit, the service is not limited by it
+	if inFlight < l.limit/2 {
+		return false
+	}
+
+	gradient := max(gradientMin, min(1, l.longRTT/l.shortRTT))
+	queueAllowance := math.Sqrt(l.estimated)
+	newLimit := l.estimated*gradient + queueAllowance
+
+	l.estimated = l.estimated*(1-gradientLimitSmoothing) + newLimit*gradientLimitSmoothing
+	l.estimated = max(float64(l.opts.MinLimit), min(float64(l.opts.MaxLimit), l.estimated))
+
+	limit := int(l.estimated)
+	if limit == l.limit {
+		return false
+	}
+	l.limit = limit
+
+	return true
+}
+
+func (l *concurrencyLimiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
@@ -0,0 +1,261 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// sheddingMetrics records load shedding events.
+type sheddingMetrics struct {
+	mu      sync.Mutex
+	reasons []string
+	limits  []int
+}
+
+func (m *sheddingMetrics) RequestShed(_, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reasons = append(m.reasons, reason)
+}
+
+func (m *sheddingMetrics) ConcurrencyLimitChanged(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limits = append(m.limits, limit)
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       ConcurrencyLimitOptions
+		releaseIn  time.Duration // Delay before the in-flight request completes
+		cancel     bool          // Cancel the queued request
+		wantStatus int
+		wantReason string
+	}{
+		{
+			name:       "queue full",
+			opts:       ConcurrencyLimitOptions{Limit: 1},
+			releaseIn:  time.Second,
+			wantStatus: http.StatusServiceUnavailable,
+			wantReason: ShedReasonQueueFull,
+		},
+		{
+			name:       "queue timeout",
+			opts:       ConcurrencyLimitOptions{Limit: 1, QueueSize: 1, QueueTimeout: 20 * time.Millisecond},
+			releaseIn:  time.Second,
+			wantStatus: http.StatusServiceUnavailable,
+			wantReason: ShedReasonQueueTimeout,
+		},
+		{
+			name:       "canceled while queued",
+			opts:       ConcurrencyLimitOptions{Limit: 1, QueueSize: 1, QueueTimeout: time.Second},
+			releaseIn:  time.Second,
+			cancel:     true,
+			wantStatus: http.StatusServiceUnavailable,
+			wantReason: ShedReasonCanceled,
+		},
+		{
+			name:       "slot is released while queued",
+			opts:       ConcurrencyLimitOptions{Limit: 1, QueueSize: 1, QueueTimeout: time.Second},
+			releaseIn:  20 * time.Millisecond,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &sheddingMetrics{}
+			tt.opts.Metrics = metrics
+			m := ConcurrencyLimit(tt.opts)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			blocking := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+				close(started)
+				<-release
+
+				return f.Success(r.Context(), "ok")
+			}
+
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			wg.Go(func() {
+				serve(m, blocking, httptest.NewRequest(http.MethodGet, "/", nil))
+			})
+			<-started
+
+			timer := time.AfterFunc(tt.releaseIn, func() { close(release) })
+			defer func() {
+				if timer.Stop() {
+					close(release)
+				}
+			}()
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+
+			w := serve(m, okHandler, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantReason == "" {
+				if len(metrics.reasons) != 0 {
+					t.Errorf("shed reasons = %v, want none", metrics.reasons)
+				}
+
+				return
+			}
+
+			if got := w.Header().Get(response.HeaderRetryAfter); got != "1" {
+				t.Errorf("Retry-After = %q, want %q", got, "1")
+			}
+			if len(metrics.reasons) != 1 || metrics.reasons[0] != tt.wantReason {
+				t.Errorf("shed reasons = %v, want [%s]", metrics.reasons, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit_QueueIsFIFO(t *testing.T) {
+	const queued = 3
+
+	m := ConcurrencyLimit(ConcurrencyLimitOptions{Limit: 1, QueueSize: queued, QueueTimeout: time.Second})
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	release := make(chan struct{})
+	h := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		mu.Lock()
+		order = append(order, r.URL.Query().Get("n"))
+		mu.Unlock()
+
+		if r.URL.Query().Get("n") == "0" {
+			<-release
+		}
+
+		return f.Success(r.Context(), "ok")
+	}
+
+	var wg sync.WaitGroup
+	for i := range queued + 1 {
+		wg.Go(func() {
+			serve(m, h, httptest.NewRequest(http.MethodGet, "/?n="+strconv.Itoa(i), nil))
+		})
+		// Let the request take the slot or enter the queue before the next one
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	want := []string{"0", "1", "2", "3"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestConcurrencyLimiter_Adaptive(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      ConcurrencyLimitOptions
+		rtt       func(i int) time.Duration
+		inFlight  int
+		wantLower bool
+		wantLimit int // Exact limit expected, 0 if only the direction is checked
+	}{
+		{
+			name:      "steady latency raises the limit up to max",
+			opts:      ConcurrencyLimitOptions{Limit: 10, MinLimit: 1, MaxLimit: 20},
+			rtt:       func(int) time.Duration { return 10 * time.Millisecond },
+			inFlight:  10,
+			wantLimit: 20,
+		},
+		{
+			name:      "growing latency lowers the limit",
+			opts:      ConcurrencyLimitOptions{Limit: 50, MinLimit: 1, MaxLimit: 500},
+			rtt:       func(i int) time.Duration { return time.Duration(i+1) * 10 * time.Millisecond },
+			inFlight:  50,
+			wantLower: true,
+		},
+		{
+			name:      "low load keeps the limit",
+			opts:      ConcurrencyLimitOptions{Limit: 10, MinLimit: 1, MaxLimit: 100},
+			rtt:       func(i int) time.Duration { return time.Duration(i+1) * 10 * time.Millisecond },
+			inFlight:  1,
+			wantLimit: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Adaptive = true
+			l := newConcurrencyLimiter(tt.opts)
+
+			for i := range 100 {
+				l.running = tt.inFlight
+				l.release(tt.rtt(i))
+			}
+
+			got := l.currentLimit()
+			if tt.wantLower && got >= tt.opts.Limit {
+				t.Errorf("limit = %d, want lower than %d", got, tt.opts.Limit)
+			}
+			if got < tt.opts.MinLimit || got > tt.opts.MaxLimit {
+				t.Errorf("limit = %d, want within [%d, %d]", got, tt.opts.MinLimit, tt.opts.MaxLimit)
+			}
+			if tt.wantLimit != 0 && got != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit_ReportsLimitChanges(t *testing.T) {
+	metrics := &sheddingMetrics{}
+	m := ConcurrencyLimit(ConcurrencyLimitOptions{Limit: 2, Adaptive: true, Metrics: metrics})
+	h := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		time.Sleep(time.Millisecond)
+
+		return f.Success(r.Context(), "ok")
+	}
+
+	for range 10 {
+		serve(m, h, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if len(metrics.limits) == 0 {
+		t.Fatal("limit changes are not reported")
+	}
+	if metrics.limits[0] <= 2 {
+		t.Errorf("first limit = %d, want raised over 2", metrics.limits[0])
+	}
+}
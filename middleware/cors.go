@@ -0,0 +1,420 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const corsWildcard = "*"
+
+// AllowOriginFunc decides whether the given origin is allowed for the request.
+type AllowOriginFunc func(r *http.Request, origin string) bool
+
+// CORSOptions configures CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins is a list of origins a cross-domain request can be executed from.
+	// Supports exact values ("https://example.com"), wildcard subdomains ("https://*.example.com")
+	// and "*" to allow any origin (default: "*", unless AllowCredentials is set).
+	AllowedOrigins []string
+
+	// AllowOriginFunc is a custom predicate to validate the origin.
+	// It is checked after AllowedOrigins, so both can be combined.
+	AllowOriginFunc AllowOriginFunc
+
+	// AllowedMethods is a list of methods the client is allowed to use (default: GET, POST, HEAD).
+	AllowedMethods []string
+
+	// AllowedHeaders is a list of non-simple headers the client is allowed to use.
+	// "*" allows any requested header (default: CORS-safelisted Accept, Accept-Language,
+	// Content-Language, Content-Type and Range).
+	AllowedHeaders []string
+
+	// ExposedHeaders is a list of headers which are safe to expose to the client.
+	ExposedHeaders []string
+
+	// AllowCredentials indicates whether the request can include user credentials.
+	// It requires explicit AllowedOrigins or AllowOriginFunc, any origin is not allowed with credentials.
+	AllowCredentials bool
+
+	// MaxAge indicates how long the results of a preflight request can be cached (0 = not sent).
+	MaxAge time.Duration
+
+	// OptionsPassthrough passes preflight requests to the next handler instead of responding with 204.
+	OptionsPassthrough bool
+
+	// RejectDisallowed rejects actual (non-preflight) requests from disallowed origins
+	// with 403 Forbidden instead of passing them through without CORS headers.
+	RejectDisallowed bool
+}
+
+// CORS creates a middleware that handles Cross-Origin Resource Sharing.
+// Preflight requests are answered with 204 No Content, disallowed preflights
+// are rejected with 403 Forbidden produced by the factory.
+//
+// It panics if AllowCredentials is set with any origin allowed ("*" or no origins),
+// as every site could make credentialed requests.
+func CORS(opts CORSOptions) dr.Middleware {
+	if len(opts.AllowedOrigins) == 0 && opts.AllowOriginFunc == nil {
+		if opts.AllowCredentials {
+			panic("middleware: CORS AllowCredentials requires AllowedOrigins or AllowOriginFunc")
+		}
+
+		opts.AllowedOrigins = []string{corsWildcard}
+	}
+
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodHead}
+	}
+
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = []string{
+			response.HeaderAccept,
+			response.HeaderAcceptLanguage,
+			response.HeaderContentLanguage,
+			response.HeaderContentType,
+			response.HeaderRange,
+		}
+	}
+
+	c := newCORSPolicy(opts)
+	if c.allowAllOrigins && c.allowCredentials {
+		panic(`middleware: CORS AllowCredentials is not allowed with "*" origin`)
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			origin := r.Header.Get(response.HeaderOrigin)
+
+			if isPreflight(r) {
+				return c.handlePreflight(r, f, next, origin)
+			}
+
+			if origin == "" {
+				return c.varyOrigin(next.Handle(r, f))
+			}
+
+			if !c.isOriginAllowed(r, origin) {
+				f.Logger().Debug(r.Context(), "cors origin is not allowed",
+					"origin", origin,
+					"method", r.Method,
+					"path", r.URL.Path,
+				)
+
+				if opts.RejectDisallowed {
+					return c.varyOrigin(f.Forbidden(r.Context(), "Origin is not allowed"))
+				}
+
+				return c.varyOrigin(next.Handle(r, f))
+			}
+
+			resp := next.Handle(r, f)
+			c.setOriginHeaders(resp, origin)
+
+			if len(c.exposedHeaders) > 0 {
+				resp.SetHeader(response.HeaderAccessControlExposeHeaders, c.exposedHeaders)
+			}
+
+			return resp
+		})
+	}
+}
+
+// DefaultCORS creates CORS middleware that allows any origin with common API methods and headers.
+func DefaultCORS() dr.Middleware {
+	return CORS(CORSOptions{
+		AllowedOrigins: []string{corsWildcard},
+		AllowedMethods: []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+			http.MethodHead,
+		},
+		AllowedHeaders: []string{
+			response.HeaderAccept,
+			response.HeaderAuthorization,
+			response.HeaderContentType,
+			response.HeaderXRequestID,
+		},
+	})
+}
+
+// corsPolicy is a precompiled CORS configuration.
+type corsPolicy struct {
+	opts CORSOptions
+
+	allowAllOrigins  bool
+	exactOrigins     map[string]bool
+	wildcardOrigins  []wildcardOrigin
+	allowAllHeaders  bool
+	allowedHeaders   map[string]bool
+	allowedMethods   map[string]bool
+	methodsHeader    string
+	headersHeader    string
+	exposedHeaders   string
+	maxAge           string
+	allowCredentials bool
+}
+
+// wildcardOrigin matches origins like "https://*.example.com".
+type wildcardOrigin struct {
+	prefix string
+	suffix string
+}
+
+func (w wildcardOrigin) match(origin string) bool {
+	return len(origin) > len(w.prefix)+len(w.suffix) &&
+		strings.HasPrefix(origin, w.prefix) &&
+		strings.HasSuffix(origin, w.suffix)
+}
+
+func newCORSPolicy(opts CORSOptions) *corsPolicy {
+	c := &corsPolicy{
+		opts:             opts,
+		exactOrigins:     make(map[string]bool, len(opts.AllowedOrigins)),
+		allowedHeaders:   make(map[string]bool, len(opts.AllowedHeaders)),
+		allowedMethods:   make(map[string]bool, len(opts.AllowedMethods)),
+		allowCredentials: opts.AllowCredentials,
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+
+		switch {
+		case origin == corsWildcard:
+			c.allowAllOrigins = true
+		case strings.Contains(origin, corsWildcard):
+			idx := strings.Index(origin, corsWildcard)
+			c.wildcardOrigins = append(c.wildcardOrigins, wildcardOrigin{
+				prefix: origin[:idx],
+				suffix: origin[idx+1:],
+			})
+		default:
+			c.exactOrigins[origin] = true
+		}
+	}
+
+	methods := make([]string, 0, len(opts.AllowedMethods))
+	for _, method := range opts.AllowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		c.allowedMethods[method] = true
+		methods = append(methods, method)
+	}
+	c.methodsHeader = strings.Join(methods, ", ")
+
+	headers := make([]string, 0, len(opts.AllowedHeaders))
+	for _, header := range opts.AllowedHeaders {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		if header == corsWildcard {
+			c.allowAllHeaders = true
+
+			continue
+		}
+		c.allowedHeaders[header] = true
+		headers = append(headers, header)
+	}
+	c.headersHeader = strings.Join(headers, ", ")
+
+	exposed := make([]string, 0, len(opts.ExposedHeaders))
+	for _, header := range opts.ExposedHeaders {
+		exposed = append(exposed, http.CanonicalHeaderKey(strings.TrimSpace(header)))
+	}
+	c.exposedHeaders = strings.Join(exposed, ", ")
+
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+
+	return c
+}
+
+// handlePreflight answers OPTIONS preflight request.
+func (c *corsPolicy) handlePreflight(
+	r *http.Request,
+	f *dr.Factory,
+	next dr.Handler,
+	origin string,
+) *response.DataResponse {
+	ctx := r.Context()
+	method := strings.ToUpper(r.Header.Get(response.HeaderAccessControlRequestMethod))
+
+	if !c.isOriginAllowed(r, origin) {
+		f.Logger().Debug(ctx, "cors preflight rejected: origin is not allowed", "origin", origin)
+
+		return c.varyPreflight(f.Forbidden(ctx, "Origin is not allowed"))
+	}
+
+	if !c.allowedMethods[method] {
+		f.Logger().Debug(ctx, "cors preflight rejected: method is not allowed",
+			"origin", origin,
+			"method", method,
+		)
+
+		return c.varyPreflight(f.Forbidden(ctx, "Method is not allowed"))
+	}
+
+	requestedHeaders := parseHeaderList(r.Header.Get(response.HeaderAccessControlRequestHeaders))
+	if !c.areHeadersAllowed(requestedHeaders) {
+		f.Logger().Debug(ctx, "cors preflight rejected: headers are not allowed",
+			"origin", origin,
+			"headers", strings.Join(requestedHeaders, ", "),
+		)
+
+		return c.varyPreflight(f.Forbidden(ctx, "Headers are not allowed"))
+	}
+
+	var resp *response.DataResponse
+	if c.opts.OptionsPassthrough {
+		resp = next.Handle(r, f)
+	} else {
+		resp = f.NoContent(ctx)
+	}
+
+	c.varyPreflight(resp)
+	c.setOriginHeaders(resp, origin)
+	resp.SetHeader(response.HeaderAccessControlAllowMethods, c.methodsHeader)
+
+	if len(requestedHeaders) > 0 {
+		if c.allowAllHeaders {
+			resp.SetHeader(response.HeaderAccessControlAllowHeaders, strings.Join(requestedHeaders, ", "))
+		} else {
+			resp.SetHeader(response.HeaderAccessControlAllowHeaders, c.headersHeader)
+		}
+	}
+
+	if c.maxAge != "" {
+		resp.SetHeader(response.HeaderAccessControlMaxAge, c.maxAge)
+	}
+
+	return resp
+}
+
+// setOriginHeaders sets Allow-Origin and Allow-Credentials headers.
+// SetHeader is used, so values set by DataResponse.WithCORS are replaced instead of duplicated.
+func (c *corsPolicy) setOriginHeaders(resp *response.DataResponse, origin string) {
+	if c.allowAllOrigins {
+		resp.SetHeader(response.HeaderAccessControlAllowOrigin, corsWildcard)
+	} else {
+		resp.SetHeader(response.HeaderAccessControlAllowOrigin, origin)
+		c.varyOrigin(resp)
+	}
+
+	if c.allowCredentials {
+		resp.SetHeader(response.HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+// varyOrigin adds "Vary: Origin" when the response depends on the request origin.
+func (c *corsPolicy) varyOrigin(resp *response.DataResponse) *response.DataResponse {
+	if c.allowAllOrigins {
+		return resp
+	}
+
+	return addVary(resp, response.HeaderOrigin)
+}
+
+// varyPreflight adds Vary headers for preflight responses.
+func (c *corsPolicy) varyPreflight(resp *response.DataResponse) *response.DataResponse {
+	return addVary(resp,
+		response.HeaderOrigin,
+		response.HeaderAccessControlRequestMethod,
+		response.HeaderAccessControlRequestHeaders,
+	)
+}
+
+func (c *corsPolicy) isOriginAllowed(r *http.Request, origin string) bool {
+	if c.allowAllOrigins {
+		return true
+	}
+
+	lowerOrigin := strings.ToLower(origin)
+	if c.exactOrigins[lowerOrigin] {
+		return true
+	}
+
+	for _, w := range c.wildcardOrigins {
+		if w.match(lowerOrigin) {
+			return true
+		}
+	}
+
+	if c.opts.AllowOriginFunc != nil {
+		return c.opts.AllowOriginFunc(r, origin)
+	}
+
+	return false
+}
+
+func (c *corsPolicy) areHeadersAllowed(headers []string) bool {
+	if c.allowAllHeaders {
+		return true
+	}
+
+	for _, header := range headers {
+		if !c.allowedHeaders[header] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPreflight checks if request is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get(response.HeaderOrigin) != "" &&
+		r.Header.Get(response.HeaderAccessControlRequestMethod) != ""
+}
+
+// parseHeaderList splits comma-separated header names into canonical form.
+func parseHeaderList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	parts := strings.Split(value, ",")
+	headers := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		headers = append(headers, http.CanonicalHeaderKey(part))
+	}
+
+	return headers
+}
+
+// addVary adds header names to the Vary header without duplicating existing values.
+func addVary(resp *response.DataResponse, headers ...string) *response.DataResponse {
+	existing := make(map[string]bool)
+	for _, value := range resp.HeaderValues(response.HeaderVary) {
+		for _, name := range strings.Split(value, ",") {
+			existing[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+
+	for _, header := range headers {
+		if existing[header] {
+			continue
+		}
+		existing[header] = true
+		resp.WithHeader(response.HeaderVary, header)
+	}
+
+	return resp
+}
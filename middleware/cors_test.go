@@ -0,0 +1,274 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func TestCORS_Preflight(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	tests := []struct {
+		name          string
+		origin        string
+		method        string
+		headers       string
+		wantStatus    int
+		wantOrigin    string
+		wantHeaders   string
+		wantMaxAge    string
+		wantMethods   string
+		wantCredsFlag string
+	}{
+		{
+			name:          "exact origin",
+			origin:        "https://app.example.com",
+			method:        http.MethodPut,
+			wantStatus:    http.StatusNoContent,
+			wantOrigin:    "https://app.example.com",
+			wantMaxAge:    "600",
+			wantMethods:   "GET, PUT",
+			wantCredsFlag: "true",
+		},
+		{
+			name:          "wildcard subdomain",
+			origin:        "https://api.example.org",
+			method:        http.MethodGet,
+			wantStatus:    http.StatusNoContent,
+			wantOrigin:    "https://api.example.org",
+			wantMaxAge:    "600",
+			wantMethods:   "GET, PUT",
+			wantCredsFlag: "true",
+		},
+		{
+			name:          "safelisted header allowed by default",
+			origin:        "https://app.example.com",
+			method:        http.MethodGet,
+			headers:       "content-type",
+			wantStatus:    http.StatusNoContent,
+			wantOrigin:    "https://app.example.com",
+			wantHeaders:   "Accept, Accept-Language, Content-Language, Content-Type, Range",
+			wantMaxAge:    "600",
+			wantMethods:   "GET, PUT",
+			wantCredsFlag: "true",
+		},
+		{
+			name:       "wildcard does not match the bare domain",
+			origin:     "https://example.org",
+			method:     http.MethodGet,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "suffix of another domain",
+			origin:     "https://app.example.com.evil.com",
+			method:     http.MethodGet,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "method not allowed",
+			origin:     "https://app.example.com",
+			method:     http.MethodDelete,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "header not allowed",
+			origin:     "https://app.example.com",
+			method:     http.MethodGet,
+			headers:    "Content-Type, Authorization",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, "/", nil)
+			r.Header.Set(response.HeaderOrigin, tt.origin)
+			r.Header.Set(response.HeaderAccessControlRequestMethod, tt.method)
+			if tt.headers != "" {
+				r.Header.Set(response.HeaderAccessControlRequestHeaders, tt.headers)
+			}
+
+			w := serve(CORS(opts), func(r *http.Request, f *dr.Factory) *response.DataResponse {
+				t.Error("preflight must not reach the handler")
+
+				return f.Success(r.Context(), nil)
+			}, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			h := w.Header()
+			checks := map[string]string{
+				response.HeaderAccessControlAllowOrigin:      tt.wantOrigin,
+				response.HeaderAccessControlAllowHeaders:     tt.wantHeaders,
+				response.HeaderAccessControlAllowMethods:     tt.wantMethods,
+				response.HeaderAccessControlMaxAge:           tt.wantMaxAge,
+				response.HeaderAccessControlAllowCredentials: tt.wantCredsFlag,
+			}
+			for name, want := range checks {
+				if got := h.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+
+			vary := h.Values(response.HeaderVary)
+			for _, want := range []string{
+				response.HeaderOrigin,
+				response.HeaderAccessControlRequestMethod,
+				response.HeaderAccessControlRequestHeaders,
+			} {
+				if !slices.Contains(vary, want) {
+					t.Errorf("Vary = %q, want %q", vary, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORS_ActualRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        CORSOptions
+		origin      string
+		wantStatus  int
+		wantOrigin  string
+		wantExposed string
+		wantVary    bool
+	}{
+		{
+			name:       "any origin",
+			opts:       CORSOptions{},
+			origin:     "https://any.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name: "allowed origin",
+			opts: CORSOptions{
+				AllowedOrigins: []string{"https://app.example.com"},
+				ExposedHeaders: []string{"x-request-id"},
+			},
+			origin:      "https://APP.example.com",
+			wantStatus:  http.StatusOK,
+			wantOrigin:  "https://APP.example.com",
+			wantExposed: "X-Request-Id",
+			wantVary:    true,
+		},
+		{
+			name: "origin predicate",
+			opts: CORSOptions{
+				AllowOriginFunc: func(_ *http.Request, origin string) bool {
+					return origin == "https://partner.example.net"
+				},
+			},
+			origin:     "https://partner.example.net",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://partner.example.net",
+			wantVary:   true,
+		},
+		{
+			name:       "disallowed origin passes without headers",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusOK,
+			wantVary:   true,
+		},
+		{
+			name: "disallowed origin rejected",
+			opts: CORSOptions{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				RejectDisallowed: true,
+			},
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusForbidden,
+			wantVary:   true,
+		},
+		{
+			name:       "same origin request",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			wantStatus: http.StatusOK,
+			wantVary:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.origin != "" {
+				r.Header.Set(response.HeaderOrigin, tt.origin)
+			}
+
+			w := serve(CORS(tt.opts), okHandler, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderAccessControlAllowOrigin); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get(response.HeaderAccessControlExposeHeaders); got != tt.wantExposed {
+				t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, tt.wantExposed)
+			}
+			if got := slices.Contains(w.Header().Values(response.HeaderVary), response.HeaderOrigin); got != tt.wantVary {
+				t.Errorf("Vary: Origin = %v, want %v", got, tt.wantVary)
+			}
+		})
+	}
+}
+
+func TestCORS_OptionsPassthrough(t *testing.T) {
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set(response.HeaderOrigin, "https://app.example.com")
+	r.Header.Set(response.HeaderAccessControlRequestMethod, http.MethodGet)
+
+	w := serve(CORS(CORSOptions{OptionsPassthrough: true}), okHandler, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get(response.HeaderAccessControlAllowOrigin); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "*")
+	}
+}
+
+func TestCORS_CredentialsRequireExplicitOrigins(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CORSOptions
+	}{
+		{name: "no origins", opts: CORSOptions{AllowCredentials: true}},
+		{name: "any origin", opts: CORSOptions{AllowCredentials: true, AllowedOrigins: []string{"*"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("CORS did not panic")
+				}
+			}()
+
+			CORS(tt.opts)
+		})
+	}
+}
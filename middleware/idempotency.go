@@ -0,0 +1,390 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	defaultIdempotencyTTL          = 24 * time.Hour
+	defaultIdempotencyLockTimeout  = 10 * time.Second
+	defaultIdempotencyMaxKeyLength = 255
+	defaultIdempotencyMaxBodySize  = 1 << 20 // 1 MB
+)
+
+// IdempotencyRecord is a stored response of the first completed request with the key.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request payload the key was used with.
+	Fingerprint string
+
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	CreatedAt time.Time
+}
+
+// IdempotencyStore persists idempotency records.
+// Get returns nil record without error if the key is not found or expired.
+//
+//go:generate mockery
+type IdempotencyStore interface {
+	Get(ctx context.Context, key string) (*IdempotencyRecord, error)
+	Set(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+}
+
+// IdempotencyLocker is an optional IdempotencyStore extension for distributed locking.
+// When the store does not implement it, requests are serialized within the process.
+type IdempotencyLocker interface {
+	Lock(ctx context.Context, key string, timeout time.Duration) (unlock func(), err error)
+}
+
+// IdempotencyOptions configures Idempotency middleware.
+type IdempotencyOptions struct {
+	// Store persists records (default: in-memory store).
+	Store IdempotencyStore
+
+	// TTL is how long the records are kept (default: 24h).
+	TTL time.Duration
+
+	// Methods lists methods the middleware is applied to (default: POST, PATCH).
+	Methods []string
+
+	// Required rejects requests without Idempotency-Key header with 400 Bad Request.
+	Required bool
+
+	// MaxKeyLength is the maximum key length (default: 255).
+	MaxKeyLength int
+
+	// MaxBodySize is the maximum size of request and response bodies (default: 1 MB).
+	// Larger requests are rejected with 413, larger responses are not stored.
+	MaxBodySize int64
+
+	// LockTimeout is how long a request waits for a concurrent request with the same key
+	// before it is rejected with 409 Conflict (default: 10s).
+	LockTimeout time.Duration
+
+	// KeyScope returns the namespace of the key, so different clients cannot share keys
+	// (default: principal subject if authenticated).
+	KeyScope func(r *http.Request) string
+}
+
+// Idempotency creates a middleware that makes unsafe requests idempotent by Idempotency-Key header.
+// The first completed response (except 5xx) is stored and replayed for repeated keys with the same
+// request fingerprint. Reusing the key with a different payload returns 422 Unprocessable Entity,
+// concurrent requests with the same key are serialized.
+func Idempotency(opts IdempotencyOptions) dr.Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemoryIdempotencyStore()
+	}
+
+	if opts.TTL == 0 {
+		opts.TTL = defaultIdempotencyTTL
+	}
+
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	if opts.MaxKeyLength == 0 {
+		opts.MaxKeyLength = defaultIdempotencyMaxKeyLength
+	}
+
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = defaultIdempotencyMaxBodySize
+	}
+
+	if opts.LockTimeout == 0 {
+		opts.LockTimeout = defaultIdempotencyLockTimeout
+	}
+
+	if opts.KeyScope == nil {
+		opts.KeyScope = principalKeyScope
+	}
+
+	methodMap := make(map[string]bool, len(opts.Methods))
+	for _, method := range opts.Methods {
+		methodMap[strings.ToUpper(method)] = true
+	}
+
+	locker, ok := opts.Store.(IdempotencyLocker)
+	if !ok {
+		locker = newKeyedMutex()
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			if !methodMap[r.Method] {
+				return next.Handle(r, f)
+			}
+
+			ctx := r.Context()
+
+			key := r.Header.Get(response.HeaderIdempotencyKey)
+			if key == "" {
+				if opts.Required {
+					return f.BadRequest(ctx, "Idempotency-Key header is required")
+				}
+
+				return next.Handle(r, f)
+			}
+
+			if len(key) > opts.MaxKeyLength {
+				return f.BadRequest(ctx, "Idempotency-Key is too long")
+			}
+
+			fingerprint, ok, err := requestFingerprint(r, opts.MaxBodySize)
+			if err != nil {
+				return f.BadRequest(ctx, "failed to read request body")
+			}
+
+			if !ok {
+				return f.Error(ctx, http.StatusRequestEntityTooLarge, "")
+			}
+
+			storeKey := opts.KeyScope(r) + ":" + key
+
+			unlock, err := locker.Lock(ctx, storeKey, opts.LockTimeout)
+			if err != nil {
+				f.Logger().Warn(ctx, "idempotency key is locked by concurrent request",
+					"key", key,
+					"error", err.Error(),
+				)
+
+				return f.Conflict(ctx, "A request with the same Idempotency-Key is in progress")
+			}
+			defer unlock()
+
+			record, err := opts.Store.Get(ctx, storeKey)
+			if err != nil {
+				return f.InternalError(ctx, response.WrapError(http.StatusInternalServerError, err, "failed to get idempotency record"))
+			}
+
+			if record != nil {
+				if record.Fingerprint != fingerprint {
+					return f.ValidationError(ctx, "Idempotency-Key is already used with a different payload", nil)
+				}
+
+				f.Logger().Debug(ctx, "idempotent response replayed", "key", key)
+
+				replayed := bufferedResponse{
+					StatusCode: record.StatusCode,
+					Header:     record.Header,
+					Body:       record.Body,
+				}
+
+				return replayed.DataResponse().
+					SetHeader(response.HeaderIdempotentReplayed, "true")
+			}
+
+			resp := next.Handle(r, f)
+
+			// Server errors are transient, the client may retry with the same key,
+			// stream responses cannot be replayed
+			if resp.StatusCode() >= http.StatusInternalServerError || resp.IsStream() {
+				return resp
+			}
+
+			buffered, ok, err := bufferResponse(resp, opts.MaxBodySize)
+			if err != nil {
+				return f.InternalError(ctx, response.WrapError(http.StatusInternalServerError, err, "failed to format response"))
+			}
+
+			if !ok {
+				f.Logger().Warn(ctx, "response is too large to be stored for idempotency", "key", key)
+
+				return resp
+			}
+
+			err = opts.Store.Set(ctx, storeKey, &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				StatusCode:  buffered.StatusCode,
+				Header:      buffered.Header,
+				Body:        buffered.Body,
+				CreatedAt:   time.Now(),
+			}, opts.TTL)
+			if err != nil {
+				f.Logger().Error(ctx, "failed to store idempotency record",
+					"key", key,
+					"error", err.Error(),
+				)
+			}
+
+			return resp
+		})
+	}
+}
+
+// requestFingerprint hashes method, URI and body of the request.
+// The body is restored, so the handler can read it again.
+func requestFingerprint(r *http.Request, maxBodySize int64) (string, bool, error) {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{0})
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			return "", false, err
+		}
+
+		if int64(len(body)) > maxBodySize {
+			return "", false, nil
+		}
+
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), true, nil
+}
+
+// principalKeyScope scopes keys by the authenticated principal.
+func principalKeyScope(r *http.Request) string {
+	if principal, ok := PrincipalFromContext(r.Context()); ok {
+		return principal.Scheme + ":" + principal.Subject
+	}
+
+	return ""
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]memoryIdempotencyEntry
+	sets    int
+}
+
+type memoryIdempotencyEntry struct {
+	record    *IdempotencyRecord
+	expiresAt time.Time
+}
+
+// cleanupEverySets is how often expired records are removed.
+const cleanupEverySets = 100
+
+// NewMemoryIdempotencyStore creates a new in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]memoryIdempotencyEntry),
+	}
+}
+
+// Get returns the record or nil if not found or expired.
+func (s *MemoryIdempotencyStore) Get(_ context.Context, key string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.records[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		delete(s.records, key)
+
+		return nil, nil
+	}
+
+	return entry.record, nil
+}
+
+// Set stores the record with TTL.
+func (s *MemoryIdempotencyStore) Set(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.records[key] = memoryIdempotencyEntry{
+		record:    record,
+		expiresAt: now.Add(ttl),
+	}
+
+	s.sets++
+	if s.sets%cleanupEverySets == 0 {
+		for k, entry := range s.records {
+			if now.After(entry.expiresAt) {
+				delete(s.records, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// keyedMutex serializes holders of the same key within the process.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock acquires the key lock, waiting up to timeout.
+func (m *keyedMutex) Lock(ctx context.Context, key string, timeout time.Duration) (func(), error) {
+	m.mu.Lock()
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &keyedLock{ch: make(chan struct{}, 1)}
+		m.locks[key] = lock
+	}
+	lock.refs++
+	m.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case lock.ch <- struct{}{}:
+		return func() {
+			<-lock.ch
+			m.release(key, lock)
+		}, nil
+	case <-timer.C:
+		m.release(key, lock)
+
+		return nil, context.DeadlineExceeded
+	case <-ctx.Done():
+		m.release(key, lock)
+
+		return nil, ctx.Err()
+	}
+}
+
+func (m *keyedMutex) release(key string, lock *keyedLock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(m.locks, key)
+	}
+}
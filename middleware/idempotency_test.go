@@ -0,0 +1,334 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// idempotencyRequest is a request sent to the idempotent endpoint.
+type idempotencyRequest struct {
+	method    string
+	key       string
+	body      string
+	principal *Principal
+}
+
+func (ir idempotencyRequest) build() *http.Request {
+	method := ir.method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	r := httptest.NewRequest(method, "/payments", strings.NewReader(ir.body))
+	if ir.key != "" {
+		r.Header.Set(response.HeaderIdempotencyKey, ir.key)
+	}
+	if ir.principal != nil {
+		r = r.WithContext(WithPrincipal(r.Context(), ir.principal))
+	}
+
+	return r
+}
+
+// paymentHandler creates a payment numbered by the calls counter, echoing the request body.
+func paymentHandler(calls *atomic.Int32, status int) dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		n := calls.Add(1)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return f.InternalError(r.Context(), err)
+		}
+
+		return f.CreateDataResponse(status, map[string]any{"id": n, "body": string(body)}).
+			WithHeader("X-Payment-Id", strconv.Itoa(int(n)))
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	alice := &Principal{Subject: "alice", Scheme: AuthSchemeBearer}
+	bob := &Principal{Subject: "bob", Scheme: AuthSchemeBearer}
+
+	tests := []struct {
+		name         string
+		opts         IdempotencyOptions
+		status       int
+		requests     []idempotencyRequest
+		wantStatuses []int
+		wantReplayed []bool
+		wantCalls    int32
+	}{
+		{
+			name:   "repeated key is replayed",
+			status: http.StatusCreated,
+			requests: []idempotencyRequest{
+				{key: "k1", body: `{"amount":10}`},
+				{key: "k1", body: `{"amount":10}`},
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+			wantReplayed: []bool{false, true},
+			wantCalls:    1,
+		},
+		{
+			name:   "different payload",
+			status: http.StatusCreated,
+			requests: []idempotencyRequest{
+				{key: "k1", body: `{"amount":10}`},
+				{key: "k1", body: `{"amount":99}`},
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantReplayed: []bool{false, false},
+			wantCalls:    1,
+		},
+		{
+			name:   "different keys",
+			status: http.StatusCreated,
+			requests: []idempotencyRequest{
+				{key: "k1", body: `{}`},
+				{key: "k2", body: `{}`},
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name:   "keys are scoped by principal",
+			status: http.StatusCreated,
+			requests: []idempotencyRequest{
+				{key: "k1", body: `{}`, principal: alice},
+				{key: "k1", body: `{}`, principal: bob},
+				{key: "k1", body: `{}`, principal: alice},
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated, http.StatusCreated},
+			wantReplayed: []bool{false, false, true},
+			wantCalls:    2,
+		},
+		{
+			name:   "server errors are not stored",
+			status: http.StatusBadGateway,
+			requests: []idempotencyRequest{
+				{key: "k1", body: `{}`},
+				{key: "k1", body: `{}`},
+			},
+			wantStatuses: []int{http.StatusBadGateway, http.StatusBadGateway},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name:   "client errors are stored",
+			status: http.StatusPaymentRequired,
+			requests: []idempotencyRequest{
+				{key: "k1", body: `{}`},
+				{key: "k1", body: `{}`},
+			},
+			wantStatuses: []int{http.StatusPaymentRequired, http.StatusPaymentRequired},
+			wantReplayed: []bool{false, true},
+			wantCalls:    1,
+		},
+		{
+			name:   "no key",
+			status: http.StatusCreated,
+			requests: []idempotencyRequest{
+				{body: `{}`},
+				{body: `{}`},
+			},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name:         "required key",
+			opts:         IdempotencyOptions{Required: true},
+			status:       http.StatusCreated,
+			requests:     []idempotencyRequest{{body: `{}`}},
+			wantStatuses: []int{http.StatusBadRequest},
+			wantReplayed: []bool{false},
+			wantCalls:    0,
+		},
+		{
+			name:   "safe methods are not applied",
+			status: http.StatusOK,
+			requests: []idempotencyRequest{
+				{method: http.MethodPut, key: "k1"},
+				{method: http.MethodPut, key: "k1"},
+			},
+			wantStatuses: []int{http.StatusOK, http.StatusOK},
+			wantReplayed: []bool{false, false},
+			wantCalls:    2,
+		},
+		{
+			name:         "key too long",
+			opts:         IdempotencyOptions{MaxKeyLength: 4},
+			status:       http.StatusCreated,
+			requests:     []idempotencyRequest{{key: "12345", body: `{}`}},
+			wantStatuses: []int{http.StatusBadRequest},
+			wantReplayed: []bool{false},
+			wantCalls:    0,
+		},
+		{
+			name:         "body too large",
+			opts:         IdempotencyOptions{MaxBodySize: 4},
+			status:       http.StatusCreated,
+			requests:     []idempotencyRequest{{key: "k1", body: `{"a":1}`}},
+			wantStatuses: []int{http.StatusRequestEntityTooLarge},
+			wantReplayed: []bool{false},
+			wantCalls:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			m := Idempotency(tt.opts)
+			h := paymentHandler(&calls, tt.status)
+
+			var first *httptest.ResponseRecorder
+			for i, ir := range tt.requests {
+				w := serve(m, h, ir.build())
+
+				if w.Code != tt.wantStatuses[i] {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, tt.wantStatuses[i])
+				}
+
+				replayed := w.Header().Get(response.HeaderIdempotentReplayed) == "true"
+				if replayed != tt.wantReplayed[i] {
+					t.Fatalf("request %d: replayed = %v, want %v", i, replayed, tt.wantReplayed[i])
+				}
+
+				if replayed {
+					if w.Body.String() != first.Body.String() {
+						t.Errorf("request %d: body = %q, want %q", i, w.Body.String(), first.Body.String())
+					}
+					if got, want := w.Header().Get("X-Payment-Id"), first.Header().Get("X-Payment-Id"); got != want {
+						t.Errorf("request %d: X-Payment-Id = %q, want %q", i, got, want)
+					}
+				}
+
+				if i == 0 {
+					first = w
+				}
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotency_ConcurrentRequests(t *testing.T) {
+	var calls atomic.Int32
+	m := Idempotency(IdempotencyOptions{})
+	h := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		time.Sleep(20 * time.Millisecond)
+
+		return paymentHandler(&calls, http.StatusCreated)(r, f)
+	}
+
+	const requests = 5
+
+	var wg sync.WaitGroup
+	bodies := make(chan string, requests)
+	for range requests {
+		wg.Go(func() {
+			w := serve(m, h, idempotencyRequest{key: "k1", body: `{}`}.build())
+			bodies <- w.Body.String()
+		})
+	}
+	wg.Wait()
+	close(bodies)
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler calls = %d, want 1", got)
+	}
+
+	var first string
+	for body := range bodies {
+		if first == "" {
+			first = body
+		}
+		if body != first {
+			t.Errorf("body = %q, want %q", body, first)
+		}
+	}
+}
+
+func TestIdempotency_LockTimeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	m := Idempotency(IdempotencyOptions{LockTimeout: 20 * time.Millisecond})
+	h := func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		close(started)
+		<-release
+
+		return paymentHandler(&calls, http.StatusCreated)(r, f)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve(m, h, idempotencyRequest{key: "k1", body: `{}`}.build())
+	}()
+	<-started
+
+	w := serve(m, h, idempotencyRequest{key: "k1", body: `{}`}.build())
+	close(release)
+	<-done
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestMemoryIdempotencyStore_TTL(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	ctx := t.Context()
+
+	if err := store.Set(ctx, "short", &IdempotencyRecord{StatusCode: http.StatusCreated}, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "long", &IdempotencyRecord{StatusCode: http.StatusCreated}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	tests := []struct {
+		key   string
+		found bool
+	}{
+		{key: "short", found: false},
+		{key: "long", found: true},
+		{key: "missing", found: false},
+	}
+
+	for _, tt := range tests {
+		record, err := store.Get(ctx, tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (record != nil) != tt.found {
+			t.Errorf("Get(%q) found = %v, want %v", tt.key, record != nil, tt.found)
+		}
+	}
+}
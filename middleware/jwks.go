@@ -0,0 +1,444 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultJWKSRefreshInterval    = time.Hour
+	defaultJWKSMinRefreshInterval = time.Minute
+	defaultJWKSFetchTimeout       = 10 * time.Second
+	maxJWKSSize                   = 1 << 20 // 1 MB
+	maxJWKSBackoffShift           = 16
+)
+
+var (
+	ErrKeyNotFound       = errors.New("verification key not found")
+	ErrUnsupportedKey    = errors.New("unsupported key type")
+	ErrJWKSFetchFailed   = errors.New("failed to fetch jwks")
+	ErrKeyAlgMismatch    = errors.New("key does not match algorithm")
+	ErrInvalidKeyContent = errors.New("invalid key content")
+)
+
+// KeyProvider resolves a key to verify a token signature.
+// The returned key is []byte for HMAC, *rsa.PublicKey for RSA and *ecdsa.PublicKey for ECDSA algorithms.
+//
+//go:generate mockery
+type KeyProvider interface {
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// KeyProviderFunc is a function adapter for KeyProvider interface.
+type KeyProviderFunc func(ctx context.Context, kid, alg string) (any, error)
+
+// Key calls f(ctx, kid, alg).
+func (f KeyProviderFunc) Key(ctx context.Context, kid, alg string) (any, error) {
+	return f(ctx, kid, alg)
+}
+
+// StaticKey creates a KeyProvider that always returns the given key.
+// Use []byte for HMAC secrets, *rsa.PublicKey or *ecdsa.PublicKey for asymmetric algorithms.
+// It panics if the HMAC secret is empty.
+//
+//nolint:ireturn,nolintlint // its ok
+func StaticKey(key any) KeyProvider {
+	if secret, ok := key.([]byte); ok && len(secret) == 0 {
+		panic("middleware: empty HMAC secret")
+	}
+
+	return KeyProviderFunc(func(context.Context, string, string) (any, error) {
+		return key, nil
+	})
+}
+
+// JWK is a JSON Web Key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+
+	// Symmetric
+	K string `json:"k,omitempty"`
+}
+
+// JWKS is a parsed JSON Web Key Set.
+type JWKS struct {
+	keys []jwksKey
+}
+
+type jwksKey struct {
+	kid string
+	alg string
+	key any
+}
+
+// ParseJWKS parses a JSON Web Key Set document.
+// Keys with "use" other than "sig" and unsupported key types are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var doc struct {
+		Keys []JWK `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to decode jwks")
+	}
+
+	set := &JWKS{keys: make([]jwksKey, 0, len(doc.Keys))}
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedKey) {
+				continue
+			}
+
+			return nil, errors.Wrapf(err, "invalid jwk %q", jwk.Kid)
+		}
+
+		set.keys = append(set.keys, jwksKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+
+	return set, nil
+}
+
+// LoadJWKSFile reads and parses a JSON Web Key Set from a local file.
+func LoadJWKSFile(filename string) (*JWKS, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read jwks file")
+	}
+
+	return ParseJWKS(data)
+}
+
+// Key returns the key matching kid and algorithm.
+// If the token has no kid, the first key compatible with the algorithm is returned.
+func (s *JWKS) Key(_ context.Context, kid, alg string) (any, error) {
+	for _, k := range s.keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+
+		if checkKeyAlg(k.key, alg) != nil {
+			continue
+		}
+
+		return k.key, nil
+	}
+
+	return nil, errors.WithStack(ErrKeyNotFound)
+}
+
+// Len returns the number of keys in the set.
+func (s *JWKS) Len() int {
+	return len(s.keys)
+}
+
+// PublicKey converts JWK to the verification key.
+func (k JWK) PublicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Wrapf(ErrUnsupportedKey, "curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidKeyContent, err.Error())
+		}
+
+		if len(secret) == 0 {
+			return nil, errors.Wrap(ErrInvalidKeyContent, "empty secret")
+		}
+
+		return secret, nil
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedKey, "kty %q", k.Kty)
+	}
+}
+
+// RemoteJWKSOptions configures RemoteJWKS.
+type RemoteJWKSOptions struct {
+	// Client is used to fetch the key set (default: http.DefaultClient).
+	Client *http.Client
+
+	// RefreshInterval is how often the key set is refreshed (default: 1h).
+	RefreshInterval time.Duration
+
+	// MinRefreshInterval limits refreshes triggered by unknown kid
+	// and is the maximum delay between retries of failed fetches (default: 1m).
+	MinRefreshInterval time.Duration
+
+	// FetchTimeout limits a fetch of the key set (default: 10s).
+	FetchTimeout time.Duration
+}
+
+// RemoteJWKS is a KeyProvider that fetches JSON Web Key Set from a URL.
+// The set is fetched lazily, refreshed periodically and when a token references an unknown kid.
+//
+// A single fetch runs at a time, detached from the requests, while the last fetched set keeps being served.
+// Requests wait only for the first fetch or a refresh triggered by an unknown kid.
+// Failed fetches are retried with exponential backoff.
+type RemoteJWKS struct {
+	url  string
+	opts RemoteJWKSOptions
+
+	mu        sync.Mutex
+	set       *JWKS
+	fetchedAt time.Time
+	fetching  chan struct{} // Closed when the running fetch completes
+	lastErr   error
+	failures  int
+	retryAt   time.Time
+}
+
+// NewRemoteJWKS creates a KeyProvider that fetches JSON Web Key Set from the URL.
+func NewRemoteJWKS(url string, opts RemoteJWKSOptions) *RemoteJWKS {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	if opts.RefreshInterval == 0 {
+		opts.RefreshInterval = defaultJWKSRefreshInterval
+	}
+
+	if opts.MinRefreshInterval == 0 {
+		opts.MinRefreshInterval = defaultJWKSMinRefreshInterval
+	}
+
+	if opts.FetchTimeout == 0 {
+		opts.FetchTimeout = defaultJWKSFetchTimeout
+	}
+
+	return &RemoteJWKS{url: url, opts: opts}
+}
+
+// Key returns the key matching kid and algorithm, fetching the key set when needed.
+func (s *RemoteJWKS) Key(ctx context.Context, kid, alg string) (any, error) {
+	set, err := s.keySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := set.Key(ctx, kid, alg)
+	if err == nil || !errors.Is(err, ErrKeyNotFound) {
+		return key, err
+	}
+
+	// Unknown key may be rotated, refresh the set (rate limited)
+	set, err = s.keySet(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return set.Key(ctx, kid, alg)
+}
+
+// keySet returns the key set starting a fetch when it is due.
+// It waits for the fetch only if there is no set yet or the set is refreshed for an unknown kid.
+func (s *RemoteJWKS) keySet(ctx context.Context, unknownKid bool) (*JWKS, error) {
+	s.mu.Lock()
+
+	now := time.Now()
+	due := s.set == nil || now.Sub(s.fetchedAt) > s.opts.RefreshInterval
+	if unknownKid {
+		due = now.Sub(s.fetchedAt) >= s.opts.MinRefreshInterval
+	}
+	due = due && !now.Before(s.retryAt)
+
+	if due && s.fetching == nil {
+		s.fetching = make(chan struct{})
+		go s.fetch(s.fetching)
+	}
+
+	set, fetching := s.set, s.fetching
+	wait := fetching != nil && (set == nil || unknownKid && due)
+	if !wait {
+		defer s.mu.Unlock()
+
+		return s.result()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+		return nil, errors.Wrap(ErrJWKSFetchFailed, context.Cause(ctx).Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.result()
+}
+
+// result returns the last fetched set or the fetch error, must be called with the lock held.
+func (s *RemoteJWKS) result() (*JWKS, error) {
+	if s.set != nil {
+		return s.set, nil
+	}
+
+	if s.lastErr != nil {
+		return nil, s.lastErr
+	}
+
+	return nil, errors.WithStack(ErrJWKSFetchFailed)
+}
+
+// fetch downloads the key set and closes done, failures delay the next attempt.
+func (s *RemoteJWKS) fetch(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.FetchTimeout)
+	defer cancel()
+
+	set, err := s.download(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer close(done)
+
+	s.fetching = nil
+	s.lastErr = err
+
+	if err != nil {
+		s.failures++
+		s.retryAt = time.Now().Add(s.backoff())
+
+		return
+	}
+
+	s.set = set
+	s.fetchedAt = time.Now()
+	s.failures = 0
+	s.retryAt = time.Time{}
+}
+
+// backoff returns the delay before the next fetch attempt, doubling from 1s up to MinRefreshInterval.
+func (s *RemoteJWKS) backoff() time.Duration {
+	delay := time.Second << min(s.failures-1, maxJWKSBackoffShift)
+
+	return min(delay, s.opts.MinRefreshInterval)
+}
+
+// download fetches and parses the key set.
+func (s *RemoteJWKS) download(ctx context.Context) (*JWKS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, http.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(ErrJWKSFetchFailed, err.Error())
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(ErrJWKSFetchFailed, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(ErrJWKSFetchFailed, "unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, errors.Wrap(ErrJWKSFetchFailed, err.Error())
+	}
+
+	return ParseJWKS(data)
+}
+
+// checkKeyAlg checks the key type is compatible with the algorithm.
+func checkKeyAlg(key any, alg string) error {
+	var ok bool
+
+	switch {
+	case strings.HasPrefix(alg, "HS"):
+		var secret []byte
+		secret, ok = key.([]byte)
+		ok = ok && len(secret) > 0 // Empty secret would verify tokens signed by anyone
+	case strings.HasPrefix(alg, "RS"):
+		_, ok = key.(*rsa.PublicKey)
+	case strings.HasPrefix(alg, "ES"):
+		_, ok = key.(*ecdsa.PublicKey)
+	}
+
+	if !ok {
+		return errors.WithStack(ErrKeyAlgMismatch)
+	}
+
+	return nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.WithStack(ErrInvalidKeyContent)
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,363 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: JWTAlgRS256,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) JWK {
+	return JWK{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func jwksDocument(t *testing.T, keys ...JWK) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestJWKS_Key(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := ParseJWKS(jwksDocument(t,
+		JWK{Kty: "RSA", Kid: "enc", Use: "enc", N: "AQAB", E: "AQAB"},
+		rsaJWK("rsa-1", &rsaKey.PublicKey),
+		ecJWK("ec-1", &ecKey.PublicKey),
+		JWK{Kty: "oct", Kid: "hmac-1", K: base64.RawURLEncoding.EncodeToString(testHMACSecret)},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if set.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", set.Len())
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		alg     string
+		want    any
+		wantErr error
+	}{
+		{name: "rsa by kid", kid: "rsa-1", alg: JWTAlgRS256, want: &rsaKey.PublicKey},
+		{name: "ec by kid", kid: "ec-1", alg: JWTAlgES256, want: &ecKey.PublicKey},
+		{name: "hmac by kid", kid: "hmac-1", alg: JWTAlgHS256, want: testHMACSecret},
+		{name: "first compatible without kid", alg: JWTAlgES256, want: &ecKey.PublicKey},
+		{name: "unknown kid", kid: "rsa-2", alg: JWTAlgRS256, wantErr: ErrKeyNotFound},
+		{name: "kid with another algorithm", kid: "rsa-1", alg: JWTAlgRS512, wantErr: ErrKeyNotFound},
+		{name: "kid with another key type", kid: "hmac-1", alg: JWTAlgRS256, wantErr: ErrKeyNotFound},
+		{name: "encryption key is skipped", kid: "enc", alg: JWTAlgRS256, wantErr: ErrKeyNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := set.Key(t.Context(), tt.kid, tt.alg)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !keysEqual(key, tt.want) {
+				t.Errorf("key = %v, want %v", key, tt.want)
+			}
+		})
+	}
+}
+
+func keysEqual(a, b any) bool {
+	switch k := a.(type) {
+	case *rsa.PublicKey:
+		return k.Equal(b)
+	case *ecdsa.PublicKey:
+		return k.Equal(b)
+	case []byte:
+		other, ok := b.([]byte)
+
+		return ok && string(k) == string(other)
+	default:
+		return false
+	}
+}
+
+func TestParseJWKS_InvalidKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     JWK
+		wantErr error
+	}{
+		{name: "empty hmac secret", key: JWK{Kty: "oct", K: ""}, wantErr: ErrInvalidKeyContent},
+		{name: "unknown curve", key: JWK{Kty: "EC", Crv: "P-192", X: "AQ", Y: "AQ"}, wantErr: ErrUnsupportedKey},
+		{name: "rsa without modulus", key: JWK{Kty: "RSA", E: "AQAB"}, wantErr: ErrInvalidKeyContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.key.PublicKey(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStaticKey_EmptySecret(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("StaticKey did not panic")
+		}
+	}()
+
+	StaticKey([]byte{})
+}
+
+// jwksServer serves the key set, counting fetches. Fetches are blocked while release is not closed.
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	document []byte
+	status   int
+	release  chan struct{}
+	fetches  atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, document []byte) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{document: document, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.fetches.Add(1)
+
+		s.mu.Lock()
+		release, status, document := s.release, s.status, s.document
+		s.mu.Unlock()
+
+		if release != nil {
+			<-release
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write(document)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *jwksServer) set(status int, document []byte, release chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status, s.document, s.release = status, document, release
+}
+
+func TestRemoteJWKS_SingleFetchForConcurrentRequests(t *testing.T) {
+	doc := jwksDocument(t, JWK{Kty: "oct", Kid: "k1", K: base64.RawURLEncoding.EncodeToString(testHMACSecret)})
+	server := newJWKSServer(t, doc)
+	release := make(chan struct{})
+	server.set(http.StatusOK, doc, release)
+
+	remote := NewRemoteJWKS(server.URL, RemoteJWKSOptions{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Go(func() {
+			_, err := remote.Key(context.Background(), "k1", JWTAlgHS256)
+			errs <- err
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
+
+func TestRemoteJWKS_CanceledRequestDoesNotAbortFetch(t *testing.T) {
+	doc := jwksDocument(t, JWK{Kty: "oct", Kid: "k1", K: base64.RawURLEncoding.EncodeToString(testHMACSecret)})
+	server := newJWKSServer(t, doc)
+	release := make(chan struct{})
+	server.set(http.StatusOK, doc, release)
+
+	remote := NewRemoteJWKS(server.URL, RemoteJWKSOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := remote.Key(ctx, "k1", JWTAlgHS256); !errors.Is(err, ErrJWKSFetchFailed) {
+		t.Fatalf("err = %v, want %v", err, ErrJWKSFetchFailed)
+	}
+
+	close(release)
+
+	if _, err := remote.Key(context.Background(), "k1", JWTAlgHS256); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
+
+func TestRemoteJWKS_UnknownKidRefresh(t *testing.T) {
+	secret := base64.RawURLEncoding.EncodeToString(testHMACSecret)
+	server := newJWKSServer(t, jwksDocument(t, JWK{Kty: "oct", Kid: "k1", K: secret}))
+
+	remote := NewRemoteJWKS(server.URL, RemoteJWKSOptions{MinRefreshInterval: time.Hour})
+
+	if _, err := remote.Key(t.Context(), "k1", JWTAlgHS256); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotated key is fetched once after MinRefreshInterval, then unknown kids are rate limited
+	server.set(http.StatusOK, jwksDocument(t, JWK{Kty: "oct", Kid: "k2", K: secret}), nil)
+	remote.mu.Lock()
+	remote.fetchedAt = time.Now().Add(-2 * time.Hour)
+	remote.mu.Unlock()
+
+	if _, err := remote.Key(t.Context(), "k2", JWTAlgHS256); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		if _, err := remote.Key(t.Context(), "k3", JWTAlgHS256); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("err = %v, want %v", err, ErrKeyNotFound)
+		}
+	}
+
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+}
+
+func TestRemoteJWKS_FailureBackoff(t *testing.T) {
+	server := newJWKSServer(t, nil)
+	server.set(http.StatusInternalServerError, nil, nil)
+
+	remote := NewRemoteJWKS(server.URL, RemoteJWKSOptions{MinRefreshInterval: time.Minute})
+
+	for range 5 {
+		if _, err := remote.Key(t.Context(), "k1", JWTAlgHS256); !errors.Is(err, ErrJWKSFetchFailed) {
+			t.Fatalf("err = %v, want %v", err, ErrJWKSFetchFailed)
+		}
+	}
+
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1 during backoff", got)
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Second},
+		{failures: 2, want: 2 * time.Second},
+		{failures: 4, want: 8 * time.Second},
+		{failures: 10, want: time.Minute},
+		{failures: 100, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		remote.failures = tt.failures
+		if got := remote.backoff(); got != tt.want {
+			t.Errorf("backoff() after %d failures = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRemoteJWKS_ServesStaleSetWhileRefreshing(t *testing.T) {
+	doc := jwksDocument(t, JWK{Kty: "oct", Kid: "k1", K: base64.RawURLEncoding.EncodeToString(testHMACSecret)})
+	server := newJWKSServer(t, doc)
+
+	remote := NewRemoteJWKS(server.URL, RemoteJWKSOptions{RefreshInterval: time.Hour})
+	if _, err := remote.Key(t.Context(), "k1", JWTAlgHS256); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	server.set(http.StatusOK, doc, release)
+
+	remote.mu.Lock()
+	remote.fetchedAt = time.Now().Add(-2 * time.Hour)
+	remote.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := remote.Key(context.Background(), "k1", JWTAlgHS256)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request waits for the background refresh")
+	}
+}
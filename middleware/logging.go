@@ -121,7 +121,11 @@ func Logging(cfg *LoggingConfig) (dr.Middleware, error) {
 				return next.Handle(r, f)
 			}
 
-			start := response.RequestStartTime(r.Context())
+			// Principal is set by auth middlewares deeper in the chain, keep a holder to see it
+			ctx := withPrincipalHolder(r.Context())
+			r = r.WithContext(ctx)
+
+			start := response.RequestStartTime(ctx)
 			resp := next.Handle(r, f)
 			duration := time.Since(start)
 
@@ -150,10 +154,8 @@ func Logging(cfg *LoggingConfig) (dr.Middleware, error) {
 				}
 			}
 
-			if user := r.Context().Value("user"); user != nil {
-				if username, ok := user.(string); ok {
-					logData.User = username
-				}
+			if principal, ok := PrincipalFromContext(ctx); ok && principal.Subject != "" {
+				logData.User = principal.Subject
 			}
 
 			// Extract custom fields from context
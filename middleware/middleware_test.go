@@ -0,0 +1,89 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestFactory() *dr.Factory {
+	return dr.New(dr.WithFormatter(formatter.NewJSON()))
+}
+
+// okHandler responds with 200 and "ok" body.
+func okHandler(r *http.Request, f *dr.Factory) *response.DataResponse {
+	return f.Success(r.Context(), "ok")
+}
+
+// serve runs the request through the middleware and the handler.
+func serve(m dr.Middleware, h dr.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	dr.WrapHandler(m(h), newTestFactory()).ServeHTTP(w, r)
+
+	return w
+}
+
+// logEntry is a message recorded by testLogger.
+type logEntry struct {
+	level string
+	msg   string
+	args  map[string]any
+}
+
+// testLogger records logged messages.
+type testLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *testLogger) Debug(_ context.Context, msg string, args ...any) { l.log("debug", msg, args) }
+func (l *testLogger) Info(_ context.Context, msg string, args ...any)  { l.log("info", msg, args) }
+func (l *testLogger) Warn(_ context.Context, msg string, args ...any)  { l.log("warn", msg, args) }
+func (l *testLogger) Error(_ context.Context, msg string, args ...any) { l.log("error", msg, args) }
+
+func (l *testLogger) log(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := logEntry{level: level, msg: msg, args: make(map[string]any, len(args)/2)}
+	for i := 0; i+1 < len(args); i += 2 {
+		if key, ok := args[i].(string); ok {
+			entry.args[key] = args[i+1]
+		}
+	}
+	l.entries = append(l.entries, entry)
+}
+
+// find returns the last entry with the message.
+func (l *testLogger) find(msg string) (logEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if l.entries[i].msg == msg {
+			return l.entries[i], true
+		}
+	}
+
+	return logEntry{}, false
+}
+
+// chain combines middlewares, the first one is the outermost.
+func chain(middlewares ...dr.Middleware) dr.Middleware {
+	return func(next dr.Handler) dr.Handler {
+		return dr.Chain(next, middlewares...)
+	}
+}
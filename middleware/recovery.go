@@ -18,8 +18,7 @@ import (
 
 func Recovery() dr.Middleware {
 	return func(next dr.Handler) dr.Handler {
-		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
-			var resp *response.DataResponse
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) (resp *response.DataResponse) {
 			defer func() {
 				if err := recover(); err != nil {
 					ctx := r.Context()
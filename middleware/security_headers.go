@@ -0,0 +1,411 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	cspNonceSize = 16
+
+	defaultHSTSMaxAge = 365 * 24 * time.Hour
+)
+
+// CSP directive names.
+const (
+	CSPDefaultSrc              = "default-src"
+	CSPScriptSrc               = "script-src"
+	CSPStyleSrc                = "style-src"
+	CSPImgSrc                  = "img-src"
+	CSPConnectSrc              = "connect-src"
+	CSPFontSrc                 = "font-src"
+	CSPObjectSrc               = "object-src"
+	CSPMediaSrc                = "media-src"
+	CSPFrameSrc                = "frame-src"
+	CSPWorkerSrc               = "worker-src"
+	CSPBaseURI                 = "base-uri"
+	CSPFormAction              = "form-action"
+	CSPFrameAncestors          = "frame-ancestors"
+	CSPUpgradeInsecureRequests = "upgrade-insecure-requests"
+	CSPReportURI               = "report-uri"
+	CSPReportTo                = "report-to"
+)
+
+// CSP source values.
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+)
+
+type cspNonceContextKey struct{}
+
+// CSPNonce returns the per-request CSP nonce generated by SecurityHeaders middleware.
+func CSPNonce(ctx context.Context) string {
+	if nonce, ok := ctx.Value(cspNonceContextKey{}).(string); ok {
+		return nonce
+	}
+
+	return ""
+}
+
+// HSTSPolicy configures Strict-Transport-Security header.
+type HSTSPolicy struct {
+	// MaxAge is the time the browser should remember that the site is only to be accessed using HTTPS.
+	MaxAge time.Duration
+
+	// IncludeSubDomains applies the rule to all subdomains.
+	IncludeSubDomains bool
+
+	// Preload allows the domain to be included in browsers preload lists.
+	Preload bool
+}
+
+// String builds the header value.
+func (p HSTSPolicy) String() string {
+	var sb strings.Builder
+	sb.WriteString("max-age=")
+	sb.WriteString(strconv.FormatInt(int64(p.MaxAge.Seconds()), 10))
+
+	if p.IncludeSubDomains {
+		sb.WriteString("; includeSubDomains")
+	}
+
+	if p.Preload {
+		sb.WriteString("; preload")
+	}
+
+	return sb.String()
+}
+
+// CSP is a Content-Security-Policy builder.
+type CSP struct {
+	names      []string
+	directives map[string][]string
+	nonce      bool
+	reportOnly bool
+}
+
+// NewCSP creates an empty Content-Security-Policy builder.
+func NewCSP() *CSP {
+	return &CSP{
+		directives: make(map[string][]string),
+	}
+}
+
+// Directive appends sources to a directive, preserving the directives order.
+func (c *CSP) Directive(name string, sources ...string) *CSP {
+	if _, ok := c.directives[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.directives[name] = append(c.directives[name], sources...)
+
+	return c
+}
+
+// DefaultSrc sets default-src directive.
+func (c *CSP) DefaultSrc(sources ...string) *CSP {
+	return c.Directive(CSPDefaultSrc, sources...)
+}
+
+// ScriptSrc sets script-src directive.
+func (c *CSP) ScriptSrc(sources ...string) *CSP {
+	return c.Directive(CSPScriptSrc, sources...)
+}
+
+// StyleSrc sets style-src directive.
+func (c *CSP) StyleSrc(sources ...string) *CSP {
+	return c.Directive(CSPStyleSrc, sources...)
+}
+
+// ImgSrc sets img-src directive.
+func (c *CSP) ImgSrc(sources ...string) *CSP {
+	return c.Directive(CSPImgSrc, sources...)
+}
+
+// ConnectSrc sets connect-src directive.
+func (c *CSP) ConnectSrc(sources ...string) *CSP {
+	return c.Directive(CSPConnectSrc, sources...)
+}
+
+// FontSrc sets font-src directive.
+func (c *CSP) FontSrc(sources ...string) *CSP {
+	return c.Directive(CSPFontSrc, sources...)
+}
+
+// ObjectSrc sets object-src directive.
+func (c *CSP) ObjectSrc(sources ...string) *CSP {
+	return c.Directive(CSPObjectSrc, sources...)
+}
+
+// FrameAncestors sets frame-ancestors directive.
+func (c *CSP) FrameAncestors(sources ...string) *CSP {
+	return c.Directive(CSPFrameAncestors, sources...)
+}
+
+// BaseURI sets base-uri directive.
+func (c *CSP) BaseURI(sources ...string) *CSP {
+	return c.Directive(CSPBaseURI, sources...)
+}
+
+// FormAction sets form-action directive.
+func (c *CSP) FormAction(sources ...string) *CSP {
+	return c.Directive(CSPFormAction, sources...)
+}
+
+// UpgradeInsecureRequests adds upgrade-insecure-requests directive.
+func (c *CSP) UpgradeInsecureRequests() *CSP {
+	return c.Directive(CSPUpgradeInsecureRequests)
+}
+
+// ReportURI sets report-uri directive.
+func (c *CSP) ReportURI(uri string) *CSP {
+	return c.Directive(CSPReportURI, uri)
+}
+
+// ReportTo sets report-to directive.
+func (c *CSP) ReportTo(group string) *CSP {
+	return c.Directive(CSPReportTo, group)
+}
+
+// WithNonce adds a per-request nonce source to script-src and style-src directives.
+// The nonce is available through CSPNonce(ctx) and the "cspNonce" function of formatter.HTML templates.
+func (c *CSP) WithNonce() *CSP {
+	c.nonce = true
+
+	return c
+}
+
+// ReportOnly sends the policy as Content-Security-Policy-Report-Only.
+func (c *CSP) ReportOnly() *CSP {
+	c.reportOnly = true
+
+	return c
+}
+
+// HeaderName returns the header name depending on report-only mode.
+func (c *CSP) HeaderName() string {
+	if c.reportOnly {
+		return response.HeaderContentSecurityPolicyReportOnly
+	}
+
+	return response.HeaderContentSecurityPolicy
+}
+
+// Build builds the header value with the given nonce (empty nonce is skipped).
+func (c *CSP) Build(nonce string) string {
+	names := c.names
+	if c.nonce && nonce != "" {
+		for _, name := range []string{CSPScriptSrc, CSPStyleSrc} {
+			if _, ok := c.directives[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		sources, ok := c.directives[name]
+		if !ok {
+			// script-src and style-src fall back to default-src, so keep its sources
+			sources = c.directives[CSPDefaultSrc]
+		}
+
+		if c.nonce && nonce != "" && (name == CSPScriptSrc || name == CSPStyleSrc) {
+			sources = append(sources[:len(sources):len(sources)], "'nonce-"+nonce+"'")
+		}
+
+		if len(sources) == 0 {
+			parts = append(parts, name)
+
+			continue
+		}
+		parts = append(parts, name+" "+strings.Join(sources, " "))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// PermissionsPolicy maps browser features to allowlists,
+// e.g. {"camera": {}, "geolocation": {"self", `"https://example.com"`}}.
+type PermissionsPolicy map[string][]string
+
+// String builds the header value with features sorted by name.
+func (p PermissionsPolicy) String() string {
+	features := make([]string, 0, len(p))
+	for feature := range p {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	parts := make([]string, 0, len(features))
+	for _, feature := range features {
+		allowlist := p[feature]
+		if len(allowlist) == 1 && allowlist[0] == corsWildcard {
+			parts = append(parts, feature+"=*")
+
+			continue
+		}
+		parts = append(parts, feature+"=("+strings.Join(allowlist, " ")+")")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// SecurityHeadersPolicy configures SecurityHeaders middleware.
+// Empty fields are not sent.
+type SecurityHeadersPolicy struct {
+	// HSTS configures Strict-Transport-Security.
+	HSTS *HSTSPolicy
+
+	// CSP configures Content-Security-Policy.
+	CSP *CSP
+
+	// PermissionsPolicy configures Permissions-Policy.
+	PermissionsPolicy PermissionsPolicy
+
+	// CrossOriginOpenerPolicy configures Cross-Origin-Opener-Policy.
+	CrossOriginOpenerPolicy string
+
+	// CrossOriginOpenerPolicyReportOnly sends COOP as Cross-Origin-Opener-Policy-Report-Only.
+	CrossOriginOpenerPolicyReportOnly bool
+
+	// CrossOriginEmbedderPolicy configures Cross-Origin-Embedder-Policy.
+	CrossOriginEmbedderPolicy string
+
+	// CrossOriginEmbedderPolicyReportOnly sends COEP as Cross-Origin-Embedder-Policy-Report-Only.
+	CrossOriginEmbedderPolicyReportOnly bool
+
+	// CrossOriginResourcePolicy configures Cross-Origin-Resource-Policy.
+	CrossOriginResourcePolicy string
+
+	// ReferrerPolicy configures Referrer-Policy.
+	ReferrerPolicy string
+
+	// FrameOptions configures X-Frame-Options.
+	FrameOptions string
+
+	// ContentTypeOptions configures X-Content-Type-Options.
+	ContentTypeOptions string
+}
+
+// SecurityHeaders creates a middleware that applies the security headers policy.
+// Headers already set by the handler are not overwritten nor duplicated.
+//
+// When CSP nonces are used together with Compression, SecurityHeaders must be registered
+// after Compression, so the nonce is bound before the body is formatted.
+func SecurityHeaders(policy SecurityHeadersPolicy) dr.Middleware {
+	static := make([][2]string, 0)
+	add := func(key, value string) {
+		if value != "" {
+			static = append(static, [2]string{key, value})
+		}
+	}
+
+	if policy.HSTS != nil {
+		add(response.HeaderStrictTransportSecurity, policy.HSTS.String())
+	}
+
+	if len(policy.PermissionsPolicy) > 0 {
+		add(response.HeaderPermissionsPolicy, policy.PermissionsPolicy.String())
+	}
+
+	if policy.CrossOriginOpenerPolicyReportOnly {
+		add(response.HeaderCrossOriginOpenerPolicyReportOnly, policy.CrossOriginOpenerPolicy)
+	} else {
+		add(response.HeaderCrossOriginOpenerPolicy, policy.CrossOriginOpenerPolicy)
+	}
+
+	if policy.CrossOriginEmbedderPolicyReportOnly {
+		add(response.HeaderCrossOriginEmbedderPolicyReportOnly, policy.CrossOriginEmbedderPolicy)
+	} else {
+		add(response.HeaderCrossOriginEmbedderPolicy, policy.CrossOriginEmbedderPolicy)
+	}
+
+	add(response.HeaderCrossOriginResourcePolicy, policy.CrossOriginResourcePolicy)
+	add(response.HeaderReferrerPolicy, policy.ReferrerPolicy)
+	add(response.HeaderXFrameOptions, policy.FrameOptions)
+	add(response.HeaderXContentTypeOptions, policy.ContentTypeOptions)
+
+	csp := policy.CSP
+	var staticCSP string
+	if csp != nil && !csp.nonce {
+		staticCSP = csp.Build("")
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			var nonce string
+			if csp != nil && csp.nonce {
+				var err error
+				nonce, err = generateNonce()
+				if err != nil {
+					return f.InternalError(r.Context(), response.WrapError(http.StatusInternalServerError, err, "failed to generate csp nonce"))
+				}
+				r = r.WithContext(context.WithValue(r.Context(), cspNonceContextKey{}, nonce))
+			}
+
+			resp := next.Handle(r, f)
+
+			for _, header := range static {
+				resp.WithDefaultHeader(header[0], header[1])
+			}
+
+			if csp != nil {
+				value := staticCSP
+				if nonce != "" {
+					value = csp.Build(nonce)
+					resp.WithCSPNonce(nonce)
+				}
+				resp.WithDefaultHeader(csp.HeaderName(), value)
+			}
+
+			return resp
+		})
+	}
+}
+
+// DefaultSecurityHeaders creates SecurityHeaders middleware with a strict policy for APIs.
+func DefaultSecurityHeaders() dr.Middleware {
+	return SecurityHeaders(SecurityHeadersPolicy{
+		HSTS: &HSTSPolicy{
+			MaxAge:            defaultHSTSMaxAge,
+			IncludeSubDomains: true,
+		},
+		CSP:                       NewCSP().DefaultSrc(CSPNone).FrameAncestors(CSPNone),
+		CrossOriginOpenerPolicy:   response.CrossOriginOpenerPolicySameOrigin,
+		CrossOriginResourcePolicy: response.CrossOriginResourcePolicySameOrigin,
+		ReferrerPolicy:            response.ReferrerPolicyStrictOriginWhenCrossOrigin,
+		FrameOptions:              response.FrameOptionsDeny,
+		ContentTypeOptions:        response.ContentTypeOptionsNoSniff,
+	})
+}
+
+// generateNonce generates a random base64 encoded nonce.
+func generateNonce() (string, error) {
+	b := make([]byte, cspNonceSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
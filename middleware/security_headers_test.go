@@ -0,0 +1,207 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"html"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func TestSecurityHeaders_PolicyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "hsts",
+			got:  HSTSPolicy{MaxAge: 24 * time.Hour}.String(),
+			want: "max-age=86400",
+		},
+		{
+			name: "hsts with subdomains and preload",
+			got:  HSTSPolicy{MaxAge: time.Hour, IncludeSubDomains: true, Preload: true}.String(),
+			want: "max-age=3600; includeSubDomains; preload",
+		},
+		{
+			name: "csp keeps directives order",
+			got:  NewCSP().ScriptSrc(CSPSelf).DefaultSrc(CSPNone).UpgradeInsecureRequests().Build(""),
+			want: "script-src 'self'; default-src 'none'; upgrade-insecure-requests",
+		},
+		{
+			name: "csp nonce falls back to default-src sources",
+			got:  NewCSP().DefaultSrc(CSPSelf).WithNonce().Build("abc"),
+			want: "default-src 'self'; script-src 'self' 'nonce-abc'; style-src 'self' 'nonce-abc'",
+		},
+		{
+			name: "csp nonce is not leaked into default-src",
+			got:  NewCSP().DefaultSrc(CSPSelf).ScriptSrc(CSPSelf).WithNonce().Build("abc"),
+			want: "default-src 'self'; script-src 'self' 'nonce-abc'; style-src 'self' 'nonce-abc'",
+		},
+		{
+			name: "permissions policy",
+			got: PermissionsPolicy{
+				"geolocation": {"self", `"https://example.com"`},
+				"camera":      {},
+				"fullscreen":  {"*"},
+			}.String(),
+			want: `camera=(), fullscreen=*, geolocation=(self "https://example.com")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders_Headers(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SecurityHeadersPolicy
+		handler dr.HandlerFunc
+		want    map[string][]string
+	}{
+		{
+			name:    "default policy",
+			policy:  SecurityHeadersPolicy{},
+			handler: okHandler,
+			want: map[string][]string{
+				response.HeaderStrictTransportSecurity: nil,
+				response.HeaderContentSecurityPolicy:   nil,
+			},
+		},
+		{
+			name: "all headers",
+			policy: SecurityHeadersPolicy{
+				HSTS:                      &HSTSPolicy{MaxAge: time.Hour},
+				CSP:                       NewCSP().DefaultSrc(CSPNone),
+				CrossOriginOpenerPolicy:   response.CrossOriginOpenerPolicySameOrigin,
+				CrossOriginEmbedderPolicy: response.CrossOriginEmbedderPolicyRequireCorp,
+				CrossOriginResourcePolicy: response.CrossOriginResourcePolicySameSite,
+				ReferrerPolicy:            response.ReferrerPolicyNoReferrer,
+				FrameOptions:              response.FrameOptionsDeny,
+				ContentTypeOptions:        response.ContentTypeOptionsNoSniff,
+			},
+			handler: okHandler,
+			want: map[string][]string{
+				response.HeaderStrictTransportSecurity:   {"max-age=3600"},
+				response.HeaderContentSecurityPolicy:     {"default-src 'none'"},
+				response.HeaderCrossOriginOpenerPolicy:   {"same-origin"},
+				response.HeaderCrossOriginEmbedderPolicy: {"require-corp"},
+				response.HeaderCrossOriginResourcePolicy: {"same-site"},
+				response.HeaderReferrerPolicy:            {"no-referrer"},
+				response.HeaderXFrameOptions:             {"DENY"},
+				response.HeaderXContentTypeOptions:       {"nosniff"},
+			},
+		},
+		{
+			name: "report only",
+			policy: SecurityHeadersPolicy{
+				CSP:                                 NewCSP().DefaultSrc(CSPSelf).ReportOnly(),
+				CrossOriginOpenerPolicy:             response.CrossOriginOpenerPolicySameOrigin,
+				CrossOriginOpenerPolicyReportOnly:   true,
+				CrossOriginEmbedderPolicy:           response.CrossOriginEmbedderPolicyCredentialless,
+				CrossOriginEmbedderPolicyReportOnly: true,
+			},
+			handler: okHandler,
+			want: map[string][]string{
+				response.HeaderContentSecurityPolicy:               nil,
+				response.HeaderContentSecurityPolicyReportOnly:     {"default-src 'self'"},
+				response.HeaderCrossOriginOpenerPolicy:             nil,
+				response.HeaderCrossOriginOpenerPolicyReportOnly:   {"same-origin"},
+				response.HeaderCrossOriginEmbedderPolicy:           nil,
+				response.HeaderCrossOriginEmbedderPolicyReportOnly: {"credentialless"},
+			},
+		},
+		{
+			name: "handler headers are kept",
+			policy: SecurityHeadersPolicy{
+				FrameOptions: response.FrameOptionsDeny,
+				CSP:          NewCSP().DefaultSrc(CSPNone),
+			},
+			handler: func(r *http.Request, f *dr.Factory) *response.DataResponse {
+				return f.Success(r.Context(), "ok").
+					WithHeader(response.HeaderXFrameOptions, response.FrameOptionsSameOrigin).
+					WithHeader(response.HeaderContentSecurityPolicy, "default-src 'self'")
+			},
+			want: map[string][]string{
+				response.HeaderXFrameOptions:         {"SAMEORIGIN"},
+				response.HeaderContentSecurityPolicy: {"default-src 'self'"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(SecurityHeaders(tt.policy), tt.handler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			for name, want := range tt.want {
+				got := w.Header().Values(name)
+				if strings.Join(got, "\n") != strings.Join(want, "\n") {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders_Nonce(t *testing.T) {
+	tmpl := template.Must(template.New("page").Funcs(formatter.HTMLFuncs()).
+		Parse(`<script nonce="{{cspNonce}}"></script>`))
+	f := dr.New(dr.WithFormatter(formatter.NewHTML().WithTemplate(tmpl)))
+
+	var ctxNonce string
+	h := SecurityHeaders(SecurityHeadersPolicy{
+		CSP: NewCSP().DefaultSrc(CSPSelf).WithNonce(),
+	})(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		ctxNonce = CSPNonce(r.Context())
+
+		return f.Success(r.Context(), nil)
+	}))
+
+	nonces := make(map[string]bool)
+	for range 3 {
+		w := httptest.NewRecorder()
+		dr.WrapHandler(h, f).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if ctxNonce == "" {
+			t.Fatal("CSPNonce() is empty")
+		}
+
+		csp := w.Header().Get(response.HeaderContentSecurityPolicy)
+		if !strings.Contains(csp, "script-src 'self' 'nonce-"+ctxNonce+"'") {
+			t.Errorf("Content-Security-Policy = %q, want nonce %q", csp, ctxNonce)
+		}
+
+		// The attribute is escaped by html/template, e.g. "+" as "&#43;"
+		body := html.UnescapeString(w.Body.String())
+		if want := `<script nonce="` + ctxNonce + `"></script>`; body != want {
+			t.Errorf("body = %q, want %q", body, want)
+		}
+
+		nonces[ctxNonce] = true
+	}
+
+	if len(nonces) != 3 {
+		t.Errorf("nonces are reused: %v", nonces)
+	}
+}
@@ -0,0 +1,252 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// TimeoutOptions configures Timeout middleware.
+type TimeoutOptions struct {
+	// Timeout is the maximum duration of the handler (default: 30s).
+	Timeout time.Duration
+
+	// StatusCode is returned when the timeout elapses: 503 (default) or 504.
+	StatusCode int
+
+	// RetryAfter is sent in Retry-After header (not sent if zero).
+	RetryAfter time.Duration
+
+	// Message is the error message (default: status text).
+	Message string
+}
+
+type timeoutScopeContextKey struct{}
+
+// timeoutScope is shared by nested Timeout middlewares, so the innermost (route) timeout
+// replaces the outer (global) one instead of being capped by it.
+type timeoutScope struct {
+	mu      sync.Mutex
+	parent  context.Context // Request context without timeouts
+	start   time.Time
+	timer   *time.Timer
+	opts    TimeoutOptions
+	expired bool
+}
+
+// override replaces the timeout, returns false if the timeout already elapsed.
+func (s *timeoutScope) override(opts TimeoutOptions) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expired {
+		return false
+	}
+
+	s.opts = opts
+	s.timer.Reset(time.Until(s.start.Add(opts.Timeout)))
+
+	return true
+}
+
+// expire marks the scope expired, returns false if it is already expired.
+func (s *timeoutScope) expire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expired {
+		return false
+	}
+	s.expired = true
+
+	return true
+}
+
+// complete marks the scope completed by the handler, returns false if the timeout already elapsed,
+// even when the timer has not fired yet.
+func (s *timeoutScope) complete() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expired {
+		return false
+	}
+	s.expired = true
+
+	return time.Now().Before(s.start.Add(s.opts.Timeout))
+}
+
+func (s *timeoutScope) options() TimeoutOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.opts
+}
+
+// Timeout creates a middleware that runs the handler with a derived context deadline,
+// its context reports the deadline and fails with context.DeadlineExceeded (cause http.ErrHandlerTimeout).
+// When the deadline elapses before the handler returns, a 503 (or 504) response is returned
+// and the late response is discarded and closed.
+//
+// Nested Timeout middlewares (e.g. global and per-route group) override each other,
+// the innermost timeout wins and is measured from the request start. Middlewares registered
+// between them see the outer deadline.
+//
+// The handler runs in its own goroutine on a copy of the request with its path values,
+// after the timeout it keeps running until it observes the context. Router state reused after
+// the request completes must not be read by it, e.g. use http.Request.PathValue (mirrored by chiadapter)
+// instead of chi.URLParam with plain chi.
+func Timeout(opts TimeoutOptions) dr.Middleware {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	if opts.StatusCode == 0 {
+		opts.StatusCode = http.StatusServiceUnavailable
+	}
+
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			parent := r.Context()
+
+			if scope, ok := parent.Value(timeoutScopeContextKey{}).(*timeoutScope); ok && scope.override(opts) {
+				// Derived from the values only, the outer deadline must not cap the overriding one
+				ctx, cancel := context.WithDeadlineCause(context.WithoutCancel(parent),
+					scope.start.Add(opts.Timeout), http.ErrHandlerTimeout)
+				defer cancel()
+
+				stop := context.AfterFunc(scope.parent, cancel)
+				defer stop()
+
+				return next.Handle(r.WithContext(ctx), f)
+			}
+
+			expired := make(chan struct{})
+			scope := &timeoutScope{
+				parent: parent,
+				start:  time.Now(),
+				opts:   opts,
+			}
+			scope.timer = time.AfterFunc(opts.Timeout, func() {
+				if scope.expire() {
+					close(expired)
+				}
+			})
+			defer scope.timer.Stop()
+
+			// Not canceled on timeout, the handler observes the deadline itself
+			ctx, cancel := context.WithDeadlineCause(context.WithValue(parent, timeoutScopeContextKey{}, scope),
+				scope.start.Add(opts.Timeout), http.ErrHandlerTimeout)
+
+			// The request is cloned, the handler may outlive it
+			req := r.Clone(ctx)
+
+			done := make(chan *response.DataResponse, 1)
+			panicked := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+
+				done <- next.Handle(req, f)
+			}()
+
+			timedOut := func() *response.DataResponse {
+				current := scope.options()
+
+				f.Logger().Warn(parent, "request timed out",
+					"timeout", current.Timeout.String(),
+					"method", r.Method,
+					"path", r.URL.Path,
+				)
+
+				return timeoutResponse(parent, f, current)
+			}
+
+			select {
+			case resp := <-done:
+				cancel()
+
+				if !scope.complete() {
+					// The handler returned after the deadline, e.g. observing it before the timer fired
+					closeLateResponse(parent, f, resp)
+
+					return timedOut()
+				}
+
+				return resp
+			case p := <-panicked:
+				cancel()
+
+				// Re-panic in the request goroutine, so Recovery middleware can handle it
+				panic(p)
+			case <-expired:
+				go discardLateResponse(parent, f, done, panicked, cancel)
+
+				return timedOut()
+			case <-parent.Done():
+				go discardLateResponse(parent, f, done, panicked, cancel)
+
+				// Client has gone, the response will not be delivered
+				return f.Error(parent, http.StatusServiceUnavailable, "Request canceled")
+			}
+		})
+	}
+}
+
+// timeoutResponse creates 503 or 504 response with Retry-After header.
+func timeoutResponse(ctx context.Context, f *dr.Factory, opts TimeoutOptions) *response.DataResponse {
+	resp := f.Error(ctx, opts.StatusCode, opts.Message)
+
+	if opts.RetryAfter > 0 {
+		resp.SetHeader(response.HeaderRetryAfter, retryAfterSeconds(opts.RetryAfter))
+	}
+
+	return resp
+}
+
+// discardLateResponse waits for the handler, closes its response and releases its context.
+func discardLateResponse(
+	ctx context.Context,
+	f *dr.Factory,
+	done <-chan *response.DataResponse,
+	panicked <-chan any,
+	cancel context.CancelFunc,
+) {
+	defer cancel()
+
+	select {
+	case resp := <-done:
+		closeLateResponse(ctx, f, resp)
+	case p := <-panicked:
+		f.Logger().Error(ctx, "handler panicked after timeout", "panic", p)
+	}
+}
+
+// closeLateResponse closes the response discarded after the timeout.
+func closeLateResponse(ctx context.Context, f *dr.Factory, resp *response.DataResponse) {
+	if resp == nil {
+		return
+	}
+
+	if err := resp.Close(); err != nil {
+		f.Logger().Warn(ctx, "failed to close late response", "error", err.Error())
+	}
+}
@@ -0,0 +1,228 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// sleepHandler responds after the delay, unless the request context is done first.
+func sleepHandler(delay time.Duration) dr.HandlerFunc {
+	return func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		select {
+		case <-time.After(delay):
+			return f.Success(r.Context(), "ok")
+		case <-r.Context().Done():
+			return f.ServiceUnavailable(r.Context(), "canceled")
+		}
+	}
+}
+
+// closerFunc is a closer calling the function.
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		m              dr.Middleware
+		handler        dr.HandlerFunc
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{
+			name:       "fast handler",
+			m:          Timeout(TimeoutOptions{Timeout: time.Second}),
+			handler:    sleepHandler(0),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "service unavailable by default",
+			m:          Timeout(TimeoutOptions{Timeout: 20 * time.Millisecond}),
+			handler:    sleepHandler(time.Second),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name: "gateway timeout with retry after",
+			m: Timeout(TimeoutOptions{
+				Timeout:    20 * time.Millisecond,
+				StatusCode: http.StatusGatewayTimeout,
+				RetryAfter: 30 * time.Second,
+			}),
+			handler:        sleepHandler(time.Second),
+			wantStatus:     http.StatusGatewayTimeout,
+			wantRetryAfter: "30",
+		},
+		{
+			name: "inner timeout extends the outer one",
+			m: chain(
+				Timeout(TimeoutOptions{Timeout: 20 * time.Millisecond}),
+				Timeout(TimeoutOptions{Timeout: time.Second}),
+			),
+			handler:    sleepHandler(100 * time.Millisecond),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "inner timeout shortens the outer one",
+			m: chain(
+				Timeout(TimeoutOptions{Timeout: time.Second}),
+				Timeout(TimeoutOptions{Timeout: 20 * time.Millisecond, StatusCode: http.StatusGatewayTimeout}),
+			),
+			handler:    sleepHandler(time.Second),
+			wantStatus: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.m, tt.handler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderRetryAfter); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestTimeout_ContextDeadline(t *testing.T) {
+	type result struct {
+		hasDeadline bool
+		err         error
+		cause       error
+	}
+	results := make(chan result, 1)
+
+	m := Timeout(TimeoutOptions{Timeout: 20 * time.Millisecond})
+	w := serve(m, func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		ctx := r.Context()
+		_, ok := ctx.Deadline()
+		<-ctx.Done()
+		results <- result{hasDeadline: ok, err: ctx.Err(), cause: context.Cause(ctx)}
+
+		return f.Success(ctx, "late")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	got := <-results
+	if !got.hasDeadline {
+		t.Error("handler context has no deadline")
+	}
+	if !errors.Is(got.err, context.DeadlineExceeded) {
+		t.Errorf("ctx.Err() = %v, want %v", got.err, context.DeadlineExceeded)
+	}
+	if !errors.Is(got.cause, http.ErrHandlerTimeout) {
+		t.Errorf("context.Cause() = %v, want %v", got.cause, http.ErrHandlerTimeout)
+	}
+}
+
+func TestTimeout_LateResponseIsClosed(t *testing.T) {
+	closed := make(chan struct{})
+	release := make(chan struct{})
+
+	m := Timeout(TimeoutOptions{Timeout: 20 * time.Millisecond})
+	w := serve(m, func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		<-release
+
+		return f.Success(r.Context(), "late").WithFile(closerFunc(func() error {
+			close(closed)
+
+			return nil
+		}), "late.txt")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("late response is not closed")
+	}
+}
+
+func TestTimeout_LateNilResponse(t *testing.T) {
+	done := make(chan *response.DataResponse, 1)
+	done <- nil
+
+	var canceled bool
+	discardLateResponse(t.Context(), newTestFactory(), done, nil, func() { canceled = true })
+
+	if !canceled {
+		t.Error("handler context is not released")
+	}
+}
+
+func TestTimeout_PanicIsRecovered(t *testing.T) {
+	m := chain(Recovery(), Timeout(TimeoutOptions{Timeout: time.Second}))
+	w := serve(m, func(*http.Request, *dr.Factory) *response.DataResponse {
+		panic("boom")
+	}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    dr.HandlerFunc
+		wantStatus int
+	}{
+		{
+			name:       "no panic",
+			handler:    okHandler,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "panic",
+			handler: func(*http.Request, *dr.Factory) *response.DataResponse {
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "panic with error",
+			handler: func(*http.Request, *dr.Factory) *response.DataResponse {
+				panic(errors.New("boom"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(Recovery(), tt.handler, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+type wrapContextKey struct{}
+
+// basicAuth is a typical std middleware writing its own error response.
+func basicAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="restricted"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// upperWriter is a writer of a std middleware transforming the body of next, e.g. like compression.
+type upperWriter struct {
+	http.ResponseWriter
+}
+
+func (uw upperWriter) Write(b []byte) (int, error) {
+	return uw.ResponseWriter.Write(bytes.ToUpper(b))
+}
+
+// statusWriter is a writer of a std middleware capturing the status, e.g. like logging.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(statusCode int) {
+	sw.status = statusCode
+	sw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// contextHandler responds with the context value set by the std middleware.
+func contextHandler(r *http.Request, f *Factory) *response.DataResponse {
+	value, _ := r.Context().Value(wrapContextKey{}).(string)
+
+	return f.Success(r.Context(), value).SetHeader("X-Next", "called")
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		stdM            func(http.Handler) http.Handler
+		opts            []WrapMiddlewareOption
+		next            HandlerFunc
+		header          map[string]string
+		wantStatus      int
+		wantHeader      map[string]string
+		wantBody        string
+		wantContentType string
+	}{
+		{
+			name: "next response is passed through with middleware headers",
+			stdM: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Request-Id", "42")
+					next.ServeHTTP(w, r)
+				})
+			},
+			next:            contextHandler,
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{"X-Request-Id": "42", "X-Next": "called"},
+			wantBody:        `""`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "context values are propagated to next",
+			stdM: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), wrapContextKey{}, "tenant-1")))
+				})
+			},
+			next:            contextHandler,
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{"X-Next": "called"},
+			wantBody:        `"tenant-1"`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "authorized",
+			stdM:            basicAuth,
+			next:            contextHandler,
+			header:          map[string]string{"Authorization": "Basic YWRtaW46c2VjcmV0"},
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{"X-Next": "called", "WWW-Authenticate": ""},
+			wantBody:        `""`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "short-circuit error is preserved",
+			stdM:            basicAuth,
+			next:            contextHandler,
+			wantStatus:      http.StatusUnauthorized,
+			wantHeader:      map[string]string{"WWW-Authenticate": `Basic realm="restricted"`, "X-Next": ""},
+			wantBody:        "Unauthorized",
+			wantContentType: "text/plain",
+		},
+		{
+			name:            "short-circuit error is re-formatted by the factory",
+			stdM:            basicAuth,
+			opts:            []WrapMiddlewareOption{WithFactoryErrors()},
+			next:            contextHandler,
+			wantStatus:      http.StatusUnauthorized,
+			wantHeader:      map[string]string{"WWW-Authenticate": `Basic realm="restricted"`, "X-Next": ""},
+			wantBody:        `{"code":"UNAUTHORIZED","status":"401","title":"Unauthorized"}`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "short-circuit content is preserved",
+			stdM: func(http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.Header().Set(response.HeaderContentType, "text/html")
+					_, _ = w.Write([]byte("<h1>maintenance</h1>"))
+				})
+			},
+			opts:            []WrapMiddlewareOption{WithFactoryErrors()},
+			next:            contextHandler,
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{"X-Next": ""},
+			wantBody:        "<h1>maintenance</h1>",
+			wantContentType: "text/html",
+		},
+		{
+			name: "next errors are not re-formatted",
+			stdM: func(next http.Handler) http.Handler {
+				return next
+			},
+			opts: []WrapMiddlewareOption{WithFactoryErrors()},
+			next: func(r *http.Request, f *Factory) *response.DataResponse {
+				return f.NotFound(r.Context(), "user not found")
+			},
+			wantStatus:      http.StatusNotFound,
+			wantBody:        `{"code":"NOT_FOUND","status":"404","title":"user not found"}`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "next is written through the middleware writer",
+			stdM: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("X-Transformed", "upper")
+					next.ServeHTTP(upperWriter{w}, r)
+				})
+			},
+			next: func(r *http.Request, f *Factory) *response.DataResponse {
+				return f.Success(r.Context(), "hello").SetHeader("X-Next", "called")
+			},
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{"X-Transformed": "upper", "X-Next": "called"},
+			wantBody:        `"HELLO"`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "next written through a wrapping writer keeps one Content-Length",
+			stdM: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(&statusWriter{ResponseWriter: w}, r)
+				})
+			},
+			next:            contextHandler,
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{response.HeaderContentLength: "3", "X-Next": "called"},
+			wantBody:        `""`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "content length set by the middleware is dropped",
+			stdM: func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set(response.HeaderContentLength, "100")
+					next.ServeHTTP(w, r)
+				})
+			},
+			next:            contextHandler,
+			wantStatus:      http.StatusOK,
+			wantHeader:      map[string]string{response.HeaderContentLength: "3"},
+			wantBody:        `""`,
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "middleware writer interfaces",
+			stdM: func(http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					_, flusher := w.(http.Flusher)
+					_, hijacker := w.(http.Hijacker)
+					if !flusher || !hijacker {
+						w.WriteHeader(http.StatusNotImplemented)
+					}
+				})
+			},
+			next:       contextHandler,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "flushing middleware streams",
+			stdM: func(http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = w.Write([]byte("data: 1\n\n"))
+					w.(http.Flusher).Flush()
+					_, _ = w.Write([]byte("data: 2\n\n"))
+				})
+			},
+			next:       contextHandler,
+			wantStatus: http.StatusOK,
+			wantBody:   "data: 1\n\ndata: 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New(WithFormatter(formatter.NewJSON()))
+			h := WrapHandler(Chain(tt.next, WrapMiddleware(tt.stdM, tt.opts...)), f)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			for name, want := range tt.wantHeader {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Values(response.HeaderContentLength); len(got) > 1 {
+				t.Errorf("Content-Length = %q, want one value", got)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestWrapMiddleware_PassesDataResponse(t *testing.T) {
+	f := New(WithFormatter(formatter.NewJSON()))
+
+	var got *response.DataResponse
+	inspect := func(next Handler) Handler {
+		return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+			got = next.Handle(r, f)
+
+			return got
+		})
+	}
+	passthrough := WrapMiddleware(func(next http.Handler) http.Handler { return next })
+
+	data := map[string]int{"id": 7}
+	h := WrapHandler(Chain(HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+		return f.Created(r.Context(), data, "/users/7")
+	}), passthrough, inspect), f)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+	if got == nil {
+		t.Fatal("response is not passed")
+	}
+	if got.StatusCode() != http.StatusCreated {
+		t.Errorf("StatusCode() = %d, want %d", got.StatusCode(), http.StatusCreated)
+	}
+	if m, ok := got.Data().(map[string]int); !ok || m["id"] != 7 {
+		t.Errorf("Data() = %#v, want the data of next", got.Data())
+	}
+	if got := w.Header().Get(response.HeaderLocation); got != "/users/7" {
+		t.Errorf("Location = %q, want %q", got, "/users/7")
+	}
+}
+
+func TestWrapMiddleware_Panic(t *testing.T) {
+	h := WrapHandler(Chain(HandlerFunc(contextHandler), WrapMiddleware(func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("middleware panic")
+		})
+	})), New(WithFormatter(formatter.NewJSON())))
+
+	defer func() {
+		if p := recover(); p != "middleware panic" {
+			t.Errorf("recovered %v, want the middleware panic", p)
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
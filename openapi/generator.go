@@ -0,0 +1,407 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+// Package openapi generates OpenAPI 3.1 documents from routes registered on dr.ServeMux or chiadapter.Router.
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	// ErrorSchemaName is the component name of factory error responses.
+	ErrorSchemaName = "Error"
+
+	// ValidationErrorSchemaName is the component name of factory validation error responses.
+	ValidationErrorSchemaName = "ValidationError"
+
+	defaultTitle   = "API"
+	defaultVersion = "1.0.0"
+
+	responseDefault = "default"
+)
+
+// RouteSource provides registered routes, implemented by dr.ServeMux and chiadapter.Router.
+type RouteSource interface {
+	Routes() []dr.Route
+}
+
+// Options configures the document generation.
+type Options struct {
+	// Info is the API metadata (default title: "API", version: "1.0.0").
+	Info Info
+
+	// Servers lists API servers.
+	Servers []Server
+
+	// Tags describes operation tags.
+	Tags []Tag
+
+	// SecuritySchemes defines security schemes referenced by security requirements.
+	SecuritySchemes map[string]*SecurityScheme
+
+	// Security is the default security requirements of operations.
+	Security []SecurityRequirement
+
+	// ContentType is the media type of request and response bodies (default: application/json).
+	ContentType string
+
+	// ErrorType is the body type of factory error responses (default: dr.Template).
+	// Set it along with dr.WithErrorBuilder.
+	ErrorType any
+
+	// ValidationErrorType is the body type of factory validation error responses
+	// (default: ErrorType with required errors list). Set it along with dr.WithValidationErrorBuilder.
+	ValidationErrorType any
+
+	// DescribedOnly excludes routes registered without openapi.Describe.
+	DescribedOnly bool
+}
+
+// Generate creates the document from the routes.
+// Routes matching any method and catch-all routes without metadata are skipped.
+func Generate(routes []dr.Route, opts Options) *Document {
+	if opts.Info.Title == "" {
+		opts.Info.Title = defaultTitle
+	}
+	if opts.Info.Version == "" {
+		opts.Info.Version = defaultVersion
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = response.ContentTypeJSON
+	}
+
+	g := &generator{
+		opts:      opts,
+		reflector: NewReflector(),
+	}
+	g.defineErrors()
+
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     opts.Info,
+		Servers:  opts.Servers,
+		Tags:     opts.Tags,
+		Security: opts.Security,
+		Paths:    make(map[string]*PathItem),
+	}
+
+	for _, route := range routes {
+		path, op, ok := g.operation(route)
+		if !ok {
+			continue
+		}
+
+		item, exists := doc.Paths[path]
+		if !exists {
+			item = &PathItem{}
+		}
+
+		if item.setOperation(route.Method, op) && !exists {
+			doc.Paths[path] = item
+		}
+	}
+
+	doc.Components = &Components{
+		Schemas:         g.reflector.Schemas(),
+		SecuritySchemes: opts.SecuritySchemes,
+	}
+
+	return doc
+}
+
+type generator struct {
+	opts      Options
+	reflector *Reflector
+
+	errorRef           *Schema
+	validationErrorRef *Schema
+}
+
+func (g *generator) defineErrors() {
+	errorType := g.opts.ErrorType
+	if errorType == nil {
+		errorType = dr.Template{}
+	}
+	g.errorRef = g.reflector.Define(ErrorSchemaName, g.reflector.Schema(errorType))
+
+	if g.opts.ValidationErrorType != nil {
+		g.validationErrorRef = g.reflector.Define(ValidationErrorSchemaName, g.reflector.Schema(g.opts.ValidationErrorType))
+
+		return
+	}
+
+	g.validationErrorRef = g.reflector.Define(ValidationErrorSchemaName, &Schema{
+		AllOf: []*Schema{
+			g.errorRef,
+			{Required: []string{"errors"}},
+		},
+	})
+}
+
+func (g *generator) operation(route dr.Route) (string, *OperationObject, bool) {
+	meta, described := operationOf(route.Metadata)
+	if meta.Hidden || (g.opts.DescribedOnly && !described) || route.Method == "" {
+		return "", nil, false
+	}
+
+	path, pathParams, catchAll := convertPattern(route.Pattern)
+	if path == "" || (catchAll && !described) {
+		return "", nil, false
+	}
+
+	op := &OperationObject{
+		OperationID: meta.OperationID,
+		Summary:     meta.Summary,
+		Description: meta.Description,
+		Tags:        meta.Tags,
+		Deprecated:  meta.Deprecated,
+		Responses:   make(map[string]*ResponseObject),
+	}
+
+	if meta.Security != nil {
+		op.Security = &meta.Security
+	}
+
+	op.Parameters = g.parameters(pathParams, meta.Parameters)
+
+	if meta.Request != nil {
+		contentType := meta.RequestContentType
+		if contentType == "" {
+			contentType = g.opts.ContentType
+		}
+
+		op.RequestBody = &RequestBodyObject{
+			Required: true,
+			Content: map[string]*MediaTypeObject{
+				contentType: {Schema: g.reflector.Schema(meta.Request)},
+			},
+		}
+	}
+
+	for status, body := range meta.Responses {
+		op.Responses[strconv.Itoa(status)] = g.response(status, g.reflector.Schema(body))
+	}
+
+	if len(meta.Responses) == 0 {
+		op.Responses[strconv.Itoa(http.StatusOK)] = &ResponseObject{Description: http.StatusText(http.StatusOK)}
+	}
+
+	g.addErrorResponses(op, meta, len(pathParams) > 0)
+
+	return path, op, true
+}
+
+// addErrorResponses adds responses the factory creates for the operation, unless they are described.
+func (g *generator) addErrorResponses(op *OperationObject, meta Operation, hasPathParams bool) {
+	hasInput := meta.Request != nil || len(op.Parameters) > 0
+
+	security := g.opts.Security
+	if meta.Security != nil {
+		security = meta.Security
+	}
+
+	errorResponses := make(map[int]*Schema)
+	if hasInput {
+		errorResponses[http.StatusBadRequest] = g.errorRef
+		errorResponses[http.StatusUnprocessableEntity] = g.validationErrorRef
+	}
+	if len(security) > 0 {
+		errorResponses[http.StatusUnauthorized] = g.errorRef
+		errorResponses[http.StatusForbidden] = g.errorRef
+	}
+	if hasPathParams {
+		errorResponses[http.StatusNotFound] = g.errorRef
+	}
+
+	for status, schema := range errorResponses {
+		key := strconv.Itoa(status)
+		if _, exists := op.Responses[key]; !exists {
+			op.Responses[key] = g.response(status, schema)
+		}
+	}
+
+	if _, exists := op.Responses[responseDefault]; !exists {
+		op.Responses[responseDefault] = &ResponseObject{
+			Description: "Error",
+			Content: map[string]*MediaTypeObject{
+				g.opts.ContentType: {Schema: g.errorRef},
+			},
+		}
+	}
+}
+
+func (g *generator) response(status int, schema *Schema) *ResponseObject {
+	resp := &ResponseObject{Description: http.StatusText(status)}
+	if resp.Description == "" {
+		resp.Description = strconv.Itoa(status)
+	}
+
+	if schema != nil {
+		resp.Content = map[string]*MediaTypeObject{
+			g.opts.ContentType: {Schema: schema},
+		}
+	}
+
+	return resp
+}
+
+func (g *generator) parameters(pathParams []pathParam, params []Parameter) []*ParameterObject {
+	result := make([]*ParameterObject, 0, len(pathParams)+len(params))
+
+	for _, pp := range pathParams {
+		param := &ParameterObject{
+			Name:     pp.name,
+			In:       InPath,
+			Required: true,
+			Schema:   &Schema{Type: "string", Pattern: pp.pattern},
+		}
+
+		for _, p := range params {
+			if p.In == InPath && p.Name == pp.name {
+				param.Description = p.Description
+				param.Deprecated = p.Deprecated
+				if p.Type != nil {
+					param.Schema = g.reflector.Schema(p.Type)
+				}
+			}
+		}
+
+		result = append(result, param)
+	}
+
+	for _, p := range params {
+		if p.In == InPath {
+			continue
+		}
+
+		schema := g.reflector.Schema(p.Type)
+		if schema == nil {
+			schema = &Schema{Type: "string"}
+		}
+
+		result = append(result, &ParameterObject{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required,
+			Deprecated:  p.Deprecated,
+			Schema:      schema,
+		})
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+func operationOf(metadata any) (Operation, bool) {
+	switch meta := metadata.(type) {
+	case Operation:
+		return meta, true
+	case *Operation:
+		if meta != nil {
+			return *meta, true
+		}
+	case dr.HandlerTypes:
+		return typedOperation(meta), true
+	}
+
+	return Operation{}, false
+}
+
+type pathParam struct {
+	name    string
+	pattern string
+}
+
+// convertPattern converts ServeMux ("/files/{path...}", "/{$}") and chi ("/users/{id:[0-9]+}", "/static/*")
+// patterns into OpenAPI path templates. A trailing chi wildcard becomes the "path" parameter
+// and is reported as catch-all, usually it is a mounted handler.
+func convertPattern(pattern string) (path string, params []pathParam, catchAll bool) {
+	// host patterns of ServeMux: "example.com/path"
+	if idx := strings.Index(pattern, "/"); idx > 0 {
+		pattern = pattern[idx:]
+	} else if idx < 0 {
+		return "", nil, false
+	}
+
+	pattern = strings.TrimSuffix(pattern, "{$}")
+
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '{':
+			end := closingBrace(pattern, i)
+			if end < 0 {
+				return "", nil, false
+			}
+
+			name, regexp, _ := strings.Cut(pattern[i+1:end], ":")
+			name = strings.TrimSuffix(name, "...")
+
+			params = append(params, pathParam{name: name, pattern: anchorPattern(regexp)})
+			sb.WriteString("{" + name + "}")
+			i = end
+		case '*':
+			if i != len(pattern)-1 {
+				sb.WriteByte(c)
+
+				continue
+			}
+
+			params = append(params, pathParam{name: "path"})
+			sb.WriteString("{path}")
+			catchAll = true
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String(), params, catchAll
+}
+
+// closingBrace returns the index of the brace closing the one at start, regexps may contain braces.
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func anchorPattern(regexp string) string {
+	if regexp == "" {
+		return ""
+	}
+
+	if !strings.HasPrefix(regexp, "^") {
+		regexp = "^" + regexp
+	}
+	if !strings.HasSuffix(regexp, "$") {
+		regexp += "$"
+	}
+
+	return regexp
+}
@@ -0,0 +1,377 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+type User struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreateUserRequest struct {
+	Name string `json:"name"`
+}
+
+type GetUserRequest struct {
+	ID      int64  `path:"id" description:"User ID"`
+	Expand  string `query:"expand"`
+	TraceID string `header:"X-Trace-Id"`
+}
+
+type UpdateUserRequest struct {
+	ID   int64  `path:"id"`
+	Name string `json:"name"`
+}
+
+type UploadRequest struct {
+	Title string `form:"title"`
+}
+
+func newTestMux() *dr.ServeMux {
+	return dr.NewServeMux(dr.New(dr.WithFormatter(formatter.NewJSON())))
+}
+
+func okHandler(r *http.Request, f *dr.Factory) *response.DataResponse {
+	return f.Success(r.Context(), "ok")
+}
+
+func getUser(context.Context, GetUserRequest) (User, error) {
+	return User{}, nil
+}
+
+func updateUser(context.Context, UpdateUserRequest) (User, error) {
+	return User{}, nil
+}
+
+func upload(context.Context, UploadRequest) (User, error) {
+	return User{}, nil
+}
+
+// responseKeys returns the sorted response keys of the operation.
+func responseKeys(op *OperationObject) []string {
+	keys := make([]string, 0, len(op.Responses))
+	for key := range op.Responses {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+// parameterKeys returns "in:name" of the operation parameters.
+func parameterKeys(op *OperationObject) []string {
+	keys := make([]string, 0, len(op.Parameters))
+	for _, p := range op.Parameters {
+		keys = append(keys, p.In+":"+p.Name)
+	}
+
+	return keys
+}
+
+func TestGenerate_Operations(t *testing.T) {
+	bearer := []SecurityRequirement{{"bearer": {}}}
+
+	tests := []struct {
+		name           string
+		pattern        string
+		handler        dr.Handler
+		opts           Options
+		path           string
+		method         string
+		wantSkipped    bool
+		wantParams     []string
+		wantResponses  []string
+		wantBody       string // Request body media type, empty if no body
+		wantBodySchema string // Request body schema reference
+		wantSecurity   *[]SecurityRequirement
+	}{
+		{
+			name:          "undescribed route",
+			pattern:       "GET /health",
+			handler:       dr.HandlerFunc(okHandler),
+			path:          "/health",
+			method:        http.MethodGet,
+			wantResponses: []string{"200", "default"},
+		},
+		{
+			name:    "described route",
+			pattern: "POST /users",
+			handler: Describe(dr.HandlerFunc(okHandler), Operation{
+				OperationID: "createUser",
+				Request:     CreateUserRequest{},
+				Responses:   map[int]any{http.StatusCreated: User{}},
+			}),
+			path:           "/users",
+			method:         http.MethodPost,
+			wantResponses:  []string{"201", "400", "422", "default"},
+			wantBody:       response.ContentTypeJSON,
+			wantBodySchema: componentSchemaPrefix + "CreateUserRequest",
+		},
+		{
+			name:          "path parameters",
+			pattern:       "DELETE /users/{id}",
+			handler:       Describe(dr.HandlerFunc(okHandler), Operation{Responses: map[int]any{http.StatusNoContent: nil}}),
+			path:          "/users/{id}",
+			method:        http.MethodDelete,
+			wantParams:    []string{"path:id"},
+			wantResponses: []string{"204", "400", "404", "422", "default"},
+		},
+		{
+			name:          "typed handler parameters",
+			pattern:       "GET /users/{id}",
+			handler:       dr.TypedHandler(getUser),
+			path:          "/users/{id}",
+			method:        http.MethodGet,
+			wantParams:    []string{"path:id", "query:expand", "header:X-Trace-Id"},
+			wantResponses: []string{"200", "400", "404", "422", "default"},
+		},
+		{
+			name:           "typed handler body",
+			pattern:        "PUT /users/{id}",
+			handler:        dr.TypedHandler(updateUser),
+			path:           "/users/{id}",
+			method:         http.MethodPut,
+			wantParams:     []string{"path:id"},
+			wantResponses:  []string{"200", "400", "404", "422", "default"},
+			wantBody:       response.ContentTypeJSON,
+			wantBodySchema: componentSchemaPrefix + "UpdateUserRequest",
+		},
+		{
+			name:           "typed handler form",
+			pattern:        "POST /uploads",
+			handler:        dr.TypedHandler(upload),
+			path:           "/uploads",
+			method:         http.MethodPost,
+			wantResponses:  []string{"200", "400", "422", "default"},
+			wantBody:       response.ContentTypeForm,
+			wantBodySchema: componentSchemaPrefix + "UploadRequest",
+		},
+		{
+			name:    "described typed handler",
+			pattern: "GET /users/{id}",
+			handler: Describe(dr.TypedHandler(getUser), Operation{
+				Summary:    "Get user",
+				Responses:  map[int]any{http.StatusOK: User{}, http.StatusNotFound: nil},
+				Parameters: []Parameter{{Name: "expand", In: InQuery, Description: "Relations"}},
+			}),
+			path:          "/users/{id}",
+			method:        http.MethodGet,
+			wantParams:    []string{"path:id", "query:expand", "header:X-Trace-Id"},
+			wantResponses: []string{"200", "400", "404", "422", "default"},
+		},
+		{
+			name:          "secured route",
+			pattern:       "GET /me",
+			handler:       dr.HandlerFunc(okHandler),
+			opts:          Options{Security: bearer},
+			path:          "/me",
+			method:        http.MethodGet,
+			wantResponses: []string{"200", "401", "403", "default"},
+		},
+		{
+			name:          "public route",
+			pattern:       "GET /login",
+			handler:       Describe(dr.HandlerFunc(okHandler), Operation{Security: []SecurityRequirement{}}),
+			opts:          Options{Security: bearer},
+			path:          "/login",
+			method:        http.MethodGet,
+			wantResponses: []string{"200", "default"},
+			wantSecurity:  &[]SecurityRequirement{},
+		},
+		{
+			name:          "host pattern",
+			pattern:       "GET api.example.com/status",
+			handler:       dr.HandlerFunc(okHandler),
+			path:          "/status",
+			method:        http.MethodGet,
+			wantResponses: []string{"200", "default"},
+		},
+		{
+			name:        "hidden route",
+			pattern:     "GET /internal",
+			handler:     Describe(dr.HandlerFunc(okHandler), Operation{Hidden: true}),
+			path:        "/internal",
+			wantSkipped: true,
+		},
+		{
+			name:        "any method",
+			pattern:     "/any",
+			handler:     dr.HandlerFunc(okHandler),
+			path:        "/any",
+			wantSkipped: true,
+		},
+		{
+			name:        "described only",
+			pattern:     "GET /health",
+			handler:     dr.HandlerFunc(okHandler),
+			opts:        Options{DescribedOnly: true},
+			path:        "/health",
+			wantSkipped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newTestMux()
+			mux.Handle(tt.pattern, tt.handler)
+
+			doc := Generate(mux.Routes(), tt.opts)
+
+			item := doc.Paths[tt.path]
+			if tt.wantSkipped {
+				if item != nil {
+					t.Errorf("path %q is documented", tt.path)
+				}
+
+				return
+			}
+			if item == nil {
+				t.Fatalf("path %q is not documented: %v", tt.path, doc.Paths)
+			}
+
+			op := map[string]*OperationObject{
+				http.MethodGet:    item.Get,
+				http.MethodPost:   item.Post,
+				http.MethodPut:    item.Put,
+				http.MethodDelete: item.Delete,
+			}[tt.method]
+			if op == nil {
+				t.Fatalf("operation %s is not documented", tt.method)
+			}
+
+			if got := parameterKeys(op); !slices.Equal(got, tt.wantParams) && (len(got) != 0 || len(tt.wantParams) != 0) {
+				t.Errorf("parameters = %v, want %v", got, tt.wantParams)
+			}
+			if got := responseKeys(op); !slices.Equal(got, tt.wantResponses) {
+				t.Errorf("responses = %v, want %v", got, tt.wantResponses)
+			}
+
+			if tt.wantBody == "" {
+				if op.RequestBody != nil {
+					t.Errorf("request body = %+v, want none", op.RequestBody)
+				}
+			} else {
+				media := op.RequestBody.Content[tt.wantBody]
+				if media == nil || media.Schema.Ref != tt.wantBodySchema {
+					t.Errorf("request body = %+v, want %s %s", op.RequestBody.Content, tt.wantBody, tt.wantBodySchema)
+				}
+			}
+
+			if tt.wantSecurity != nil && (op.Security == nil || len(*op.Security) != len(*tt.wantSecurity)) {
+				t.Errorf("security = %v, want %v", op.Security, *tt.wantSecurity)
+			}
+		})
+	}
+}
+
+func TestGenerate_Document(t *testing.T) {
+	mux := newTestMux()
+	mux.Handle("GET /users/{id}", Describe(dr.TypedHandler(getUser), Operation{
+		Summary:    "Get user",
+		Tags:       []string{"users"},
+		Deprecated: true,
+		Parameters: []Parameter{{Name: "id", In: InPath, Description: "Identifier", Type: int64(0)}},
+	}))
+	mux.Handle("DELETE /users/{id}", dr.TypedHandler(getUser))
+
+	doc := Generate(mux.Routes(), Options{
+		Info:            Info{Title: "Users"},
+		SecuritySchemes: map[string]*SecurityScheme{"bearer": BearerAuth("JWT")},
+	})
+
+	if doc.OpenAPI != Version || doc.Info.Title != "Users" || doc.Info.Version != defaultVersion {
+		t.Errorf("document header = %s %+v", doc.OpenAPI, doc.Info)
+	}
+
+	item := doc.Paths["/users/{id}"]
+	if item == nil || item.Get == nil || item.Delete == nil {
+		t.Fatalf("path item = %+v, want GET and DELETE", item)
+	}
+
+	get := item.Get
+	if get.Summary != "Get user" || !get.Deprecated || !slices.Equal(get.Tags, []string{"users"}) {
+		t.Errorf("operation = %+v", get)
+	}
+
+	id := get.Parameters[0]
+	if id.Name != "id" || !id.Required || id.Description != "Identifier" || id.Schema.Type != "integer" {
+		t.Errorf("path parameter = %+v, schema %+v", id, id.Schema)
+	}
+
+	for _, name := range []string{"User", ErrorSchemaName, ValidationErrorSchemaName} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("component %q is not defined", name)
+		}
+	}
+	if doc.Components.SecuritySchemes["bearer"].Scheme != "bearer" {
+		t.Errorf("security schemes = %v", doc.Components.SecuritySchemes)
+	}
+	if ref := get.Responses["200"].Content[response.ContentTypeJSON].Schema.Ref; ref != componentSchemaPrefix+"User" {
+		t.Errorf("200 response schema = %q, want the User reference", ref)
+	}
+}
+
+func TestConvertPattern(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		wantPath     string
+		wantParams   []pathParam
+		wantCatchAll bool
+	}{
+		{pattern: "/users", wantPath: "/users"},
+		{pattern: "/users/{id}", wantPath: "/users/{id}", wantParams: []pathParam{{name: "id"}}},
+		{pattern: "/files/{path...}", wantPath: "/files/{path}", wantParams: []pathParam{{name: "path"}}},
+		{pattern: "/{$}", wantPath: "/"},
+		{pattern: "api.example.com/users", wantPath: "/users"},
+		{
+			pattern:    "/users/{id:[0-9]+}",
+			wantPath:   "/users/{id}",
+			wantParams: []pathParam{{name: "id", pattern: "^[0-9]+$"}},
+		},
+		{
+			pattern:    "/codes/{code:[a-z]{2}}",
+			wantPath:   "/codes/{code}",
+			wantParams: []pathParam{{name: "code", pattern: "^[a-z]{2}$"}},
+		},
+		{
+			pattern:      "/static/*",
+			wantPath:     "/static/{path}",
+			wantParams:   []pathParam{{name: "path"}},
+			wantCatchAll: true,
+		},
+		{pattern: "/users/{id", wantPath: ""},
+		{pattern: "example.com", wantPath: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			path, params, catchAll := convertPattern(tt.pattern)
+
+			if path != tt.wantPath {
+				t.Errorf("path = %q, want %q", path, tt.wantPath)
+			}
+			if !slices.Equal(params, tt.wantParams) {
+				t.Errorf("params = %v, want %v", params, tt.wantParams)
+			}
+			if catchAll != tt.wantCatchAll {
+				t.Errorf("catch-all = %v, want %v", catchAll, tt.wantCatchAll)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const defaultDocsScriptURL = "https://cdn.jsdelivr.net/npm/redoc@2/bundles/redoc.standalone.js"
+
+//go:embed docs.html
+var docsTemplate string
+
+// Handler serves the document generated from the source routes.
+// It responds with YAML if the path ends with ".yaml" or ".yml", or the format=yaml query parameter is set,
+// otherwise with JSON, e.g.:
+//
+//	router.Handle(http.MethodGet, "/openapi.json", openapi.Handler(router, opts))
+//	router.Handle(http.MethodGet, "/openapi.yaml", openapi.Handler(router, opts))
+//
+// The document is generated on the first request and regenerated when routes are added.
+func Handler(source RouteSource, opts Options) dr.Handler {
+	s := &specHandler{source: source, opts: opts}
+
+	return Describe(dr.HandlerFunc(s.handle), Operation{Hidden: true})
+}
+
+type specHandler struct {
+	source RouteSource
+	opts   Options
+
+	mu         sync.Mutex
+	routeCount int
+	json       []byte
+	yaml       []byte
+}
+
+func (s *specHandler) handle(r *http.Request, f *dr.Factory) *response.DataResponse {
+	jsonData, yamlData, err := s.document()
+	if err != nil {
+		return f.InternalError(r.Context(), response.WrapError(http.StatusInternalServerError, err, "failed to generate OpenAPI document"))
+	}
+
+	data, contentType := jsonData, response.ContentTypeJSON
+	if wantsYAML(r) {
+		data, contentType = yamlData, response.ContentTypeYAML
+	}
+
+	return f.CreateDataResponse(http.StatusOK, nil).
+		WithFormatted(response.FormattedResponse{
+			Stream:     bytes.NewReader(data),
+			StreamSize: int64(len(data)),
+		}).
+		WithContentType(contentType)
+}
+
+func (s *specHandler) document() (jsonData, yamlData []byte, err error) {
+	routes := s.source.Routes()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.json != nil && s.routeCount == len(routes) {
+		return s.json, s.yaml, nil
+	}
+
+	doc := Generate(routes, s.opts)
+
+	jsonData, err = json.MarshalIndent(doc, "", yamlIndent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	yamlData, err = doc.MarshalYAML()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.json, s.yaml, s.routeCount = jsonData, yamlData, len(routes)
+
+	return jsonData, yamlData, nil
+}
+
+func wantsYAML(r *http.Request) bool {
+	if strings.HasSuffix(r.URL.Path, ".yaml") || strings.HasSuffix(r.URL.Path, ".yml") {
+		return true
+	}
+
+	return r.URL.Query().Get("format") == "yaml"
+}
+
+// DocsOptions configures the documentation page.
+type DocsOptions struct {
+	// Title is the page title (default: "API").
+	Title string
+
+	// SpecURL is the document URL, e.g. "/openapi.json".
+	SpecURL string
+
+	// ScriptURL is the Redoc standalone bundle URL (default: jsDelivr CDN), set it to self-host the script.
+	ScriptURL string
+}
+
+type docsData struct {
+	Title     string
+	SpecURL   string
+	ScriptURL string
+}
+
+// DocsHandler serves the embedded documentation page rendering the document with Redoc.
+// The script tag carries the CSP nonce set by the middleware.SecurityHeaders.
+func DocsHandler(opts DocsOptions) dr.Handler {
+	if opts.Title == "" {
+		opts.Title = defaultTitle
+	}
+	if opts.ScriptURL == "" {
+		opts.ScriptURL = defaultDocsScriptURL
+	}
+
+	tmpl := template.Must(template.New("docs").Funcs(formatter.HTMLFuncs()).Parse(docsTemplate))
+	html := formatter.NewHTML().WithTemplate(tmpl)
+	data := docsData(opts)
+
+	return Describe(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return f.Success(r.Context(), data).WithFormatter(html)
+	}), Operation{Hidden: true})
+}
@@ -0,0 +1,187 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json",
+			target:          "/openapi.json",
+			wantContentType: response.ContentTypeJSON,
+			wantBody:        `"openapi": "` + Version + `"`,
+		},
+		{
+			name:            "yaml path",
+			target:          "/openapi.yaml",
+			wantContentType: response.ContentTypeYAML,
+			wantBody:        "openapi: \"" + Version + "\"\n",
+		},
+		{
+			name:            "yml path",
+			target:          "/openapi.yml",
+			wantContentType: response.ContentTypeYAML,
+			wantBody:        "openapi: \"" + Version + "\"\n",
+		},
+		{
+			name:            "yaml format query",
+			target:          "/openapi.json?format=yaml",
+			wantContentType: response.ContentTypeYAML,
+			wantBody:        "openapi: \"" + Version + "\"\n",
+		},
+	}
+
+	mux := newTestMux()
+	mux.HandleFunc("GET /users", okHandler)
+	h := Handler(mux, Options{Info: Info{Title: "Users"}})
+	mux.Handle("GET /openapi.json", h)
+	mux.Handle("GET /openapi.yaml", h)
+	mux.Handle("GET /openapi.yml", h)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+			if strings.Contains(w.Body.String(), "/openapi") {
+				t.Error("document routes are documented")
+			}
+		})
+	}
+}
+
+func TestHandler_RegeneratesOnNewRoutes(t *testing.T) {
+	mux := newTestMux()
+	mux.HandleFunc("GET /users", okHandler)
+	mux.Handle("GET /openapi.json", Handler(mux, Options{}))
+
+	paths := func() map[string]any {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+		var doc struct {
+			Paths map[string]any `json:"paths"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("decode %s: %v", w.Body.String(), err)
+		}
+
+		return doc.Paths
+	}
+
+	if got := paths(); len(got) != 1 {
+		t.Fatalf("paths = %v, want /users", got)
+	}
+
+	mux.HandleFunc("GET /orders", okHandler)
+
+	if got := paths(); len(got) != 2 || got["/orders"] == nil {
+		t.Errorf("paths = %v, want /users and /orders", got)
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "Users: API", Version: "1.0.0"},
+		Tags:    []Tag{{Name: "users"}, {Name: "true"}},
+		Paths:   map[string]*PathItem{},
+	}
+
+	data, err := doc.MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`openapi: "` + Version + `"`,
+		"info:",
+		`  title: "Users: API"`,
+		`  version: "1.0.0"`,
+		"tags:",
+		"  - name: users",
+		`  - name: "true"`,
+		"paths: {}",
+		"",
+	}, "\n")
+
+	if string(data) != want {
+		t.Errorf("MarshalYAML() =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     DocsOptions
+		wantBody []string
+	}{
+		{
+			name: "defaults",
+			opts: DocsOptions{SpecURL: "/openapi.json"},
+			wantBody: []string{
+				"<title>API</title>",
+				`spec-url="/openapi.json"`,
+				`src="` + defaultDocsScriptURL + `"`,
+			},
+		},
+		{
+			name: "self-hosted script",
+			opts: DocsOptions{Title: "Users", SpecURL: "/openapi.yaml", ScriptURL: "/static/redoc.js"},
+			wantBody: []string{
+				"<title>Users</title>",
+				`spec-url="/openapi.yaml"`,
+				`src="/static/redoc.js"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			dr.WrapHandler(DocsHandler(tt.opts), dr.New()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, "text/html") {
+				t.Errorf("Content-Type = %q, want text/html", got)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("body = %s, want containing %s", w.Body.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	dr "github.com/raoptimus/data-response.go/v2"
+)
+
+// Parameter locations.
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InCookie = "cookie"
+)
+
+// Operation is the route metadata used to generate the OpenAPI operation.
+// Types are given by sample values, e.g. CreateUserRequest{} or []User(nil),
+// a *Schema value is used as is.
+type Operation struct {
+	// OperationID is a unique operation identifier.
+	OperationID string
+
+	// Summary is a short operation summary.
+	Summary string
+
+	// Description is a verbose operation description.
+	Description string
+
+	// Tags group operations.
+	Tags []string
+
+	// Deprecated marks the operation as deprecated.
+	Deprecated bool
+
+	// Hidden excludes the route from the document.
+	Hidden bool
+
+	// Request is the request body type.
+	Request any
+
+	// RequestContentType is the request body media type (default: application/json).
+	RequestContentType string
+
+	// Responses maps status codes to response body types, nil for a response without body.
+	// Error responses of the factory are added automatically.
+	Responses map[int]any
+
+	// Parameters describes query, header and cookie parameters.
+	// Path parameters are taken from the route pattern, listing them here sets their type and description.
+	Parameters []Parameter
+
+	// Security overrides the document security requirements, an empty non-nil slice makes the route public.
+	Security []SecurityRequirement
+}
+
+// Parameter describes an operation parameter.
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Deprecated  bool
+
+	// Type is the parameter type sample (default: string).
+	Type any
+}
+
+// Describe attaches the operation metadata to the handler.
+// The route registry of dr.ServeMux and chiadapter.Router records it on registration:
+//
+//	router.Handle(http.MethodGet, "/users/{id}", openapi.Describe(getUser, openapi.Operation{
+//		Summary:   "Get user",
+//		Responses: map[int]any{http.StatusOK: User{}},
+//	}))
+//
+// Handlers created by dr.TypedHandler are described by their types without it.
+func Describe(h dr.Handler, op Operation) dr.Handler {
+	return &describedHandler{Handler: h, operation: op}
+}
+
+type describedHandler struct {
+	dr.Handler
+	operation Operation
+}
+
+// RouteMetadata implements dr.RouteMetadataProvider.
+// Metadata of the wrapped handler, e.g. dr.TypedHandler types, fills the fields not set explicitly.
+func (h *describedHandler) RouteMetadata() any {
+	if p, ok := h.Handler.(dr.RouteMetadataProvider); ok {
+		if base, ok := operationOf(p.RouteMetadata()); ok {
+			return h.operation.merge(base)
+		}
+	}
+
+	return h.operation
+}
@@ -0,0 +1,299 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+)
+
+const componentSchemaPrefix = "#/components/schemas/"
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Reflector converts Go types into JSON Schema, named struct types become component schemas.
+// Struct fields follow encoding/json rules: json tag names, omitempty (optional field),
+// "-" (skipped), ",string" and embedded structs. The "description" tag sets the field description.
+// Fields bound by dr.Bind from path, query or header are not part of the body and skipped,
+// fields tagged "form" are named by the tag.
+type Reflector struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewReflector creates a reflector with empty component schemas.
+func NewReflector() *Reflector {
+	return &Reflector{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns component schemas collected by the reflector.
+func (rf *Reflector) Schemas() map[string]*Schema {
+	return rf.schemas
+}
+
+// Schema returns the schema of the value type, nil for nil value.
+// A *Schema value is returned as is.
+func (rf *Reflector) Schema(v any) *Schema {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *Schema:
+		return v
+	case reflect.Type:
+		return rf.schemaOf(v)
+	}
+
+	return rf.schemaOf(reflect.TypeOf(v))
+}
+
+// Define registers the component schema by name and returns the reference to it.
+func (rf *Reflector) Define(name string, schema *Schema) *Schema {
+	rf.schemas[name] = schema
+
+	return Ref(name)
+}
+
+func (rf *Reflector) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		// custom JSON representation is unknown
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: rf.schemaOf(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: rf.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: rf.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return rf.structSchema(t)
+		}
+
+		return rf.namedStructSchema(t)
+	default:
+		// interfaces, funcs and channels
+		return &Schema{}
+	}
+}
+
+func (rf *Reflector) namedStructSchema(t reflect.Type) *Schema {
+	if name, ok := rf.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := rf.componentName(t)
+	rf.names[t] = name
+	// placeholder breaks recursion of self-referencing types
+	rf.schemas[name] = &Schema{}
+	*rf.schemas[name] = *rf.structSchema(t)
+
+	return Ref(name)
+}
+
+// componentName returns a unique component name of the type, e.g. "User" or "AdminUser" on conflict.
+func (rf *Reflector) componentName(t reflect.Type) string {
+	name := sanitizeName(typeName(t))
+	if _, exists := rf.schemas[name]; !exists {
+		return name
+	}
+
+	pkg := t.PkgPath()
+	if idx := strings.LastIndex(pkg, "/"); idx > -1 {
+		pkg = pkg[idx+1:]
+	}
+
+	base := sanitizeName(exportName(pkg) + typeName(t))
+	name = base
+	for i := 2; ; i++ {
+		if _, exists := rf.schemas[name]; !exists {
+			return name
+		}
+		name = base + "_" + strconv.Itoa(i)
+	}
+}
+
+func (rf *Reflector) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	rf.collectFields(t, schema, map[reflect.Type]bool{})
+
+	return schema
+}
+
+func (rf *Reflector) collectFields(t reflect.Type, schema *Schema, visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" || hasBindTag(field) {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if formName, ok := lookupTag(field, dr.TagForm); ok {
+			name = formName
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				rf.collectFields(ft, schema, visited)
+
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		var fieldSchema *Schema
+		if hasOption(opts, "string") {
+			fieldSchema = &Schema{Type: "string"}
+		} else {
+			fieldSchema = rf.schemaOf(field.Type)
+		}
+
+		if desc := field.Tag.Get("description"); desc != "" {
+			if fieldSchema.Ref != "" {
+				// siblings of $ref are allowed by JSON Schema 2020-12
+				fieldSchema = &Schema{Ref: fieldSchema.Ref}
+			}
+			fieldSchema.Description = desc
+		}
+
+		if _, exists := schema.Properties[name]; exists {
+			// the first field wins, embedded fields are expected after own ones
+			continue
+		}
+
+		schema.Properties[name] = fieldSchema
+		if !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeName returns the type name with short type arguments, e.g. "PageUser" for Page[example.com/app.User].
+func typeName(t reflect.Type) string {
+	name, args, generic := strings.Cut(t.Name(), "[")
+	if !generic {
+		return name
+	}
+
+	var sb strings.Builder
+	sb.WriteString(name)
+
+	for _, arg := range strings.FieldsFunc(args, func(r rune) bool {
+		return r == '[' || r == ']' || r == ',' || r == '*' || r == ' '
+	}) {
+		if idx := strings.LastIndex(arg, "/"); idx > -1 {
+			arg = arg[idx+1:]
+		}
+		if idx := strings.LastIndex(arg, "."); idx > -1 {
+			arg = arg[idx+1:]
+		}
+		sb.WriteString(exportName(arg))
+	}
+
+	return sb.String()
+}
+
+// sanitizeName makes the type name a valid component name.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, name)
+}
+
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
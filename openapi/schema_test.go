@@ -0,0 +1,173 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type Timestamps struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Profile struct {
+	Timestamps
+
+	Name     string            `json:"name" description:"Display name"`
+	Nickname string            `json:"nickname,omitempty"`
+	Age      uint8             `json:"age"`
+	Score    float64           `json:"score,string"`
+	Avatar   []byte            `json:"avatar"`
+	Address  *Address          `json:"address"`
+	Home     Address           `json:"home" description:"Home address"`
+	Labels   map[string]string `json:"labels"`
+	Extra    json.RawMessage   `json:"extra"`
+	IP       netip.Addr        `json:"ip"`
+	Secret   string            `json:"-"`
+	ID       int64             `path:"id"`
+	Untagged bool
+	internal string //nolint:unused // Unexported fields are skipped
+}
+
+type Node struct {
+	Children []Node `json:"children"`
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+// marshalSchema encodes the schema for comparison.
+func marshalSchema(t *testing.T, s *Schema) string {
+	t.Helper()
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestReflector_Schema(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "nil", value: nil, want: "null"},
+		{name: "bool", value: true, want: `{"type":"boolean"}`},
+		{name: "int64", value: int64(0), want: `{"type":"integer","format":"int64"}`},
+		{name: "uint", value: uint(0), want: `{"type":"integer","minimum":0}`},
+		{name: "string pointer", value: new(string), want: `{"type":"string"}`},
+		{name: "bytes", value: []byte(nil), want: `{"type":"string","format":"byte"}`},
+		{name: "time", value: time.Time{}, want: `{"type":"string","format":"date-time"}`},
+		{name: "text marshaler", value: netip.Addr{}, want: `{"type":"string"}`},
+		{name: "slice", value: []int32(nil), want: `{"type":"array","items":{"type":"integer","format":"int32"}}`},
+		{name: "map", value: map[string]bool{}, want: `{"type":"object","additionalProperties":{"type":"boolean"}}`},
+		{name: "named struct", value: Address{}, want: `{"$ref":"#/components/schemas/Address"}`},
+		{name: "slice of structs", value: []Address(nil), want: `{"type":"array","items":{"$ref":"#/components/schemas/Address"}}`},
+		{
+			name:  "anonymous struct",
+			value: struct{ Count int }{},
+			want:  `{"type":"object","properties":{"Count":{"type":"integer"}},"required":["Count"]}`,
+		},
+		{name: "schema as is", value: &Schema{Type: "string", Format: "uuid"}, want: `{"type":"string","format":"uuid"}`},
+		{name: "generic", value: Page[Address]{}, want: `{"$ref":"#/components/schemas/PageAddress"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := marshalSchema(t, NewReflector().Schema(tt.value)); got != tt.want {
+				t.Errorf("Schema() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReflector_StructFields(t *testing.T) {
+	rf := NewReflector()
+	rf.Schema(Profile{})
+
+	schema := rf.Schemas()["Profile"]
+	if schema == nil {
+		t.Fatalf("component Profile is not defined: %v", rf.Schemas())
+	}
+
+	wantProperties := map[string]string{
+		"createdAt": `{"type":"string","format":"date-time"}`,
+		"name":      `{"type":"string","description":"Display name"}`,
+		"nickname":  `{"type":"string"}`,
+		"age":       `{"type":"integer","minimum":0}`,
+		"score":     `{"type":"string"}`,
+		"avatar":    `{"type":"string","format":"byte"}`,
+		"address":   `{"$ref":"#/components/schemas/Address"}`,
+		"home":      `{"$ref":"#/components/schemas/Address","description":"Home address"}`,
+		"labels":    `{"type":"object","additionalProperties":{"type":"string"}}`,
+		"extra":     `{}`,
+		"ip":        `{"type":"string"}`,
+		"Untagged":  `{"type":"boolean"}`,
+	}
+
+	if len(schema.Properties) != len(wantProperties) {
+		t.Errorf("properties = %v, want %d", schema.Properties, len(wantProperties))
+	}
+	for name, want := range wantProperties {
+		if got := marshalSchema(t, schema.Properties[name]); got != want {
+			t.Errorf("property %q = %s, want %s", name, got, want)
+		}
+	}
+
+	wantRequired := map[string]bool{
+		"createdAt": true, "name": true, "age": true, "score": true, "avatar": true,
+		"home": true, "labels": true, "extra": true, "ip": true, "Untagged": true,
+	}
+	if len(schema.Required) != len(wantRequired) {
+		t.Errorf("required = %v, want %d fields", schema.Required, len(wantRequired))
+	}
+	for _, name := range schema.Required {
+		if !wantRequired[name] {
+			t.Errorf("field %q is required", name)
+		}
+	}
+}
+
+func TestReflector_Recursion(t *testing.T) {
+	rf := NewReflector()
+
+	if got := marshalSchema(t, rf.Schema(Node{})); got != `{"$ref":"#/components/schemas/Node"}` {
+		t.Errorf("Schema() = %s", got)
+	}
+
+	want := `{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#/components/schemas/Node"}}},"required":["children"]}`
+	if got := marshalSchema(t, rf.Schemas()["Node"]); got != want {
+		t.Errorf("component = %s, want %s", got, want)
+	}
+}
+
+func TestReflector_NameConflict(t *testing.T) {
+	rf := NewReflector()
+	rf.Define("Address", &Schema{Type: "string"})
+
+	if got := marshalSchema(t, rf.Schema(Address{})); got != `{"$ref":"#/components/schemas/OpenapiAddress"}` {
+		t.Errorf("Schema() = %s, want the package-qualified reference", got)
+	}
+
+	// The same type is referenced by the same name
+	if got := marshalSchema(t, rf.Schema(&Address{})); got != `{"$ref":"#/components/schemas/OpenapiAddress"}` {
+		t.Errorf("Schema() = %s, want the same reference", got)
+	}
+}
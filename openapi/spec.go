@@ -0,0 +1,190 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import "net/http"
+
+// Version is the OpenAPI specification version of generated documents.
+const Version = "3.1.0"
+
+type (
+	// Document is the OpenAPI root object.
+	Document struct {
+		OpenAPI    string                `json:"openapi"`
+		Info       Info                  `json:"info"`
+		Servers    []Server              `json:"servers,omitempty"`
+		Tags       []Tag                 `json:"tags,omitempty"`
+		Security   []SecurityRequirement `json:"security,omitempty"`
+		Paths      map[string]*PathItem  `json:"paths"`
+		Components *Components           `json:"components,omitempty"`
+	}
+
+	// Info provides metadata about the API.
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Summary     string `json:"summary,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// Server is an API server.
+	Server struct {
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// Tag adds metadata to a tag used by operations.
+	Tag struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// SecurityRequirement maps security scheme names to required scopes.
+	SecurityRequirement map[string][]string
+
+	// PathItem describes operations available on a single path.
+	PathItem struct {
+		Get     *OperationObject `json:"get,omitempty"`
+		Put     *OperationObject `json:"put,omitempty"`
+		Post    *OperationObject `json:"post,omitempty"`
+		Delete  *OperationObject `json:"delete,omitempty"`
+		Options *OperationObject `json:"options,omitempty"`
+		Head    *OperationObject `json:"head,omitempty"`
+		Patch   *OperationObject `json:"patch,omitempty"`
+		Trace   *OperationObject `json:"trace,omitempty"`
+	}
+
+	// OperationObject describes a single API operation on a path.
+	OperationObject struct {
+		OperationID string                     `json:"operationId,omitempty"`
+		Summary     string                     `json:"summary,omitempty"`
+		Description string                     `json:"description,omitempty"`
+		Tags        []string                   `json:"tags,omitempty"`
+		Parameters  []*ParameterObject         `json:"parameters,omitempty"`
+		RequestBody *RequestBodyObject         `json:"requestBody,omitempty"`
+		Responses   map[string]*ResponseObject `json:"responses"`
+		Security    *[]SecurityRequirement     `json:"security,omitempty"`
+		Deprecated  bool                       `json:"deprecated,omitempty"`
+	}
+
+	// ParameterObject describes a single operation parameter.
+	ParameterObject struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Deprecated  bool    `json:"deprecated,omitempty"`
+		Schema      *Schema `json:"schema,omitempty"`
+	}
+
+	// RequestBodyObject describes a request body.
+	RequestBodyObject struct {
+		Description string                      `json:"description,omitempty"`
+		Required    bool                        `json:"required,omitempty"`
+		Content     map[string]*MediaTypeObject `json:"content"`
+	}
+
+	// ResponseObject describes a single response of an operation.
+	ResponseObject struct {
+		Description string                      `json:"description"`
+		Headers     map[string]*HeaderObject    `json:"headers,omitempty"`
+		Content     map[string]*MediaTypeObject `json:"content,omitempty"`
+	}
+
+	// HeaderObject describes a response header.
+	HeaderObject struct {
+		Description string  `json:"description,omitempty"`
+		Schema      *Schema `json:"schema,omitempty"`
+	}
+
+	// MediaTypeObject provides the schema of a media type.
+	MediaTypeObject struct {
+		Schema *Schema `json:"schema,omitempty"`
+	}
+
+	// Components holds reusable objects of the document.
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	// SecurityScheme defines a security scheme used by operations.
+	SecurityScheme struct {
+		Type             string `json:"type"`
+		Description      string `json:"description,omitempty"`
+		Name             string `json:"name,omitempty"`
+		In               string `json:"in,omitempty"`
+		Scheme           string `json:"scheme,omitempty"`
+		BearerFormat     string `json:"bearerFormat,omitempty"`
+		OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+	}
+
+	// Schema is a JSON Schema (draft 2020-12) object.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 any                `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Title                string             `json:"title,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Enum                 []any              `json:"enum,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		AllOf                []*Schema          `json:"allOf,omitempty"`
+	}
+)
+
+// BearerAuth returns the HTTP bearer security scheme.
+func BearerAuth(format string) *SecurityScheme {
+	return &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: format}
+}
+
+// BasicAuth returns the HTTP basic security scheme.
+func BasicAuth() *SecurityScheme {
+	return &SecurityScheme{Type: "http", Scheme: "basic"}
+}
+
+// APIKeyAuth returns the API key security scheme, in is "header", "query" or "cookie".
+func APIKeyAuth(name, in string) *SecurityScheme {
+	return &SecurityScheme{Type: "apiKey", Name: name, In: in}
+}
+
+// Ref returns the schema referencing the component schema by name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: componentSchemaPrefix + name}
+}
+
+// setOperation sets the operation for the method, returns false for unsupported methods.
+func (p *PathItem) setOperation(method string, op *OperationObject) bool {
+	switch method {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodOptions:
+		p.Options = op
+	case http.MethodHead:
+		p.Head = op
+	case http.MethodPatch:
+		p.Patch = op
+	case http.MethodTrace:
+		p.Trace = op
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,150 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// paramTags maps dr.Bind tags to parameter locations.
+var paramTags = []struct{ tag, in string }{
+	{dr.TagPath, InPath},
+	{dr.TagQuery, InQuery},
+	{dr.TagHeader, InHeader},
+}
+
+// typedOperation describes the dr.TypedHandler: parameters and the request body come from In tags, Out is 200 response.
+func typedOperation(types dr.HandlerTypes) Operation {
+	op := Operation{
+		Responses: map[int]any{http.StatusOK: types.Out},
+	}
+
+	in := types.In
+	for in != nil && in.Kind() == reflect.Pointer {
+		in = in.Elem()
+	}
+
+	if in == nil || in.Kind() != reflect.Struct {
+		return op
+	}
+
+	var hasBody, hasForm bool
+	walkFields(in, func(field reflect.StructField) {
+		if _, ok := lookupTag(field, dr.TagForm); ok {
+			hasBody, hasForm = true, true
+
+			return
+		}
+
+		for _, pt := range paramTags {
+			if name, ok := lookupTag(field, pt.tag); ok {
+				op.Parameters = append(op.Parameters, Parameter{
+					Name:        name,
+					In:          pt.in,
+					Description: field.Tag.Get("description"),
+					Type:        field.Type,
+				})
+
+				return
+			}
+		}
+
+		if field.Tag.Get("json") != "-" {
+			hasBody = true
+		}
+	})
+
+	if hasBody {
+		op.Request = types.In
+		if hasForm {
+			op.RequestContentType = response.ContentTypeForm
+		}
+	}
+
+	return op
+}
+
+// walkFields calls fn for exported fields of the struct, embedded structs without tags are flattened.
+func walkFields(t reflect.Type, fn func(field reflect.StructField)) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !hasBindTag(field) && field.Tag.Get("json") == "" {
+			walkFields(field.Type, fn)
+
+			continue
+		}
+
+		if field.IsExported() {
+			fn(field)
+		}
+	}
+}
+
+// hasBindTag reports whether the field is bound from path, query or header instead of the body.
+func hasBindTag(field reflect.StructField) bool {
+	for _, pt := range paramTags {
+		if _, ok := field.Tag.Lookup(pt.tag); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lookupTag(field reflect.StructField, tag string) (string, bool) {
+	value, ok := field.Tag.Lookup(tag)
+	if !ok {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(value, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// merge fills the operation fields not set explicitly from the base one.
+func (op Operation) merge(base Operation) Operation {
+	if op.Request == nil {
+		op.Request = base.Request
+		if op.RequestContentType == "" {
+			op.RequestContentType = base.RequestContentType
+		}
+	}
+
+	if op.Responses == nil {
+		op.Responses = base.Responses
+	}
+
+	for _, p := range base.Parameters {
+		if !op.hasParameter(p.Name, p.In) {
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+
+	return op
+}
+
+func (op Operation) hasParameter(name, in string) bool {
+	for _, p := range op.Parameters {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,221 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const yamlIndent = "  "
+
+// yamlNode is a JSON value keeping the object keys order.
+type yamlNode struct {
+	scalar any
+	keys   []string
+	values []*yamlNode
+	object bool
+	array  bool
+}
+
+// MarshalYAML encodes the document to YAML.
+func (d *Document) MarshalYAML() ([]byte, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonToYAML(data)
+}
+
+// jsonToYAML converts the JSON to block style YAML, the keys order is preserved.
+func jsonToYAML(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	node, err := decodeNode(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case node.object && len(node.keys) > 0:
+		writeObject(&buf, node, 0, false)
+	case node.array && len(node.values) > 0:
+		writeArray(&buf, node, 0)
+	default:
+		writeInline(&buf, node)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &yamlNode{scalar: tok}, nil
+	}
+
+	node := &yamlNode{object: delim == '{', array: delim == '['}
+	for dec.More() {
+		if node.object {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, errors.New("invalid JSON object key")
+			}
+			node.keys = append(node.keys, key)
+		}
+
+		value, err := decodeNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		node.values = append(node.values, value)
+	}
+
+	// closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func (n *yamlNode) isBlock() bool {
+	return (n.object || n.array) && len(n.values) > 0
+}
+
+// writeObject writes the object keys, inlineFirst means the first key follows an array item marker.
+func writeObject(buf *bytes.Buffer, node *yamlNode, depth int, inlineFirst bool) {
+	for i, key := range node.keys {
+		if i > 0 || !inlineFirst {
+			buf.WriteString(strings.Repeat(yamlIndent, depth))
+		}
+		writeValue(buf, yamlString(key), node.values[i], depth)
+	}
+}
+
+func writeArray(buf *bytes.Buffer, node *yamlNode, depth int) {
+	for _, value := range node.values {
+		buf.WriteString(strings.Repeat(yamlIndent, depth))
+		buf.WriteString("- ")
+
+		switch {
+		case value.object && len(value.values) > 0:
+			writeObject(buf, value, depth+1, true)
+		case value.array && len(value.values) > 0:
+			buf.WriteByte('\n')
+			writeArray(buf, value, depth+1)
+		default:
+			writeInline(buf, value)
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+func writeValue(buf *bytes.Buffer, key string, value *yamlNode, depth int) {
+	buf.WriteString(key)
+	buf.WriteByte(':')
+
+	if !value.isBlock() {
+		buf.WriteByte(' ')
+		writeInline(buf, value)
+		buf.WriteByte('\n')
+
+		return
+	}
+
+	buf.WriteByte('\n')
+	if value.object {
+		writeObject(buf, value, depth+1, false)
+	} else {
+		writeArray(buf, value, depth+1)
+	}
+}
+
+func writeInline(buf *bytes.Buffer, node *yamlNode) {
+	switch {
+	case node.object:
+		buf.WriteString("{}")
+	case node.array:
+		buf.WriteString("[]")
+	default:
+		switch v := node.scalar.(type) {
+		case nil:
+			buf.WriteString("null")
+		case bool:
+			if v {
+				buf.WriteString("true")
+			} else {
+				buf.WriteString("false")
+			}
+		case json.Number:
+			buf.WriteString(v.String())
+		case string:
+			buf.WriteString(yamlString(v))
+		}
+	}
+}
+
+// yamlString returns the plain scalar if it is unambiguous, otherwise the double-quoted one.
+// JSON string escapes are valid in YAML double-quoted scalars.
+func yamlString(s string) string {
+	if isPlainYAML(s) {
+		return s
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func isPlainYAML(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return false
+	}
+
+	first := s[0]
+	if (first < 'a' || first > 'z') && (first < 'A' || first > 'Z') && first != '_' && first != '/' && first != '$' {
+		return false
+	}
+
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("_-./$()+ ", c) >= 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
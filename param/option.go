@@ -0,0 +1,118 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package param
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type options[T any] struct {
+	required     bool
+	hasDefault   bool
+	defaultValue T
+	parse        func(value string) (T, error)
+	validators   []func(v T) error
+}
+
+// Option configures parameter extraction.
+type Option[T any] func(o *options[T])
+
+func newOptions[T any](opts []Option[T]) *options[T] {
+	o := &options[T]{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Required reports an error if the parameter is absent and has no Default.
+func Required[T any]() Option[T] {
+	return func(o *options[T]) {
+		o.required = true
+	}
+}
+
+// Default sets the value returned if the parameter is absent.
+func Default[T any](value T) Option[T] {
+	return func(o *options[T]) {
+		o.hasDefault = true
+		o.defaultValue = value
+	}
+}
+
+// OneOf restricts the parameter to the listed values, e.g. enum values.
+func OneOf[T comparable](values ...T) Option[T] {
+	return Validate(func(v T) error {
+		if slices.Contains(values, v) {
+			return nil
+		}
+
+		allowed := make([]string, 0, len(values))
+		for _, value := range values {
+			allowed = append(allowed, fmt.Sprint(value))
+		}
+
+		return errors.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+	})
+}
+
+// Min restricts the parameter to values greater than or equal to minValue.
+func Min[T cmp.Ordered](minValue T) Option[T] {
+	return Validate(func(v T) error {
+		if v < minValue {
+			return errors.Errorf("must be greater than or equal to %v", minValue)
+		}
+
+		return nil
+	})
+}
+
+// Max restricts the parameter to values less than or equal to maxValue.
+func Max[T cmp.Ordered](maxValue T) Option[T] {
+	return Validate(func(v T) error {
+		if v > maxValue {
+			return errors.Errorf("must be less than or equal to %v", maxValue)
+		}
+
+		return nil
+	})
+}
+
+// Layout parses the time parameter with the layout instead of RFC 3339, e.g. time.DateOnly.
+func Layout(layout string) Option[time.Time] {
+	return Parse(func(value string) (time.Time, error) {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return t, errors.Errorf("must be a date-time in %s format", layout)
+		}
+
+		return t, nil
+	})
+}
+
+// Parse sets the custom parser, the error message is reported to the client.
+func Parse[T any](parse func(value string) (T, error)) Option[T] {
+	return func(o *options[T]) {
+		o.parse = parse
+	}
+}
+
+// Validate adds the validator, the error message is reported to the client.
+func Validate[T any](validate func(v T) error) Option[T] {
+	return func(o *options[T]) {
+		o.validators = append(o.validators, validate)
+	}
+}
@@ -0,0 +1,196 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+// Package param extracts typed path and query parameters, accumulating failures into a single validation error:
+//
+//	p := param.From(r)
+//	id := param.Path[int64](p, "id")
+//	limit := param.Query(p, "limit", param.Default(20), param.Max(100))
+//	status := param.Query(p, "status", param.OneOf("active", "blocked"))
+//	if resp := p.ErrorResponse(r.Context(), f); resp != nil {
+//		return resp // 422 with "path.id", "query.limit" pointers
+//	}
+package param
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/internal/conv"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// Pointer prefixes of validation errors.
+const (
+	LocationPath  = "path"
+	LocationQuery = "query"
+)
+
+const messageRequired = "is required"
+
+// Params extracts request parameters and accumulates their errors.
+// It is not safe for concurrent use.
+type Params struct {
+	r      *http.Request
+	query  url.Values
+	errors dr.ValidationErrors
+}
+
+// From creates the request parameters extractor.
+func From(r *http.Request) *Params {
+	return &Params{
+		r:      r,
+		query:  r.URL.Query(),
+		errors: make(dr.ValidationErrors),
+	}
+}
+
+// PathValue returns the raw path parameter from http.Request.PathValue,
+// the router adapters mirror their path parameters into it.
+func (p *Params) PathValue(name string) string {
+	return p.r.PathValue(name)
+}
+
+// AddError adds the error for the pointer, e.g. "query.from" for cross-parameter checks.
+func (p *Params) AddError(pointer, message string) {
+	p.errors.Add(pointer, message)
+}
+
+// Valid reports whether all extracted parameters are valid.
+func (p *Params) Valid() bool {
+	return len(p.errors) == 0
+}
+
+// Err returns dr.ValidationErrors with all failures, nil if all parameters are valid.
+func (p *Params) Err() error {
+	if p.Valid() {
+		return nil
+	}
+
+	return p.errors
+}
+
+// ErrorResponse returns the 422 validation error response with all failures, nil if all parameters are valid.
+func (p *Params) ErrorResponse(ctx context.Context, f *dr.Factory) *response.DataResponse {
+	if p.Valid() {
+		return nil
+	}
+
+	return f.ValidationError(ctx, "", p.errors)
+}
+
+// Path extracts the required path parameter.
+func Path[T any](p *Params, name string, opts ...Option[T]) T {
+	var values []string
+	if value := p.PathValue(name); value != "" {
+		values = []string{value}
+	}
+
+	return extract(p, LocationPath+"."+name, values, append([]Option[T]{Required[T]()}, opts...))
+}
+
+// Query extracts the query parameter, zero value or the default is returned if it is absent.
+func Query[T any](p *Params, name string, opts ...Option[T]) T {
+	var values []string
+	if value := p.query.Get(name); value != "" {
+		values = []string{value}
+	}
+
+	return extract(p, LocationQuery+"."+name, values, opts)
+}
+
+// QueryList extracts the list query parameter given by repeated keys or comma-separated values,
+// e.g. "?id=1&id=2" or "?id=1,2". Options are applied to each element,
+// Required requires a non-empty list and Default is the single element of an absent one.
+func QueryList[T any](p *Params, name string, opts ...Option[T]) []T {
+	o := newOptions(opts)
+	pointer := LocationQuery + "." + name
+
+	var values []string
+	for _, value := range p.query[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		if o.hasDefault {
+			return []T{o.defaultValue}
+		}
+		if o.required {
+			p.errors.Add(pointer, messageRequired)
+		}
+
+		return nil
+	}
+
+	result := make([]T, 0, len(values))
+	for _, value := range values {
+		v, ok := parse(p, pointer, value, o)
+		if ok {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+func extract[T any](p *Params, pointer string, values []string, opts []Option[T]) T {
+	o := newOptions(opts)
+
+	if len(values) == 0 {
+		if o.hasDefault {
+			return o.defaultValue
+		}
+		if o.required {
+			p.errors.Add(pointer, messageRequired)
+		}
+
+		var zero T
+
+		return zero
+	}
+
+	v, _ := parse(p, pointer, values[0], o)
+
+	return v
+}
+
+func parse[T any](p *Params, pointer, value string, o *options[T]) (T, bool) {
+	var v T
+
+	if o.parse != nil {
+		parsed, err := o.parse(value)
+		if err != nil {
+			p.errors.Add(pointer, err.Error())
+
+			return v, false
+		}
+		v = parsed
+	} else if err := conv.SetString(reflect.ValueOf(&v).Elem(), value); err != nil {
+		p.errors.Add(pointer, err.Error())
+
+		return v, false
+	}
+
+	valid := true
+	for _, validate := range o.validators {
+		if err := validate(v); err != nil {
+			p.errors.Add(pointer, err.Error())
+			valid = false
+		}
+	}
+
+	return v, valid
+}
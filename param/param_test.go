@@ -0,0 +1,404 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package param
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newRequest(target string, pathValues map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, value := range pathValues {
+		r.SetPathValue(name, value)
+	}
+
+	return r
+}
+
+func TestParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		pathValues map[string]string
+		extract    func(p *Params) any
+		want       any
+		wantErrors dr.ValidationErrors
+	}{
+		{
+			name:       "path value",
+			target:     "/users/7",
+			pathValues: map[string]string{"id": "7"},
+			extract:    func(p *Params) any { return Path[int64](p, "id") },
+			want:       int64(7),
+		},
+		{
+			name:       "path value is required",
+			target:     "/users/",
+			extract:    func(p *Params) any { return Path[int64](p, "id") },
+			want:       int64(0),
+			wantErrors: dr.ValidationErrors{"path.id": {"is required"}},
+		},
+		{
+			name:       "invalid path value",
+			target:     "/users/abc",
+			pathValues: map[string]string{"id": "abc"},
+			extract:    func(p *Params) any { return Path[int64](p, "id") },
+			want:       int64(0),
+			wantErrors: dr.ValidationErrors{"path.id": {"must be an integer"}},
+		},
+		{
+			name:       "path value validators",
+			target:     "/users/0",
+			pathValues: map[string]string{"id": "0"},
+			extract:    func(p *Params) any { return Path(p, "id", Min[int64](1)) },
+			want:       int64(0),
+			wantErrors: dr.ValidationErrors{"path.id": {"must be greater than or equal to 1"}},
+		},
+		{
+			name:    "absent query value is zero",
+			target:  "/users",
+			extract: func(p *Params) any { return Query[int](p, "limit") },
+			want:    0,
+		},
+		{
+			name:    "empty query value is absent",
+			target:  "/users?limit=",
+			extract: func(p *Params) any { return Query(p, "limit", Default(20)) },
+			want:    20,
+		},
+		{
+			name:    "default",
+			target:  "/users",
+			extract: func(p *Params) any { return Query(p, "limit", Default(20), Max(100)) },
+			want:    20,
+		},
+		{
+			name:    "default satisfies required",
+			target:  "/users",
+			extract: func(p *Params) any { return Query(p, "limit", Required[int](), Default(20)) },
+			want:    20,
+		},
+		{
+			name:       "required",
+			target:     "/users",
+			extract:    func(p *Params) any { return Query(p, "limit", Required[int]()) },
+			want:       0,
+			wantErrors: dr.ValidationErrors{"query.limit": {"is required"}},
+		},
+		{
+			name:    "query value",
+			target:  "/users?limit=50",
+			extract: func(p *Params) any { return Query(p, "limit", Default(20), Max(100)) },
+			want:    50,
+		},
+		{
+			name:       "max",
+			target:     "/users?limit=500",
+			extract:    func(p *Params) any { return Query(p, "limit", Default(20), Max(100)) },
+			want:       500,
+			wantErrors: dr.ValidationErrors{"query.limit": {"must be less than or equal to 100"}},
+		},
+		{
+			name:       "all validators are reported",
+			target:     "/users?limit=500",
+			extract:    func(p *Params) any { return Query(p, "limit", Max(100), OneOf(10, 20)) },
+			want:       500,
+			wantErrors: dr.ValidationErrors{"query.limit": {"must be less than or equal to 100", "must be one of: 10, 20"}},
+		},
+		{
+			name:    "one of",
+			target:  "/users?status=active",
+			extract: func(p *Params) any { return Query(p, "status", OneOf("active", "blocked")) },
+			want:    "active",
+		},
+		{
+			name:       "not one of",
+			target:     "/users?status=deleted",
+			extract:    func(p *Params) any { return Query(p, "status", OneOf("active", "blocked")) },
+			want:       "deleted",
+			wantErrors: dr.ValidationErrors{"query.status": {"must be one of: active, blocked"}},
+		},
+		{
+			name:       "invalid boolean",
+			target:     "/users?notify=sometimes",
+			extract:    func(p *Params) any { return Query[bool](p, "notify") },
+			want:       false,
+			wantErrors: dr.ValidationErrors{"query.notify": {"must be a boolean"}},
+		},
+		{
+			name:    "duration",
+			target:  "/users?timeout=1m30s",
+			extract: func(p *Params) any { return Query[time.Duration](p, "timeout") },
+			want:    90 * time.Second,
+		},
+		{
+			name:    "layout",
+			target:  "/users?from=2024-02-29",
+			extract: func(p *Params) any { return Query(p, "from", Layout(time.DateOnly)) },
+			want:    time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "invalid layout",
+			target:     "/users?from=29.02.2024",
+			extract:    func(p *Params) any { return Query(p, "from", Layout(time.DateOnly)) },
+			want:       time.Time{},
+			wantErrors: dr.ValidationErrors{"query.from": {"must be a date-time in 2006-01-02 format"}},
+		},
+		{
+			name:   "custom parser",
+			target: "/users?sort=-name",
+			extract: func(p *Params) any {
+				return Query(p, "sort", Parse(func(value string) (string, error) {
+					if !strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "+") {
+						return "", errors.New("must start with + or -")
+					}
+
+					return value[1:], nil
+				}))
+			},
+			want: "name",
+		},
+		{
+			name:   "custom parser error",
+			target: "/users?sort=name",
+			extract: func(p *Params) any {
+				return Query(p, "sort", Parse(func(value string) (string, error) {
+					return "", errors.New("must start with + or -")
+				}))
+			},
+			want:       "",
+			wantErrors: dr.ValidationErrors{"query.sort": {"must start with + or -"}},
+		},
+		{
+			name:    "list of repeated keys",
+			target:  "/users?id=1&id=2",
+			extract: func(p *Params) any { return QueryList[int](p, "id") },
+			want:    []int{1, 2},
+		},
+		{
+			name:    "list of comma-separated values",
+			target:  "/users?id=1,%202,,3&id=4",
+			extract: func(p *Params) any { return QueryList[int](p, "id") },
+			want:    []int{1, 2, 3, 4},
+		},
+		{
+			name:    "absent list",
+			target:  "/users",
+			extract: func(p *Params) any { return QueryList[int](p, "id") },
+			want:    []int(nil),
+		},
+		{
+			name:    "list default",
+			target:  "/users",
+			extract: func(p *Params) any { return QueryList(p, "id", Required[int](), Default(1)) },
+			want:    []int{1},
+		},
+		{
+			name:       "empty list is required",
+			target:     "/users?id=,",
+			extract:    func(p *Params) any { return QueryList(p, "id", Required[int]()) },
+			want:       []int(nil),
+			wantErrors: dr.ValidationErrors{"query.id": {"is required"}},
+		},
+		{
+			name:       "invalid list elements are skipped",
+			target:     "/users?id=1,abc,500",
+			extract:    func(p *Params) any { return QueryList(p, "id", Max(100)) },
+			want:       []int{1},
+			wantErrors: dr.ValidationErrors{"query.id": {"must be an integer", "must be less than or equal to 100"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := From(newRequest(tt.target, tt.pathValues))
+
+			if got := tt.extract(p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("value = %#v, want %#v", got, tt.want)
+			}
+
+			if tt.wantErrors == nil {
+				if !p.Valid() || p.Err() != nil {
+					t.Errorf("Err() = %v, want nil", p.Err())
+				}
+
+				return
+			}
+
+			if p.Valid() {
+				t.Fatal("Valid() = true, want false")
+			}
+
+			var verrs dr.ValidationErrors
+			if !errors.As(p.Err(), &verrs) {
+				t.Fatalf("Err() = %T, want dr.ValidationErrors", p.Err())
+			}
+			if !reflect.DeepEqual(verrs, tt.wantErrors) {
+				t.Errorf("Err() = %v, want %v", verrs, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParams_ErrorResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "valid",
+			target:     "/users/7?limit=50",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":7,"limit":50}`,
+		},
+		{
+			name:       "all failures are accumulated",
+			target:     "/users/abc?limit=500",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `{"code":"UNPROCESSABLE_ENTITY","status":"422","title":"Validation failed","errors":[` +
+				`{"pointer":"path.id","detail":"must be an integer"},` +
+				`{"pointer":"query.limit","detail":"must be less than or equal to 100"}]}`,
+		},
+		{
+			name:       "cross-parameter error",
+			target:     "/users/7?limit=50&from=2024-03-01&to=2024-02-01",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody: `{"code":"UNPROCESSABLE_ENTITY","status":"422","title":"Validation failed","errors":[` +
+				`{"pointer":"query.from","detail":"must be before query.to"}]}`,
+		},
+	}
+
+	f := dr.New(dr.WithFormatter(formatter.NewJSON()))
+	h := dr.WrapHandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		p := From(r)
+		id := Path[int64](p, "id")
+		limit := Query(p, "limit", Default(20), Max(100))
+		from := Query(p, "from", Layout(time.DateOnly))
+		to := Query(p, "to", Layout(time.DateOnly))
+		if !from.IsZero() && !to.IsZero() && from.After(to) {
+			p.AddError("query.from", "must be before query.to")
+		}
+		if resp := p.ErrorResponse(r.Context(), f); resp != nil {
+			return resp
+		}
+
+		return f.Success(r.Context(), struct {
+			ID    int64 `json:"id"`
+			Limit int   `json:"limit"`
+		}{ID: id, Limit: limit})
+	}, f)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("GET /users/{id}", h)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestUUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "lower case", value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{name: "upper case", value: "6BA7B810-9DAD-11D1-80B4-00C04FD430C8"},
+		{name: "nil", value: "00000000-0000-0000-0000-000000000000"},
+		{name: "without hyphens", value: "6ba7b8109dad11d180b400c04fd430c8", wantErr: true},
+		{name: "braces", value: "{6ba7b810-9dad-11d1-80b4-00c04fd430c8}", wantErr: true},
+		{name: "misplaced hyphen", value: "6ba7b81-09dad-11d1-80b4-00c04fd430c8", wantErr: true},
+		{name: "not hex", value: "6ba7b810-9dad-11d1-80b4-00c04fd430cz", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var u UUID
+			err := u.UnmarshalText([]byte(tt.value))
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidUUID) {
+					t.Errorf("UnmarshalText() error = %v, want %v", err, errInvalidUUID)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalText() error = %v", err)
+			}
+
+			if got := u.String(); got != strings.ToLower(tt.value) {
+				t.Errorf("String() = %s, want %s", got, strings.ToLower(tt.value))
+			}
+			text, err := u.MarshalText()
+			if err != nil || string(text) != strings.ToLower(tt.value) {
+				t.Errorf("MarshalText() = %s, %v", text, err)
+			}
+			if got, want := u.IsZero(), tt.name == "nil"; got != want {
+				t.Errorf("IsZero() = %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestPath_UUID(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		want       string
+		wantErrors dr.ValidationErrors
+	}{
+		{name: "valid", id: "6ba7b810-9dad-11d1-80b4-00c04fd430c8", want: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{
+			name:       "invalid",
+			id:         "42",
+			want:       "00000000-0000-0000-0000-000000000000",
+			wantErrors: dr.ValidationErrors{"path.id": {"must be a valid UUID"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := From(newRequest("/users/"+tt.id, map[string]string{"id": tt.id}))
+
+			if got := Path[UUID](p, "id"); got.String() != tt.want {
+				t.Errorf("Path() = %s, want %s", got, tt.want)
+			}
+			if tt.wantErrors == nil {
+				if err := p.Err(); err != nil {
+					t.Errorf("Err() = %v, want nil", err)
+				}
+
+				return
+			}
+			if got := p.Err(); !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("Err() = %v, want %v", got, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package param
+
+import (
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+const uuidLength = 36
+
+var errInvalidUUID = errors.New("must be a valid UUID")
+
+// UUID is a RFC 4122 UUID parameter, e.g. param.Path[param.UUID](p, "id").
+// Any encoding.TextUnmarshaler type, such as github.com/google/uuid.UUID, can be used as well.
+type UUID [16]byte
+
+// UnmarshalText parses the canonical "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" form.
+func (u *UUID) UnmarshalText(text []byte) error {
+	if len(text) != uuidLength || text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+		return errInvalidUUID
+	}
+
+	src := make([]byte, 0, len(u)*2)
+	src = append(src, text[0:8]...)
+	src = append(src, text[9:13]...)
+	src = append(src, text[14:18]...)
+	src = append(src, text[19:23]...)
+	src = append(src, text[24:]...)
+
+	if _, err := hex.Decode(u[:], src); err != nil {
+		return errInvalidUUID
+	}
+
+	return nil
+}
+
+// MarshalText returns the canonical form.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// String returns the canonical form.
+func (u UUID) String() string {
+	buf := make([]byte, uuidLength)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf)
+}
+
+// IsZero reports whether the UUID is nil UUID.
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
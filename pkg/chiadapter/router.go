@@ -9,7 +9,11 @@
 package chiadapter
 
 import (
+	"context"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
 	dr "github.com/raoptimus/data-response.go/v2"
@@ -18,30 +22,72 @@ import (
 // Router wraps chi.Router with DataResponse support.
 type Router struct {
 	chi.Router
-	factory     *dr.Factory
-	middlewares []dr.Middleware
+	scope  *dr.Scope
+	prefix string
+	shared *routerShared
+}
+
+// routerShared is the state shared by the root router and its sub-routers.
+type routerShared struct {
+	mu sync.RWMutex
+
+	// methods is a flat routing tree of full patterns used to resolve Allow header,
+	// chi cannot resolve methods through mounted sub-routers.
+	methods          chi.Router
+	routes           *dr.RouteRegistry
+	notFound         dr.Handler
+	methodNotAllowed dr.Handler
 }
 
 // NewRouter creates a new chi Router with DataResponse support.
 func NewRouter(factory *dr.Factory) *Router {
-	return &Router{
-		Router:      chi.NewRouter(),
-		factory:     factory,
-		middlewares: make([]dr.Middleware, 0),
+	r := &Router{
+		Router: chi.NewRouter(),
+		scope:  dr.NewScope(factory),
+		shared: &routerShared{
+			methods:          chi.NewRouter(),
+			routes:           dr.NewRouteRegistry(),
+			notFound:         dr.NotFoundHandler(),
+			methodNotAllowed: dr.MethodNotAllowedHandler(),
+		},
 	}
+	r.installFallbacks()
+
+	return r
 }
 
-// WithMiddleware adds DataResponse middleware.
-// These middleware will be applied to all subsequently registered handlers.
+// WithMiddleware adds DataResponse middlewares to the router or group,
+// they apply to all its routes, including those registered before.
 func (r *Router) WithMiddleware(middlewares ...dr.Middleware) *Router {
-	r.middlewares = append(r.middlewares, middlewares...)
+	r.scope.Use(middlewares...)
 	return r
 }
 
+// WithFactory sets the Factory of the router or group, e.g. a clone with another formatter.
+func (r *Router) WithFactory(factory *dr.Factory) *Router {
+	r.scope.SetFactory(factory)
+	return r
+}
+
+// With returns a router sharing the same routing tree with additional middlewares,
+// e.g. r.With(middleware.RequireRoles("admin")).Get("/stats", h).
+func (r *Router) With(middlewares ...dr.Middleware) *Router {
+	return &Router{
+		Router: r.Router,
+		scope:  r.scope.Sub(middlewares...),
+		prefix: r.prefix,
+		shared: r.shared,
+	}
+}
+
 // Handle registers a DataResponse handler for the given pattern and method.
 func (r *Router) Handle(method, pattern string, handler dr.Handler) {
-	chained := dr.Chain(handler, r.middlewares...)
-	r.Router.Method(method, pattern, dr.WrapHandler(chained, r.factory))
+	r.Router.Method(method, pattern, withRouteContext(r.scope.Handler(handler)))
+	r.shared.routes.AddScoped(method, r.prefix+pattern, handler, r.scope)
+
+	r.shared.mu.Lock()
+	r.shared.methods.Method(method, r.prefix+pattern, http.NotFoundHandler())
+	r.shared.mu.Unlock()
 }
 
 // HandleFunc registers a DataResponse handler function.
@@ -84,13 +130,15 @@ func (r *Router) Head(pattern string, handlerFunc dr.HandlerFunc) {
 	r.HandleFunc("HEAD", pattern, handlerFunc)
 }
 
-// Group creates a new router group with optional middleware.
+// Group creates a group sharing the routing tree, with its own DataResponse middlewares and Factory
+// applied after the parent ones. Standard middlewares added by Use inside the group apply
+// to the group routes only, the parent ones are inherited.
 func (r *Router) Group(fn func(r *Router)) *Router {
-	// Create new router with same factory and copy of middlewares
 	group := &Router{
-		Router:      chi.NewRouter(),
-		factory:     r.factory,
-		middlewares: append([]dr.Middleware{}, r.middlewares...),
+		Router: r.Router.With(),
+		scope:  r.scope.Sub(),
+		prefix: r.prefix,
+		shared: r.shared,
 	}
 
 	if fn != nil {
@@ -100,9 +148,20 @@ func (r *Router) Group(fn func(r *Router)) *Router {
 	return group
 }
 
-// Route mounts a sub-router along a routing path.
+// Route mounts a sub-router along a routing path, it is a group with the path prefix.
 func (r *Router) Route(pattern string, fn func(r *Router)) {
-	subRouter := r.Group(fn)
+	subRouter := &Router{
+		Router: chi.NewRouter(),
+		scope:  r.scope.Sub(),
+		prefix: r.prefix + strings.TrimSuffix(pattern, "/"),
+		shared: r.shared,
+	}
+	subRouter.installFallbacks()
+
+	if fn != nil {
+		fn(subRouter)
+	}
+
 	r.Router.Mount(pattern, subRouter)
 }
 
@@ -117,7 +176,106 @@ func (r *Router) Mount(pattern string, handler http.Handler) {
 	r.Router.Mount(pattern, handler)
 }
 
-// Factory returns the Factory associated with this router.
+// NotFound sets the handler for requests not matching any route (default: factory-formatted 404).
+func (r *Router) NotFound(handler dr.Handler) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+
+	r.shared.notFound = handler
+}
+
+// MethodNotAllowed sets the handler for requests matching a route with another method
+// (default: factory-formatted 405). Allow header is always set, the allowed methods
+// are available via dr.AllowedMethods(r.Context()).
+func (r *Router) MethodNotAllowed(handler dr.Handler) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+
+	r.shared.methodNotAllowed = handler
+}
+
+// Routes returns the routes registered via Handle and its shortcuts.
+func (r *Router) Routes() []dr.Route {
+	return r.shared.routes.Routes()
+}
+
+// RouteRegistry returns the registry of the registered routes.
+func (r *Router) RouteRegistry() *dr.RouteRegistry {
+	return r.shared.routes
+}
+
+// installFallbacks sets chi NotFound and MethodNotAllowed handlers of this router's mux,
+// they are served with the router DataResponse middlewares and Factory.
+func (r *Router) installFallbacks() {
+	r.Router.NotFound(func(w http.ResponseWriter, req *http.Request) {
+		r.shared.mu.RLock()
+		handler := r.shared.notFound
+		r.shared.mu.RUnlock()
+
+		r.scope.Wrap(handler).ServeHTTP(w, req)
+	})
+
+	r.Router.MethodNotAllowed(func(w http.ResponseWriter, req *http.Request) {
+		r.shared.mu.RLock()
+		handler := dr.WithAllowHeader(r.shared.methodNotAllowed)
+		r.shared.mu.RUnlock()
+
+		req = req.WithContext(dr.WithAllowedMethods(req.Context(), r.allowedMethods(req)))
+		r.scope.Wrap(handler).ServeHTTP(w, req)
+	})
+}
+
+// allowedMethods probes the methods of the routes matching the request path.
+func (r *Router) allowedMethods(req *http.Request) []string {
+	r.shared.mu.RLock()
+	defer r.shared.mu.RUnlock()
+
+	var allowed []string
+
+	for _, method := range dr.StandardMethods {
+		if r.shared.methods.Match(chi.NewRouteContext(), method, req.URL.Path) {
+			allowed = append(allowed, method)
+		}
+	}
+
+	return allowed
+}
+
+// Factory returns the Factory of the router or group.
 func (r *Router) Factory() *dr.Factory {
-	return r.factory
+	return r.scope.Factory()
+}
+
+// withRouteContext sets http.Request.Pattern to the matched chi route pattern and mirrors chi URL params
+// into http.Request.PathValue, so DataResponse middlewares (metrics, authorization) and dr.Bind
+// see the route like with ServeMux.
+// The handler gets its own copy of the chi route context, chi resets and reuses the pooled one
+// once the request completes, while background work (cache refresh, timed out handler) may still read it.
+func withRouteContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if rctx := chi.RouteContext(req.Context()); rctx != nil {
+			rctx = cloneRouteContext(rctx)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				req.Pattern = pattern
+			}
+
+			for i, key := range rctx.URLParams.Keys {
+				req.SetPathValue(key, rctx.URLParams.Values[i])
+			}
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// cloneRouteContext copies the matched route state of the pooled chi route context.
+func cloneRouteContext(rctx *chi.Context) *chi.Context {
+	clone := *rctx
+	clone.URLParams.Keys = slices.Clone(rctx.URLParams.Keys)
+	clone.URLParams.Values = slices.Clone(rctx.URLParams.Values)
+	clone.RoutePatterns = slices.Clone(rctx.RoutePatterns)
+
+	return &clone
 }
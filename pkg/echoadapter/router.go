@@ -0,0 +1,337 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package echoadapter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// Router wraps echo.Echo with DataResponse support.
+// Patterns may use chi style "{id}" or echo style ":id" parameters, regexp constraints are not supported.
+type Router struct {
+	*echo.Echo
+	scope  *dr.Scope
+	use    []echo.MiddlewareFunc
+	prefix string
+	root   bool
+	shared *routerShared
+}
+
+// routerShared is the state shared by the root router and its groups.
+type routerShared struct {
+	mu sync.RWMutex
+
+	routes           *dr.RouteRegistry
+	notFound         dr.Handler
+	methodNotAllowed dr.Handler
+}
+
+// NewRouter creates a new echo instance with DataResponse support.
+// Errors returned by echo handlers and middlewares, including 404 and 405, are rendered by the factory.
+func NewRouter(factory *dr.Factory) *Router {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	r := &Router{
+		Echo:  e,
+		scope: dr.NewScope(factory),
+		root:  true,
+		shared: &routerShared{
+			routes:           dr.NewRouteRegistry(),
+			notFound:         dr.NotFoundHandler(),
+			methodNotAllowed: dr.MethodNotAllowedHandler(),
+		},
+	}
+	e.HTTPErrorHandler = r.handleError
+
+	return r
+}
+
+// WithMiddleware adds DataResponse middlewares to the router or group,
+// they apply to all its routes, including those registered before.
+func (r *Router) WithMiddleware(middlewares ...dr.Middleware) *Router {
+	r.scope.Use(middlewares...)
+	return r
+}
+
+// WithFactory sets the Factory of the router or group, e.g. a clone with another formatter.
+func (r *Router) WithFactory(factory *dr.Factory) *Router {
+	r.scope.SetFactory(factory)
+	return r
+}
+
+// With returns a router sharing the same routing tree with additional middlewares,
+// e.g. r.With(middleware.RequireRoles("admin")).Get("/stats", h).
+func (r *Router) With(middlewares ...dr.Middleware) *Router {
+	return r.sub(r.prefix, middlewares...)
+}
+
+// Handle registers a DataResponse handler for the given pattern and method, empty method matches any.
+func (r *Router) Handle(method, pattern string, handler dr.Handler) {
+	path := r.prefix + pattern
+	chained := r.scope.Handler(handler)
+
+	h := func(c echo.Context) error {
+		chained.ServeHTTP(c.Response(), withRouteContext(c))
+
+		return nil
+	}
+
+	if method == "" {
+		r.Echo.Any(echoPath(path), h, r.use...)
+	} else {
+		r.Echo.Add(method, echoPath(path), h, r.use...)
+	}
+
+	r.shared.routes.AddScoped(method, stdPath(path), handler, r.scope)
+}
+
+// HandleFunc registers a DataResponse handler function.
+func (r *Router) HandleFunc(method, pattern string, handlerFunc dr.HandlerFunc) {
+	r.Handle(method, pattern, handlerFunc)
+}
+
+// Get registers a GET handler.
+func (r *Router) Get(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodGet, pattern, handlerFunc)
+}
+
+// Post registers a POST handler.
+func (r *Router) Post(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodPost, pattern, handlerFunc)
+}
+
+// Put registers a PUT handler.
+func (r *Router) Put(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodPut, pattern, handlerFunc)
+}
+
+// Patch registers a PATCH handler.
+func (r *Router) Patch(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodPatch, pattern, handlerFunc)
+}
+
+// Delete registers a DELETE handler.
+func (r *Router) Delete(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodDelete, pattern, handlerFunc)
+}
+
+// Options registers an OPTIONS handler.
+func (r *Router) Options(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodOptions, pattern, handlerFunc)
+}
+
+// Head registers a HEAD handler.
+func (r *Router) Head(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodHead, pattern, handlerFunc)
+}
+
+// Group creates a group sharing the routing tree, with its own DataResponse middlewares and Factory
+// applied after the parent ones. Standard middlewares added by Use inside the group apply
+// to the subsequently registered group routes only, the parent ones are inherited.
+func (r *Router) Group(fn func(r *Router)) *Router {
+	group := r.sub(r.prefix)
+
+	if fn != nil {
+		fn(group)
+	}
+
+	return group
+}
+
+// Route creates a group with the path prefix, e.g. r.Route("/api", func(r *Router) { r.Get("/users", h) }).
+func (r *Router) Route(pattern string, fn func(r *Router)) {
+	sub := r.sub(r.prefix + strings.TrimSuffix(pattern, "/"))
+
+	if fn != nil {
+		fn(sub)
+	}
+}
+
+// Use adds standard middleware (not DataResponse middleware).
+// For DataResponse middleware, use WithMiddleware.
+// On the root router it applies to all requests, on a group to the subsequently registered group routes.
+func (r *Router) Use(middlewares ...func(http.Handler) http.Handler) {
+	for _, m := range middlewares {
+		if r.root {
+			r.Echo.Use(echo.WrapMiddleware(m))
+		} else {
+			r.use = append(r.use, echo.WrapMiddleware(m))
+		}
+	}
+}
+
+// Mount attaches a standard handler to the path and its sub-paths.
+func (r *Router) Mount(pattern string, handler http.Handler) {
+	path := echoPath(r.prefix + strings.TrimSuffix(pattern, "/"))
+	h := echo.WrapHandler(handler)
+
+	r.Echo.Any(path, h, r.use...)
+	r.Echo.Any(path+"/*", h, r.use...)
+}
+
+// NotFound sets the handler for requests not matching any route (default: factory-formatted 404).
+func (r *Router) NotFound(handler dr.Handler) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+
+	r.shared.notFound = handler
+}
+
+// MethodNotAllowed sets the handler for requests matching a route with another method
+// (default: factory-formatted 405). Allow header is always set, the allowed methods
+// are available via dr.AllowedMethods(r.Context()).
+func (r *Router) MethodNotAllowed(handler dr.Handler) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+
+	r.shared.methodNotAllowed = handler
+}
+
+// Routes returns the routes registered via Handle and its shortcuts.
+func (r *Router) Routes() []dr.Route {
+	return r.shared.routes.Routes()
+}
+
+// RouteRegistry returns the registry of the registered routes.
+func (r *Router) RouteRegistry() *dr.RouteRegistry {
+	return r.shared.routes
+}
+
+// Factory returns the Factory of the router or group.
+func (r *Router) Factory() *dr.Factory {
+	return r.scope.Factory()
+}
+
+func (r *Router) sub(prefix string, middlewares ...dr.Middleware) *Router {
+	return &Router{
+		Echo:   r.Echo,
+		scope:  r.scope.Sub(middlewares...),
+		use:    append([]echo.MiddlewareFunc{}, r.use...),
+		prefix: prefix,
+		shared: r.shared,
+	}
+}
+
+// handleError renders echo errors by the factory: 404 and 405 by NotFound and MethodNotAllowed handlers,
+// *echo.HTTPError by Factory.Error, other errors by Factory.InternalError.
+func (r *Router) handleError(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	req := c.Request()
+
+	var handler dr.Handler
+
+	var httpErr *echo.HTTPError
+	switch {
+	case errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound:
+		r.shared.mu.RLock()
+		handler = r.shared.notFound
+		r.shared.mu.RUnlock()
+	case errors.As(err, &httpErr) && httpErr.Code == http.StatusMethodNotAllowed:
+		r.shared.mu.RLock()
+		handler = dr.WithAllowHeader(r.shared.methodNotAllowed)
+		r.shared.mu.RUnlock()
+
+		// echo sets Allow header itself, the handler sets it on the response
+		c.Response().Header().Del(response.HeaderAllow)
+
+		allow, _ := c.Get(echo.ContextKeyHeaderAllow).(string)
+		req = req.WithContext(dr.WithAllowedMethods(req.Context(), splitMethods(allow)))
+	case errors.As(err, &httpErr):
+		handler = dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			return f.Error(r.Context(), httpErr.Code, fmt.Sprint(httpErr.Message))
+		})
+	default:
+		handler = dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			return f.InternalError(r.Context(), err)
+		})
+	}
+
+	r.scope.Wrap(handler).ServeHTTP(c.Response(), req)
+}
+
+func splitMethods(allow string) []string {
+	var methods []string
+	for _, method := range strings.Split(allow, ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+// withRouteContext returns the request with http.Request.Pattern set to the matched route
+// and echo path params mirrored into http.Request.PathValue.
+func withRouteContext(c echo.Context) *http.Request {
+	req := c.Request()
+	req = req.WithContext(req.Context())
+	req.Pattern = stdPath(c.Path())
+
+	values := c.ParamValues()
+	for i, name := range c.ParamNames() {
+		if i < len(values) {
+			req.SetPathValue(name, values[i])
+		}
+	}
+
+	return req
+}
+
+// echoPath converts "{id}" parameters to echo ":id" ones.
+func echoPath(path string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			sb.WriteString(path)
+
+			return sb.String()
+		}
+
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			panic(fmt.Sprintf("echoadapter: unclosed parameter in %q", path))
+		}
+		end += start
+
+		name := strings.TrimSuffix(path[start+1:end], "...")
+		if strings.Contains(name, ":") {
+			panic(fmt.Sprintf("echoadapter: regexp parameter constraints are not supported: %q", path))
+		}
+
+		sb.WriteString(path[:start])
+		sb.WriteString(":" + name)
+		path = path[end+1:]
+	}
+}
+
+// stdPath converts echo ":id" parameters to "{id}" ones used by the route registry.
+func stdPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,422 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package echoadapter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestRouter() *Router {
+	return NewRouter(dr.New(dr.WithFormatter(formatter.NewJSON())))
+}
+
+func okHandler(r *http.Request, f *dr.Factory) *response.DataResponse {
+	return f.Success(r.Context(), "ok")
+}
+
+// markMiddleware sets X-Middleware header on the responses it wraps.
+func markMiddleware(next dr.Handler) dr.Handler {
+	return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return next.Handle(r, f).SetHeader("X-Middleware", "applied")
+	})
+}
+
+// denyAll rejects requests, recording the route pattern seen by the middleware.
+func denyAll(pattern *string) dr.Middleware {
+	return func(dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			*pattern = r.Pattern
+
+			return f.Forbidden(r.Context(), "denied")
+		})
+	}
+}
+
+// markStd sets the header on all responses it wraps, it is a standard middleware.
+func markStd(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(name, "applied")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRouter_With(t *testing.T) {
+	var pattern string
+
+	router := newTestRouter()
+	router.With(denyAll(&pattern)).Get("/admin/{id}", okHandler)
+	router.Get("/public/:id", okHandler)
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantPattern string
+	}{
+		{name: "scoped route", target: "/admin/1", wantStatus: http.StatusForbidden, wantPattern: "/admin/{id}"},
+		{name: "other route", target: "/public/1", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern = ""
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if pattern != tt.wantPattern {
+				t.Errorf("r.Pattern = %q, want %q", pattern, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestRouter_Groups(t *testing.T) {
+	var pattern string
+
+	router := newTestRouter()
+	router.Use(markStd("X-Root"))
+	router.Get("/public", okHandler)
+	router.Group(func(admin *Router) {
+		admin.Use(markStd("X-Group"))
+		admin.WithMiddleware(denyAll(&pattern))
+		admin.Get("/admin", okHandler)
+	})
+	router.Route("/api/", func(api *Router) {
+		api.WithMiddleware(markMiddleware)
+		api.Get("/users/{id}", okHandler)
+	})
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantPattern string
+		wantHeaders []string
+		wantMissing []string
+	}{
+		{
+			name:        "root route",
+			target:      "/public",
+			wantStatus:  http.StatusOK,
+			wantHeaders: []string{"X-Root"},
+			wantMissing: []string{"X-Group", "X-Middleware"},
+		},
+		{
+			name:        "group route",
+			target:      "/admin",
+			wantStatus:  http.StatusForbidden,
+			wantPattern: "/admin",
+			wantHeaders: []string{"X-Root", "X-Group"},
+		},
+		{
+			name:        "prefixed route",
+			target:      "/api/users/7",
+			wantStatus:  http.StatusOK,
+			wantHeaders: []string{"X-Root", "X-Middleware"},
+			wantMissing: []string{"X-Group"},
+		},
+		{
+			name:        "not found",
+			target:      "/api/orders",
+			wantStatus:  http.StatusNotFound,
+			wantHeaders: []string{"X-Root"},
+			wantMissing: []string{"X-Group", "X-Middleware"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern = ""
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if pattern != tt.wantPattern {
+				t.Errorf("r.Pattern = %q, want %q", pattern, tt.wantPattern)
+			}
+			for _, name := range tt.wantHeaders {
+				if w.Header().Get(name) == "" {
+					t.Errorf("%s header is missing", name)
+				}
+			}
+			for _, name := range tt.wantMissing {
+				if w.Header().Get(name) != "" {
+					t.Errorf("%s header is set", name)
+				}
+			}
+		})
+	}
+
+	wantRoutes := []string{"GET /public", "GET /admin", "GET /api/users/{id}"}
+	var gotRoutes []string
+	for _, route := range router.Routes() {
+		gotRoutes = append(gotRoutes, route.Method+" "+route.Pattern)
+	}
+	if !reflect.DeepEqual(gotRoutes, wantRoutes) {
+		t.Errorf("Routes() = %v, want %v", gotRoutes, wantRoutes)
+	}
+}
+
+func TestRouter_Fallbacks(t *testing.T) {
+	router := newTestRouter().WithMiddleware(markMiddleware)
+	router.Get("/items/{id}", okHandler)
+	router.Delete("/items/{id}", okHandler)
+	router.Route("/api", func(api *Router) {
+		api.Post("/orders", okHandler)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		wantStatus  int
+		wantMethods []string
+	}{
+		{name: "matched route", method: http.MethodGet, target: "/items/1", wantStatus: http.StatusOK},
+		{name: "not found", method: http.MethodGet, target: "/missing", wantStatus: http.StatusNotFound},
+		{
+			name:        "method not allowed",
+			method:      http.MethodPut,
+			target:      "/items/1",
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantMethods: []string{http.MethodGet, http.MethodDelete},
+		},
+		{
+			name:        "method not allowed in sub-router",
+			method:      http.MethodGet,
+			target:      "/api/orders",
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantMethods: []string{http.MethodPost},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			allow := w.Header().Values(response.HeaderAllow)
+			if len(tt.wantMethods) == 0 && len(allow) > 0 {
+				t.Errorf("Allow = %q, want none", allow)
+			}
+			if len(allow) > 1 {
+				t.Errorf("Allow = %q, want a single header", allow)
+			}
+			for _, method := range tt.wantMethods {
+				if len(allow) == 0 || !strings.Contains(allow[0], method) {
+					t.Errorf("Allow = %q, want containing %s", allow, method)
+				}
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, response.ContentTypeJSON) {
+				t.Errorf("Content-Type = %q, want formatted by the factory", got)
+			}
+			if w.Header().Get("X-Middleware") != "applied" {
+				t.Error("router middleware is not applied")
+			}
+		})
+	}
+}
+
+func TestRouter_CustomFallbacks(t *testing.T) {
+	router := newTestRouter()
+	router.Get("/items", okHandler)
+	router.NotFound(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return f.NotFound(r.Context(), "no such page")
+	}))
+	router.MethodNotAllowed(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		if !strings.Contains(strings.Join(dr.AllowedMethods(r.Context()), ","), http.MethodGet) {
+			return f.Error(r.Context(), http.StatusMethodNotAllowed, "allowed methods are unknown")
+		}
+
+		return f.Error(r.Context(), http.StatusMethodNotAllowed, "use GET")
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "not found", method: http.MethodGet, target: "/missing", wantStatus: http.StatusNotFound, wantBody: "no such page"},
+		{name: "method not allowed", method: http.MethodPost, target: "/items", wantStatus: http.StatusMethodNotAllowed, wantBody: "use GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRouter_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "echo error",
+			err:        echo.NewHTTPError(http.StatusTooManyRequests, "slow down"),
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   "slow down",
+		},
+		{
+			name:       "other error",
+			err:        errors.New("connection reset"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter().WithMiddleware(markMiddleware)
+			router.Echo.Use(func(echo.HandlerFunc) echo.HandlerFunc {
+				return func(echo.Context) error {
+					return tt.err
+				}
+			})
+			router.Get("/items", okHandler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, response.ContentTypeJSON) {
+				t.Errorf("Content-Type = %q, want formatted by the factory", got)
+			}
+			if w.Header().Get("X-Middleware") != "applied" {
+				t.Error("router middleware is not applied")
+			}
+		})
+	}
+}
+
+func TestRouter_PathValues(t *testing.T) {
+	var (
+		pattern string
+		id      string
+	)
+
+	router := newTestRouter()
+	router.Route("/users/{user}", func(users *Router) {
+		users.Get("/orders/:id", func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			pattern = r.Pattern
+			id = r.PathValue("user") + "/" + r.PathValue("id")
+
+			return f.Success(r.Context(), nil)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7/orders/9", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if pattern != "/users/{user}/orders/{id}" {
+		t.Errorf("r.Pattern = %q, want %q", pattern, "/users/{user}/orders/{id}")
+	}
+	if id != "7/9" {
+		t.Errorf("path values = %q, want %q", id, "7/9")
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	router := newTestRouter()
+	router.Mount("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{name: "prefix", target: "/static"},
+		{name: "sub-path", target: "/static/js/app.js"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != http.StatusOK || w.Body.String() != tt.target {
+				t.Errorf("response = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, tt.target)
+			}
+		})
+	}
+}
+
+func TestEchoPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		want      string
+		wantPanic bool
+	}{
+		{name: "static", path: "/users", want: "/users"},
+		{name: "chi style", path: "/users/{user}/orders/{id}", want: "/users/:user/orders/:id"},
+		{name: "echo style", path: "/users/:id", want: "/users/:id"},
+		{name: "ServeMux wildcard suffix", path: "/files/{path...}", want: "/files/:path"},
+		{name: "unclosed", path: "/users/{id", wantPanic: true},
+		{name: "regexp constraint", path: "/users/{id:[0-9]+}", wantPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantPanic {
+					t.Errorf("panic = %v, want panic %t", r, tt.wantPanic)
+				}
+			}()
+
+			if got := echoPath(tt.path); got != tt.want {
+				t.Errorf("echoPath() = %q, want %q", got, tt.want)
+			}
+			if got := stdPath(tt.want); tt.name == "chi style" && got != tt.path {
+				t.Errorf("stdPath() = %q, want %q", got, tt.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+// Package httpadapter adapts DataResponse handlers for any router accepting http.Handler.
+//
+//	a := httpadapter.New(factory, httpadapter.WithPathParams(func(r *http.Request) map[string]string {
+//		return mux.Vars(r)
+//	}))
+//	router.Handle("/users/{id}", a.Get("/users/{id}", getUser)).Methods(http.MethodGet)
+package httpadapter
+
+import (
+	"net/http"
+	"strings"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+)
+
+// PathParamsFunc returns router-specific path parameters of the request.
+type PathParamsFunc func(r *http.Request) map[string]string
+
+// Option configures Adapter.
+type Option func(a *Adapter)
+
+// WithPathParams sets the function resolving path parameters, they are mirrored into http.Request.PathValue.
+func WithPathParams(fn PathParamsFunc) Option {
+	return func(a *Adapter) {
+		a.pathParams = fn
+	}
+}
+
+// Adapter converts DataResponse handlers to http.Handler and records them in the route registry.
+// Routing itself is left to the router the handlers are registered on.
+type Adapter struct {
+	scope      *dr.Scope
+	pathParams PathParamsFunc
+	prefix     string
+	routes     *dr.RouteRegistry
+}
+
+// New creates a new Adapter.
+func New(factory *dr.Factory, opts ...Option) *Adapter {
+	a := &Adapter{
+		scope:  dr.NewScope(factory),
+		routes: dr.NewRouteRegistry(),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// WithMiddleware adds DataResponse middlewares to the adapter or group,
+// they apply to all its handlers, including those adapted before.
+func (a *Adapter) WithMiddleware(middlewares ...dr.Middleware) *Adapter {
+	a.scope.Use(middlewares...)
+	return a
+}
+
+// WithFactory sets the Factory of the adapter or group, e.g. a clone with another formatter.
+func (a *Adapter) WithFactory(factory *dr.Factory) *Adapter {
+	a.scope.SetFactory(factory)
+	return a
+}
+
+// With returns an adapter sharing the route registry with additional middlewares.
+func (a *Adapter) With(middlewares ...dr.Middleware) *Adapter {
+	return a.sub(a.prefix, middlewares...)
+}
+
+// Group creates an adapter sharing the route registry, with its own DataResponse middlewares and Factory
+// applied after the parent ones.
+func (a *Adapter) Group(fn func(a *Adapter)) *Adapter {
+	group := a.sub(a.prefix)
+
+	if fn != nil {
+		fn(group)
+	}
+
+	return group
+}
+
+// Route creates a group recording routes under the path prefix.
+// The prefix must be routed by the router itself, e.g. by its subrouter.
+func (a *Adapter) Route(pattern string, fn func(a *Adapter)) {
+	sub := a.sub(a.prefix + strings.TrimSuffix(pattern, "/"))
+
+	if fn != nil {
+		fn(sub)
+	}
+}
+
+// Handle returns http.Handler serving the DataResponse handler and records the route, empty method matches any.
+// The pattern is set to http.Request.Pattern unless the router has set it.
+func (a *Adapter) Handle(method, pattern string, handler dr.Handler) http.Handler {
+	a.routes.AddScoped(method, a.prefix+pattern, handler, a.scope)
+
+	return a.withRouteContext(a.prefix+pattern, a.scope.Handler(handler))
+}
+
+// HandleFunc returns http.Handler serving the DataResponse handler function and records the route.
+func (a *Adapter) HandleFunc(method, pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.Handle(method, pattern, handlerFunc)
+}
+
+// Get returns http.Handler for a GET route.
+func (a *Adapter) Get(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodGet, pattern, handlerFunc)
+}
+
+// Post returns http.Handler for a POST route.
+func (a *Adapter) Post(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodPost, pattern, handlerFunc)
+}
+
+// Put returns http.Handler for a PUT route.
+func (a *Adapter) Put(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodPut, pattern, handlerFunc)
+}
+
+// Patch returns http.Handler for a PATCH route.
+func (a *Adapter) Patch(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodPatch, pattern, handlerFunc)
+}
+
+// Delete returns http.Handler for a DELETE route.
+func (a *Adapter) Delete(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodDelete, pattern, handlerFunc)
+}
+
+// Options returns http.Handler for an OPTIONS route.
+func (a *Adapter) Options(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodOptions, pattern, handlerFunc)
+}
+
+// Head returns http.Handler for a HEAD route.
+func (a *Adapter) Head(pattern string, handlerFunc dr.HandlerFunc) http.Handler {
+	return a.HandleFunc(http.MethodHead, pattern, handlerFunc)
+}
+
+// Handler returns http.Handler serving the DataResponse handler without recording a route,
+// e.g. for NotFound handlers of the router.
+func (a *Adapter) Handler(handler dr.Handler) http.Handler {
+	return a.withRouteContext("", a.scope.Handler(handler))
+}
+
+// HandlerFunc returns http.HandlerFunc serving the DataResponse handler function without recording a route.
+func (a *Adapter) HandlerFunc(handlerFunc dr.HandlerFunc) http.HandlerFunc {
+	return a.Handler(handlerFunc).ServeHTTP
+}
+
+// Routes returns the routes recorded via Handle and its shortcuts.
+func (a *Adapter) Routes() []dr.Route {
+	return a.routes.Routes()
+}
+
+// RouteRegistry returns the registry of the recorded routes.
+func (a *Adapter) RouteRegistry() *dr.RouteRegistry {
+	return a.routes
+}
+
+// Factory returns the Factory of the adapter or group.
+func (a *Adapter) Factory() *dr.Factory {
+	return a.scope.Factory()
+}
+
+func (a *Adapter) sub(prefix string, middlewares ...dr.Middleware) *Adapter {
+	return &Adapter{
+		scope:      a.scope.Sub(middlewares...),
+		pathParams: a.pathParams,
+		prefix:     prefix,
+		routes:     a.routes,
+	}
+}
+
+// withRouteContext sets http.Request.Pattern and mirrors router path params into http.Request.PathValue.
+func (a *Adapter) withRouteContext(pattern string, next http.Handler) http.Handler {
+	if pattern == "" && a.pathParams == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = req.WithContext(req.Context())
+
+		if req.Pattern == "" {
+			req.Pattern = pattern
+		}
+
+		if a.pathParams != nil {
+			for key, value := range a.pathParams(req) {
+				req.SetPathValue(key, value)
+			}
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,217 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package httpadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestFactory() *dr.Factory {
+	return dr.New(dr.WithFormatter(formatter.NewJSON()))
+}
+
+// echoRoute responds with the route pattern and the "id" path value seen by the handler.
+func echoRoute(r *http.Request, f *dr.Factory) *response.DataResponse {
+	return f.Success(r.Context(), r.Pattern+" "+r.PathValue("id"))
+}
+
+// markMiddleware sets the header on the responses it wraps.
+func markMiddleware(name string) dr.Middleware {
+	return func(next dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			return next.Handle(r, f).SetHeader(name, "applied")
+		})
+	}
+}
+
+// paramsRouter is a minimal router passing path params outside of http.Request.PathValue.
+type paramsRouter struct {
+	prefix  string
+	handler http.Handler
+}
+
+type paramsKey struct{}
+
+func (pr *paramsRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutPrefix(r.URL.Path, pr.prefix)
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), paramsKey{}, map[string]string{"id": id})
+	pr.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func TestAdapter(t *testing.T) {
+	tests := []struct {
+		name       string
+		router     func(a *Adapter) http.Handler
+		opts       []Option
+		target     string
+		wantStatus int
+		wantBody   string
+		wantHeader []string
+		wantRoutes []string
+	}{
+		{
+			name: "ServeMux sets the pattern and path values",
+			router: func(a *Adapter) http.Handler {
+				mux := http.NewServeMux()
+				mux.Handle("GET /users/{id}", a.Get("/users/{id}", echoRoute))
+
+				return mux
+			},
+			target:     "/users/7",
+			wantStatus: http.StatusOK,
+			wantBody:   `"GET /users/{id} 7"`,
+			wantRoutes: []string{"GET /users/{id}"},
+		},
+		{
+			name: "pattern is set for routers not setting it",
+			router: func(a *Adapter) http.Handler {
+				return &paramsRouter{prefix: "/users/", handler: a.Get("/users/{id}", echoRoute)}
+			},
+			target:     "/users/7",
+			wantStatus: http.StatusOK,
+			wantBody:   `"/users/{id} "`,
+			wantRoutes: []string{"GET /users/{id}"},
+		},
+		{
+			name: "path params are mirrored",
+			router: func(a *Adapter) http.Handler {
+				return &paramsRouter{prefix: "/users/", handler: a.Get("/users/{id}", echoRoute)}
+			},
+			opts: []Option{WithPathParams(func(r *http.Request) map[string]string {
+				params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+
+				return params
+			})},
+			target:     "/users/7",
+			wantStatus: http.StatusOK,
+			wantBody:   `"/users/{id} 7"`,
+			wantRoutes: []string{"GET /users/{id}"},
+		},
+		{
+			name: "route prefix is recorded",
+			router: func(a *Adapter) http.Handler {
+				mux := http.NewServeMux()
+				a.Route("/api/", func(api *Adapter) {
+					api.WithMiddleware(markMiddleware("X-Api"))
+					mux.Handle("/api/users/{id}", api.HandleFunc("", "/users/{id}", echoRoute))
+				})
+
+				return mux
+			},
+			target:     "/api/users/7",
+			wantStatus: http.StatusOK,
+			wantBody:   `"/api/users/{id} 7"`,
+			wantHeader: []string{"X-Api"},
+			wantRoutes: []string{" /api/users/{id}"},
+		},
+		{
+			name: "group and with middlewares",
+			router: func(a *Adapter) http.Handler {
+				mux := http.NewServeMux()
+				a.WithMiddleware(markMiddleware("X-Root"))
+				a.Group(func(g *Adapter) {
+					g.WithMiddleware(markMiddleware("X-Group"))
+					mux.Handle("POST /orders", g.With(markMiddleware("X-With")).Post("/orders", echoRoute))
+				})
+				mux.Handle("GET /orders", a.Get("/orders", echoRoute))
+
+				return mux
+			},
+			target:     "/orders",
+			wantStatus: http.StatusOK,
+			wantBody:   `"GET /orders "`,
+			wantHeader: []string{"X-Root"},
+			wantRoutes: []string{"POST /orders", "GET /orders"},
+		},
+		{
+			name: "handler is not recorded",
+			router: func(a *Adapter) http.Handler {
+				mux := http.NewServeMux()
+				mux.Handle("/", a.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+					return f.NotFound(r.Context(), "no such page")
+				}))
+
+				return mux
+			},
+			target:     "/missing",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "no such page",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(newTestFactory(), tt.opts...)
+			h := tt.router(a)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %s", w.Body.String(), tt.wantBody)
+			}
+			for _, name := range tt.wantHeader {
+				if w.Header().Get(name) == "" {
+					t.Errorf("%s header is missing", name)
+				}
+			}
+
+			var routes []string
+			for _, route := range a.Routes() {
+				routes = append(routes, route.Method+" "+route.Pattern)
+			}
+			if !reflect.DeepEqual(routes, tt.wantRoutes) {
+				t.Errorf("Routes() = %q, want %q", routes, tt.wantRoutes)
+			}
+		})
+	}
+}
+
+func TestAdapter_Factory(t *testing.T) {
+	f := newTestFactory()
+	other := dr.New(dr.WithFormatter(formatter.NewXML()))
+
+	a := New(f)
+	group := a.Group(nil).WithFactory(other)
+
+	if a.Factory() != f {
+		t.Error("adapter Factory() is not the given factory")
+	}
+	if group.Factory() != other {
+		t.Error("group Factory() is not the overridden factory")
+	}
+
+	w := httptest.NewRecorder()
+	group.Get("/", echoRoute).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, response.ContentTypeXML) {
+		t.Errorf("Content-Type = %q, want formatted by the group factory", got)
+	}
+	if a.RouteRegistry() != group.RouteRegistry() {
+		t.Error("group does not share the route registry")
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/raoptimus/data-response.go/pkg/muxadapter
+
+go 1.25.4
+
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/raoptimus/data-response.go/v2 v2.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+)
+
+replace github.com/raoptimus/data-response.go/v2 => ../../
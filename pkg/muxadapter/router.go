@@ -0,0 +1,269 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package muxadapter
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	dr "github.com/raoptimus/data-response.go/v2"
+)
+
+// Router wraps gorilla mux.Router with DataResponse support.
+type Router struct {
+	*mux.Router
+	scope  *dr.Scope
+	prefix string
+	shared *routerShared
+}
+
+// routerShared is the state shared by the root router and its groups.
+type routerShared struct {
+	mu sync.RWMutex
+
+	root             *mux.Router
+	routes           *dr.RouteRegistry
+	notFound         dr.Handler
+	methodNotAllowed dr.Handler
+}
+
+// NewRouter creates a new gorilla mux Router with DataResponse support.
+func NewRouter(factory *dr.Factory) *Router {
+	root := mux.NewRouter()
+
+	r := &Router{
+		Router: root,
+		scope:  dr.NewScope(factory),
+		shared: &routerShared{
+			root:             root,
+			routes:           dr.NewRouteRegistry(),
+			notFound:         dr.NotFoundHandler(),
+			methodNotAllowed: dr.MethodNotAllowedHandler(),
+		},
+	}
+	r.installFallbacks()
+
+	return r
+}
+
+// WithMiddleware adds DataResponse middlewares to the router or group,
+// they apply to all its routes, including those registered before.
+func (r *Router) WithMiddleware(middlewares ...dr.Middleware) *Router {
+	r.scope.Use(middlewares...)
+	return r
+}
+
+// WithFactory sets the Factory of the router or group, e.g. a clone with another formatter.
+func (r *Router) WithFactory(factory *dr.Factory) *Router {
+	r.scope.SetFactory(factory)
+	return r
+}
+
+// With returns a router sharing the same routing tree with additional middlewares,
+// e.g. r.With(middleware.RequireRoles("admin")).Get("/stats", h).
+func (r *Router) With(middlewares ...dr.Middleware) *Router {
+	return &Router{
+		Router: r.Router,
+		scope:  r.scope.Sub(middlewares...),
+		prefix: r.prefix,
+		shared: r.shared,
+	}
+}
+
+// Handle registers a DataResponse handler for the given pattern and method, empty method matches any.
+// Patterns use gorilla syntax, e.g. "/users/{id:[0-9]+}".
+func (r *Router) Handle(method, pattern string, handler dr.Handler) {
+	route := r.Router.Handle(pattern, withRouteContext(r.scope.Handler(handler)))
+	if method != "" {
+		route.Methods(method)
+	}
+
+	r.shared.routes.AddScoped(method, r.prefix+pattern, handler, r.scope)
+}
+
+// HandleFunc registers a DataResponse handler function.
+func (r *Router) HandleFunc(method, pattern string, handlerFunc dr.HandlerFunc) {
+	r.Handle(method, pattern, handlerFunc)
+}
+
+// Get registers a GET handler.
+func (r *Router) Get(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodGet, pattern, handlerFunc)
+}
+
+// Post registers a POST handler.
+func (r *Router) Post(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodPost, pattern, handlerFunc)
+}
+
+// Put registers a PUT handler.
+func (r *Router) Put(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodPut, pattern, handlerFunc)
+}
+
+// Patch registers a PATCH handler.
+func (r *Router) Patch(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodPatch, pattern, handlerFunc)
+}
+
+// Delete registers a DELETE handler.
+func (r *Router) Delete(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodDelete, pattern, handlerFunc)
+}
+
+// Options registers an OPTIONS handler.
+func (r *Router) Options(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodOptions, pattern, handlerFunc)
+}
+
+// Head registers a HEAD handler.
+func (r *Router) Head(pattern string, handlerFunc dr.HandlerFunc) {
+	r.HandleFunc(http.MethodHead, pattern, handlerFunc)
+}
+
+// Group creates a group sharing the routing tree, with its own DataResponse middlewares and Factory
+// applied after the parent ones. Standard middlewares added by Use inside the group apply
+// to the group routes only, the parent ones are inherited.
+func (r *Router) Group(fn func(r *Router)) *Router {
+	group := &Router{
+		Router: r.Router.NewRoute().Subrouter(),
+		scope:  r.scope.Sub(),
+		prefix: r.prefix,
+		shared: r.shared,
+	}
+
+	if fn != nil {
+		fn(group)
+	}
+
+	return group
+}
+
+// Route creates a group with the path prefix, e.g. r.Route("/api", func(r *Router) { r.Get("/users", h) }).
+func (r *Router) Route(pattern string, fn func(r *Router)) {
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	sub := &Router{
+		Router: r.Router.PathPrefix(pattern).Subrouter(),
+		scope:  r.scope.Sub(),
+		prefix: r.prefix + pattern,
+		shared: r.shared,
+	}
+
+	if fn != nil {
+		fn(sub)
+	}
+}
+
+// Use adds standard middleware (not DataResponse middleware).
+// For DataResponse middleware, use WithMiddleware.
+func (r *Router) Use(middlewares ...func(http.Handler) http.Handler) {
+	for _, m := range middlewares {
+		r.Router.Use(m)
+	}
+}
+
+// Mount attaches a standard handler to the path prefix.
+func (r *Router) Mount(pattern string, handler http.Handler) {
+	r.Router.PathPrefix(pattern).Handler(handler)
+}
+
+// NotFound sets the handler for requests not matching any route (default: factory-formatted 404).
+func (r *Router) NotFound(handler dr.Handler) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+
+	r.shared.notFound = handler
+}
+
+// MethodNotAllowed sets the handler for requests matching a route with another method
+// (default: factory-formatted 405). Allow header is always set, the allowed methods
+// are available via dr.AllowedMethods(r.Context()).
+func (r *Router) MethodNotAllowed(handler dr.Handler) {
+	r.shared.mu.Lock()
+	defer r.shared.mu.Unlock()
+
+	r.shared.methodNotAllowed = handler
+}
+
+// Routes returns the routes registered via Handle and its shortcuts.
+func (r *Router) Routes() []dr.Route {
+	return r.shared.routes.Routes()
+}
+
+// RouteRegistry returns the registry of the registered routes.
+func (r *Router) RouteRegistry() *dr.RouteRegistry {
+	return r.shared.routes
+}
+
+// Factory returns the Factory of the router or group.
+func (r *Router) Factory() *dr.Factory {
+	return r.scope.Factory()
+}
+
+// installFallbacks sets NotFound and MethodNotAllowed handlers of the root router,
+// they are served with the root router DataResponse middlewares and Factory.
+// Groups must not set them: gorilla stops matching at the first router having them.
+func (r *Router) installFallbacks() {
+	r.Router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.shared.mu.RLock()
+		handler := r.shared.notFound
+		r.shared.mu.RUnlock()
+
+		r.scope.Wrap(handler).ServeHTTP(w, req)
+	})
+
+	r.Router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.shared.mu.RLock()
+		handler := dr.WithAllowHeader(r.shared.methodNotAllowed)
+		r.shared.mu.RUnlock()
+
+		req = req.WithContext(dr.WithAllowedMethods(req.Context(), r.allowedMethods(req)))
+		r.scope.Wrap(handler).ServeHTTP(w, req)
+	})
+}
+
+// allowedMethods probes the methods of the routes matching the request path.
+func (r *Router) allowedMethods(req *http.Request) []string {
+	var allowed []string
+
+	for _, method := range dr.StandardMethods {
+		probe := req.Clone(req.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if r.shared.root.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+
+	return allowed
+}
+
+// withRouteContext sets http.Request.Pattern to the matched route template and mirrors mux.Vars
+// into http.Request.PathValue, so DataResponse middlewares and dr.Bind see the route like with ServeMux.
+func withRouteContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = req.WithContext(req.Context())
+
+		if route := mux.CurrentRoute(req); route != nil {
+			if pattern, err := route.GetPathTemplate(); err == nil {
+				req.Pattern = pattern
+			}
+		}
+
+		for key, value := range mux.Vars(req) {
+			req.SetPathValue(key, value)
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,324 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package muxadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	dr "github.com/raoptimus/data-response.go/v2"
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestRouter() *Router {
+	return NewRouter(dr.New(dr.WithFormatter(formatter.NewJSON())))
+}
+
+func okHandler(r *http.Request, f *dr.Factory) *response.DataResponse {
+	return f.Success(r.Context(), "ok")
+}
+
+// markMiddleware sets X-Middleware header on the responses it wraps.
+func markMiddleware(next dr.Handler) dr.Handler {
+	return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return next.Handle(r, f).SetHeader("X-Middleware", "applied")
+	})
+}
+
+// denyAll rejects requests, recording the route pattern seen by the middleware.
+func denyAll(pattern *string) dr.Middleware {
+	return func(dr.Handler) dr.Handler {
+		return dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			*pattern = r.Pattern
+
+			return f.Forbidden(r.Context(), "denied")
+		})
+	}
+}
+
+// markStd sets the header on all responses it wraps, it is a standard middleware.
+func markStd(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(name, "applied")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRouter_With(t *testing.T) {
+	var pattern string
+
+	router := newTestRouter()
+	router.With(denyAll(&pattern)).Get("/admin/{id}", okHandler)
+	router.Get("/public/{id}", okHandler)
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantPattern string
+	}{
+		{name: "scoped route", target: "/admin/1", wantStatus: http.StatusForbidden, wantPattern: "/admin/{id}"},
+		{name: "other route", target: "/public/1", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern = ""
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if pattern != tt.wantPattern {
+				t.Errorf("r.Pattern = %q, want %q", pattern, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestRouter_Groups(t *testing.T) {
+	var pattern string
+
+	router := newTestRouter()
+	router.Use(markStd("X-Root"))
+	router.Get("/public", okHandler)
+	router.Group(func(admin *Router) {
+		admin.Use(markStd("X-Group"))
+		admin.WithMiddleware(denyAll(&pattern))
+		admin.Get("/admin", okHandler)
+	})
+	router.Route("/api/", func(api *Router) {
+		api.WithMiddleware(markMiddleware)
+		api.Get("/users/{id:[0-9]+}", okHandler)
+	})
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantPattern string
+		wantHeaders []string
+		wantMissing []string
+	}{
+		{
+			name:        "root route",
+			target:      "/public",
+			wantStatus:  http.StatusOK,
+			wantHeaders: []string{"X-Root"},
+			wantMissing: []string{"X-Group", "X-Middleware"},
+		},
+		{
+			name:        "group route",
+			target:      "/admin",
+			wantStatus:  http.StatusForbidden,
+			wantPattern: "/admin",
+			wantHeaders: []string{"X-Root", "X-Group"},
+		},
+		{
+			name:        "prefixed route",
+			target:      "/api/users/7",
+			wantStatus:  http.StatusOK,
+			wantHeaders: []string{"X-Root", "X-Middleware"},
+			wantMissing: []string{"X-Group"},
+		},
+		{
+			name:        "regexp constraint mismatch",
+			target:      "/api/users/abc",
+			wantStatus:  http.StatusNotFound,
+			wantMissing: []string{"X-Middleware"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern = ""
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if pattern != tt.wantPattern {
+				t.Errorf("r.Pattern = %q, want %q", pattern, tt.wantPattern)
+			}
+			for _, name := range tt.wantHeaders {
+				if w.Header().Get(name) == "" {
+					t.Errorf("%s header is missing", name)
+				}
+			}
+			for _, name := range tt.wantMissing {
+				if w.Header().Get(name) != "" {
+					t.Errorf("%s header is set", name)
+				}
+			}
+		})
+	}
+
+	wantRoutes := []string{"GET /public", "GET /admin", "GET /api/users/{id:[0-9]+}"}
+	var gotRoutes []string
+	for _, route := range router.Routes() {
+		gotRoutes = append(gotRoutes, route.Method+" "+route.Pattern)
+	}
+	if !reflect.DeepEqual(gotRoutes, wantRoutes) {
+		t.Errorf("Routes() = %v, want %v", gotRoutes, wantRoutes)
+	}
+}
+
+func TestRouter_Fallbacks(t *testing.T) {
+	router := newTestRouter().WithMiddleware(markMiddleware)
+	router.Get("/items/{id}", okHandler)
+	router.Delete("/items/{id}", okHandler)
+	router.Route("/api", func(api *Router) {
+		api.Post("/orders", okHandler)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantAllow  string
+	}{
+		{name: "matched route", method: http.MethodGet, target: "/items/1", wantStatus: http.StatusOK},
+		{name: "not found", method: http.MethodGet, target: "/missing", wantStatus: http.StatusNotFound},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPut,
+			target:     "/items/1",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "GET, DELETE",
+		},
+		{name: "not found in sub-router", method: http.MethodGet, target: "/api/missing", wantStatus: http.StatusNotFound},
+		{
+			name:       "method not allowed in sub-router",
+			method:     http.MethodGet,
+			target:     "/api/orders",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "POST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderAllow); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, response.ContentTypeJSON) {
+				t.Errorf("Content-Type = %q, want formatted by the factory", got)
+			}
+			if w.Header().Get("X-Middleware") != "applied" {
+				t.Error("router middleware is not applied")
+			}
+		})
+	}
+}
+
+func TestRouter_CustomFallbacks(t *testing.T) {
+	router := newTestRouter()
+	router.Get("/items", okHandler)
+	router.NotFound(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return f.NotFound(r.Context(), "no such page")
+	}))
+	router.MethodNotAllowed(dr.HandlerFunc(func(r *http.Request, f *dr.Factory) *response.DataResponse {
+		return f.Error(r.Context(), http.StatusMethodNotAllowed, "use "+strings.Join(dr.AllowedMethods(r.Context()), " or "))
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantAllow  string
+		wantBody   string
+	}{
+		{name: "not found", method: http.MethodGet, target: "/missing", wantStatus: http.StatusNotFound, wantBody: "no such page"},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			target:     "/items",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "GET",
+			wantBody:   "use GET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderAllow); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRouter_PathValues(t *testing.T) {
+	var (
+		pattern string
+		id      string
+	)
+
+	router := newTestRouter()
+	router.Route("/users/{user}", func(users *Router) {
+		users.Get("/orders/{id:[0-9]+}", func(r *http.Request, f *dr.Factory) *response.DataResponse {
+			pattern = r.Pattern
+			id = r.PathValue("user") + "/" + r.PathValue("id")
+
+			return f.Success(r.Context(), nil)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7/orders/9", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if pattern != "/users/{user}/orders/{id:[0-9]+}" {
+		t.Errorf("r.Pattern = %q, want %q", pattern, "/users/{user}/orders/{id:[0-9]+}")
+	}
+	if id != "7/9" {
+		t.Errorf("path values = %q, want %q", id, "7/9")
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	router := newTestRouter()
+	router.Mount("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "/static/app.js" {
+		t.Errorf("response = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, "/static/app.js")
+	}
+}
@@ -0,0 +1,231 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseRecorder captures response data.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	written    bool
+}
+
+// newResponseRecorder returns the recorder and the writer exposing exactly the optional interfaces
+// (http.Flusher, http.Hijacker, io.ReaderFrom, http.Pusher) the underlying writer implements.
+func newResponseRecorder(w http.ResponseWriter) (*responseRecorder, http.ResponseWriter) {
+	rr := &responseRecorder{ResponseWriter: w}
+
+	var kind int
+	if _, ok := w.(http.Flusher); ok {
+		kind |= 1
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		kind |= 2
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		kind |= 4
+	}
+	if _, ok := w.(http.Pusher); ok {
+		kind |= 8
+	}
+
+	f, h, rf, p := recorderFlusher{rr}, recorderHijacker{rr}, recorderReaderFrom{rr}, recorderPusher{rr}
+
+	switch kind {
+	case 1:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+		}{rr, f}
+	case 2:
+		return rr, struct {
+			*responseRecorder
+			recorderHijacker
+		}{rr, h}
+	case 1 | 2:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderHijacker
+		}{rr, f, h}
+	case 4:
+		return rr, struct {
+			*responseRecorder
+			recorderReaderFrom
+		}{rr, rf}
+	case 1 | 4:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderReaderFrom
+		}{rr, f, rf}
+	case 2 | 4:
+		return rr, struct {
+			*responseRecorder
+			recorderHijacker
+			recorderReaderFrom
+		}{rr, h, rf}
+	case 1 | 2 | 4:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderHijacker
+			recorderReaderFrom
+		}{rr, f, h, rf}
+	case 8:
+		return rr, struct {
+			*responseRecorder
+			recorderPusher
+		}{rr, p}
+	case 1 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderPusher
+		}{rr, f, p}
+	case 2 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderHijacker
+			recorderPusher
+		}{rr, h, p}
+	case 1 | 2 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderHijacker
+			recorderPusher
+		}{rr, f, h, p}
+	case 4 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderReaderFrom
+			recorderPusher
+		}{rr, rf, p}
+	case 1 | 4 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderReaderFrom
+			recorderPusher
+		}{rr, f, rf, p}
+	case 2 | 4 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderHijacker
+			recorderReaderFrom
+			recorderPusher
+		}{rr, h, rf, p}
+	case 1 | 2 | 4 | 8:
+		return rr, struct {
+			*responseRecorder
+			recorderFlusher
+			recorderHijacker
+			recorderReaderFrom
+			recorderPusher
+		}{rr, f, h, rf, p}
+	default:
+		return rr, rr
+	}
+}
+
+// WriteHeader captures the status code.
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if rr.written {
+		return
+	}
+
+	rr.statusCode = statusCode
+	rr.ResponseWriter.WriteHeader(statusCode)
+	rr.written = true
+}
+
+// Write marks response as written.
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.writeHeader()
+
+	return rr.ResponseWriter.Write(b)
+}
+
+// Written returns true if response was written.
+func (rr *responseRecorder) Written() bool {
+	return rr.written
+}
+
+func (rr *responseRecorder) StatusCode() int {
+	return rr.statusCode
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// writeHeader writes the implicit 200 status before the body.
+func (rr *responseRecorder) writeHeader() {
+	if rr.written {
+		return
+	}
+
+	if rr.statusCode == 0 {
+		rr.statusCode = http.StatusOK
+	}
+
+	rr.WriteHeader(rr.statusCode)
+}
+
+type recorderFlusher struct {
+	rr *responseRecorder
+}
+
+// Flush writes the status, if not yet, and flushes the underlying writer.
+func (f recorderFlusher) Flush() {
+	f.rr.writeHeader()
+	f.rr.ResponseWriter.(http.Flusher).Flush()
+}
+
+type recorderHijacker struct {
+	rr *responseRecorder
+}
+
+// Hijack takes over the connection, the response is considered written.
+func (h recorderHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.rr.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.rr.written = true
+	}
+
+	return conn, rw, err
+}
+
+type recorderReaderFrom struct {
+	rr *responseRecorder
+}
+
+// ReadFrom lets the underlying writer copy the body, e.g. by sendfile for files.
+func (rf recorderReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	rf.rr.writeHeader()
+
+	return rf.rr.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+type recorderPusher struct {
+	rr *responseRecorder
+}
+
+// Push initiates an HTTP/2 server push.
+func (p recorderPusher) Push(target string, opts *http.PushOptions) error {
+	return p.rr.ResponseWriter.(http.Pusher).Push(target, opts)
+}
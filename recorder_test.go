@@ -0,0 +1,289 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// plainWriter implements no optional interfaces.
+type plainWriter struct {
+	header http.Header
+	status int
+	body   strings.Builder
+}
+
+func newPlainWriter() *plainWriter {
+	return &plainWriter{header: make(http.Header)}
+}
+
+func (pw *plainWriter) Header() http.Header { return pw.header }
+
+func (pw *plainWriter) WriteHeader(statusCode int) { pw.status = statusCode }
+
+func (pw *plainWriter) Write(b []byte) (int, error) { return pw.body.Write(b) }
+
+// hijackWriter implements http.Hijacker only.
+type hijackWriter struct {
+	*plainWriter
+	hijacked bool
+}
+
+func (hw *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hw.hijacked = true
+
+	return nil, nil, nil
+}
+
+// readerFromWriter implements io.ReaderFrom only, recording the source it copies from.
+type readerFromWriter struct {
+	*plainWriter
+	src io.Reader
+}
+
+func (rw *readerFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	rw.src = src
+
+	return io.Copy(&rw.body, src)
+}
+
+// pusherWriter implements http.Pusher only.
+type pusherWriter struct {
+	*plainWriter
+	pushed string
+}
+
+func (pw *pusherWriter) Push(target string, _ *http.PushOptions) error {
+	pw.pushed = target
+
+	return nil
+}
+
+// flushPushWriter implements http.Flusher and http.Pusher like an HTTP/2 writer.
+type flushPushWriter struct {
+	*pusherWriter
+	flushed bool
+}
+
+func (fw *flushPushWriter) Flush() { fw.flushed = true }
+
+func TestNewResponseRecorder_Interfaces(t *testing.T) {
+	tests := []struct {
+		name           string
+		writer         http.ResponseWriter
+		wantFlusher    bool
+		wantHijacker   bool
+		wantReaderFrom bool
+		wantPusher     bool
+	}{
+		{name: "plain", writer: newPlainWriter()},
+		{name: "flusher", writer: httptest.NewRecorder(), wantFlusher: true},
+		{name: "hijacker", writer: &hijackWriter{plainWriter: newPlainWriter()}, wantHijacker: true},
+		{name: "reader from", writer: &readerFromWriter{plainWriter: newPlainWriter()}, wantReaderFrom: true},
+		{name: "pusher", writer: &pusherWriter{plainWriter: newPlainWriter()}, wantPusher: true},
+		{
+			name:        "flusher and pusher",
+			writer:      &flushPushWriter{pusherWriter: &pusherWriter{plainWriter: newPlainWriter()}},
+			wantFlusher: true,
+			wantPusher:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, w := newResponseRecorder(tt.writer)
+
+			if _, ok := w.(http.Flusher); ok != tt.wantFlusher {
+				t.Errorf("http.Flusher = %t, want %t", ok, tt.wantFlusher)
+			}
+			if _, ok := w.(http.Hijacker); ok != tt.wantHijacker {
+				t.Errorf("http.Hijacker = %t, want %t", ok, tt.wantHijacker)
+			}
+			if _, ok := w.(io.ReaderFrom); ok != tt.wantReaderFrom {
+				t.Errorf("io.ReaderFrom = %t, want %t", ok, tt.wantReaderFrom)
+			}
+			if _, ok := w.(http.Pusher); ok != tt.wantPusher {
+				t.Errorf("http.Pusher = %t, want %t", ok, tt.wantPusher)
+			}
+
+			err := http.NewResponseController(w).Flush()
+			if tt.wantFlusher && err != nil {
+				t.Errorf("ResponseController.Flush() error = %v", err)
+			}
+			if !tt.wantFlusher && !errors.Is(err, http.ErrNotSupported) {
+				t.Errorf("ResponseController.Flush() error = %v, want %v", err, http.ErrNotSupported)
+			}
+		})
+	}
+}
+
+func TestNewResponseRecorder_Status(t *testing.T) {
+	tests := []struct {
+		name        string
+		write       func(w http.ResponseWriter)
+		wantStatus  int
+		wantWritten bool
+	}{
+		{name: "nothing written", write: func(http.ResponseWriter) {}},
+		{
+			name:        "explicit status",
+			write:       func(w http.ResponseWriter) { w.WriteHeader(http.StatusAccepted) },
+			wantStatus:  http.StatusAccepted,
+			wantWritten: true,
+		},
+		{
+			name: "first status wins",
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantStatus:  http.StatusCreated,
+			wantWritten: true,
+		},
+		{
+			name:        "implicit status on write",
+			write:       func(w http.ResponseWriter) { _, _ = w.Write([]byte("ok")) },
+			wantStatus:  http.StatusOK,
+			wantWritten: true,
+		},
+		{
+			name:        "implicit status on flush",
+			write:       func(w http.ResponseWriter) { w.(http.Flusher).Flush() },
+			wantStatus:  http.StatusOK,
+			wantWritten: true,
+		},
+		{
+			name: "implicit status on read from",
+			write: func(w http.ResponseWriter) {
+				_, _ = w.(io.ReaderFrom).ReadFrom(strings.NewReader("ok"))
+			},
+			wantStatus:  http.StatusOK,
+			wantWritten: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Writer of a real server implements Flusher, Hijacker and ReaderFrom
+			var (
+				rr       *responseRecorder
+				recorded int
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				var rw http.ResponseWriter
+				rr, rw = newResponseRecorder(w)
+				tt.write(rw)
+				recorded = rr.StatusCode()
+			}))
+
+			resp, err := srv.Client().Get(srv.URL)
+			if err != nil {
+				srv.Close()
+				t.Fatalf("GET: %v", err)
+			}
+			_ = resp.Body.Close()
+			srv.Close() // waits for the handler
+
+			if recorded != tt.wantStatus {
+				t.Errorf("StatusCode() = %d, want %d", recorded, tt.wantStatus)
+			}
+			if rr.Written() != tt.wantWritten {
+				t.Errorf("Written() = %t, want %t", rr.Written(), tt.wantWritten)
+			}
+			if wantStatus := max(tt.wantStatus, http.StatusOK); resp.StatusCode != wantStatus {
+				t.Errorf("client status = %d, want %d", resp.StatusCode, wantStatus)
+			}
+		})
+	}
+}
+
+func TestNewResponseRecorder_Passthrough(t *testing.T) {
+	t.Run("hijack marks response written", func(t *testing.T) {
+		hw := &hijackWriter{plainWriter: newPlainWriter()}
+		rr, w := newResponseRecorder(hw)
+
+		if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+			t.Fatalf("Hijack() error = %v", err)
+		}
+		if !hw.hijacked || !rr.Written() {
+			t.Errorf("hijacked = %t, Written() = %t, want both true", hw.hijacked, rr.Written())
+		}
+	})
+
+	t.Run("push", func(t *testing.T) {
+		pw := &pusherWriter{plainWriter: newPlainWriter()}
+		_, w := newResponseRecorder(pw)
+
+		if err := w.(http.Pusher).Push("/app.js", nil); err != nil || pw.pushed != "/app.js" {
+			t.Errorf("Push() = %v, pushed %q, want %q", err, pw.pushed, "/app.js")
+		}
+	})
+
+	t.Run("response controller", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, rw := newResponseRecorder(w)
+			rc := http.NewResponseController(rw)
+
+			if err := rc.SetWriteDeadline(time.Now().Add(time.Minute)); err != nil {
+				t.Errorf("SetWriteDeadline() error = %v", err)
+			}
+			if err := rc.EnableFullDuplex(); err != nil {
+				t.Errorf("EnableFullDuplex() error = %v", err)
+			}
+		}))
+		defer srv.Close()
+
+		resp, err := srv.Client().Get(srv.URL)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		_ = resp.Body.Close()
+	})
+}
+
+func TestWrapHandler_FileUsesReaderFrom(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(filename, []byte("id,name\n1,Alice\n"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	rw := &readerFromWriter{plainWriter: newPlainWriter()}
+	h := WrapHandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+		return f.File(r.Context(), filename)
+	}, New(WithFormatter(formatter.NewJSON())))
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/report", nil))
+
+	if rw.status != http.StatusOK {
+		t.Errorf("status = %d, want %d", rw.status, http.StatusOK)
+	}
+	if got := rw.body.String(); got != "id,name\n1,Alice\n" {
+		t.Errorf("body = %q, want the file content", got)
+	}
+
+	src := rw.src
+	if lr, ok := src.(*io.LimitedReader); ok {
+		src = lr.R
+	}
+	if _, ok := src.(*os.File); !ok {
+		t.Errorf("ReadFrom source = %T, want *os.File for sendfile", rw.src)
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/pkg/errors"
 )
 
-var ErrFormatterMustBeSet = errors.New("formatter must be set to response")
+var (
+	ErrFormatterMustBeSet = errors.New("formatter must be set to response")
+	ErrStreamResponse     = errors.New("stream response has no formatted body")
+)
 
 // Error wraps an error with stack trace preservation.
 // It provides additional context and preserves the call stack for debugging.
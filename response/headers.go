@@ -26,17 +26,21 @@ const (
 	HeaderHost            = "Host"
 	HeaderIfModifiedSince = "If-Modified-Since"
 	HeaderIfNoneMatch     = "If-None-Match"
+	HeaderRange           = "Range"
 	HeaderUserAgent       = "User-Agent"
 	HeaderReferer         = "Referer"
 
 	// Response Headers
 
+	HeaderAge             = "Age"
+	HeaderAllow           = "Allow"
 	HeaderETag            = "ETag"
 	HeaderLocation        = "Location"
 	HeaderRetryAfter      = "Retry-After"
 	HeaderServer          = "Server"
 	HeaderVary            = "Vary"
 	HeaderWWWAuthenticate = "WWW-Authenticate"
+	HeaderXCache          = "X-Cache"
 
 	// Entity Headers
 
@@ -60,6 +64,8 @@ const (
 	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
 	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
 	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
+	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
+	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
 	HeaderOrigin                        = "Origin"
 
 	// Security Headers
@@ -71,6 +77,22 @@ const (
 	HeaderXXSSProtection          = "X-XSS-Protection"
 	HeaderReferrerPolicy          = "Referrer-Policy"
 
+	HeaderContentSecurityPolicyReportOnly     = "Content-Security-Policy-Report-Only"
+	HeaderPermissionsPolicy                   = "Permissions-Policy"
+	HeaderCrossOriginOpenerPolicy             = "Cross-Origin-Opener-Policy"
+	HeaderCrossOriginOpenerPolicyReportOnly   = "Cross-Origin-Opener-Policy-Report-Only"
+	HeaderCrossOriginEmbedderPolicy           = "Cross-Origin-Embedder-Policy"
+	HeaderCrossOriginEmbedderPolicyReportOnly = "Cross-Origin-Embedder-Policy-Report-Only"
+	HeaderCrossOriginResourcePolicy           = "Cross-Origin-Resource-Policy"
+
+	// WebSocket Headers
+
+	HeaderUpgrade              = "Upgrade"
+	HeaderSecWebSocketKey      = "Sec-WebSocket-Key"
+	HeaderSecWebSocketAccept   = "Sec-WebSocket-Accept"
+	HeaderSecWebSocketVersion  = "Sec-WebSocket-Version"
+	HeaderSecWebSocketProtocol = "Sec-WebSocket-Protocol"
+
 	// Custom Headers
 
 	HeaderXRequestID          = "X-Request-ID"
@@ -82,6 +104,8 @@ const (
 	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
 	HeaderXRateLimitReset     = "X-RateLimit-Reset"
 	HeaderAPIVersion          = "API-Version"
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
 )
 
 // Common header values as constants.
@@ -90,9 +114,11 @@ const (
 
 	ContentTypeJSON             = "application/json"
 	ContentTypeJSONCharsetUTF8  = "application/json; charset=utf-8"
+	ContentTypeHealthJSON       = "application/health+json"
 	ContentTypeXML              = "application/xml"
 	ContentTypeXMLCharsetUTF8   = "application/xml; charset=utf-8"
 	ContentTypeTextXML          = "text/xml"
+	ContentTypeYAML             = "application/yaml"
 	ContentTypeHTML             = "text/html"
 	ContentTypeHTMLCharsetUTF8  = "text/html; charset=utf-8"
 	ContentTypePlain            = "text/plain"
@@ -134,4 +160,22 @@ const (
 
 	ReferrerPolicyNoReferrer                  = "no-referrer"
 	ReferrerPolicyStrictOriginWhenCrossOrigin = "strict-origin-when-cross-origin"
+
+	// Cross-Origin-Opener-Policy values
+
+	CrossOriginOpenerPolicySameOrigin            = "same-origin"
+	CrossOriginOpenerPolicySameOriginAllowPopups = "same-origin-allow-popups"
+	CrossOriginOpenerPolicyUnsafeNone            = "unsafe-none"
+
+	// Cross-Origin-Embedder-Policy values
+
+	CrossOriginEmbedderPolicyRequireCorp    = "require-corp"
+	CrossOriginEmbedderPolicyCredentialless = "credentialless"
+	CrossOriginEmbedderPolicyUnsafeNone     = "unsafe-none"
+
+	// Cross-Origin-Resource-Policy values
+
+	CrossOriginResourcePolicySameOrigin  = "same-origin"
+	CrossOriginResourcePolicySameSite    = "same-site"
+	CrossOriginResourcePolicyCrossOrigin = "cross-origin"
 )
@@ -0,0 +1,296 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Multipart subtypes.
+const (
+	MultipartMixed    = "mixed"
+	MultipartFormData = "form-data"
+)
+
+// ContentTypeHTTPResponse is the content type of a part holding an HTTP response (RFC 9112).
+const ContentTypeHTTPResponse = "application/http; msgtype=response"
+
+// Multipart builds a multipart body (RFC 2046) of parts, each part is a DataResponse
+// formatted by its own formatter or a binary stream.
+type Multipart struct {
+	subtype  string
+	boundary string
+	parts    []multipartPart
+}
+
+type multipartPart struct {
+	resp *DataResponse
+	name string // form-data field name
+
+	// The part is a whole HTTP response (status line, headers and body), e.g. of a batch request
+	embedded bool
+}
+
+// NewMultipart creates a multipart builder of the subtype, e.g. MultipartMixed, with a random boundary.
+func NewMultipart(subtype string) *Multipart {
+	return &Multipart{
+		subtype:  subtype,
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+	}
+}
+
+// Boundary returns the boundary separating the parts.
+func (m *Multipart) Boundary() string {
+	return m.boundary
+}
+
+// SetBoundary overrides the random boundary, it must be 1 to 70 allowed characters (RFC 2046).
+func (m *Multipart) SetBoundary(boundary string) error {
+	if err := multipart.NewWriter(io.Discard).SetBoundary(boundary); err != nil {
+		return errors.WithStack(err)
+	}
+
+	m.boundary = boundary
+
+	return nil
+}
+
+// ContentType returns the Content-Type header value with the boundary, e.g. multipart/mixed; boundary=...
+func (m *Multipart) ContentType() string {
+	return mime.FormatMediaType("multipart/"+m.subtype, map[string]string{"boundary": m.boundary})
+}
+
+// Add adds the response as a part with its headers, a binary response gets attachment disposition.
+func (m *Multipart) Add(part *DataResponse) *Multipart {
+	m.parts = append(m.parts, multipartPart{resp: part})
+
+	return m
+}
+
+// AddField adds the response as a form-data part named name, with filename for binary responses.
+func (m *Multipart) AddField(name string, part *DataResponse) *Multipart {
+	m.parts = append(m.parts, multipartPart{resp: part, name: name})
+
+	return m
+}
+
+// AddResponse adds the whole response (status line, headers and body) as an application/http part,
+// e.g. a sub-response of a batch request.
+func (m *Multipart) AddResponse(part *DataResponse) *Multipart {
+	m.parts = append(m.parts, multipartPart{resp: part, embedded: true})
+
+	return m
+}
+
+// Len returns the number of parts.
+func (m *Multipart) Len() int {
+	return len(m.parts)
+}
+
+// WriteTo writes the multipart body to w, the parts are formatted one by one as they are written.
+// Stream responses cannot be parts.
+func (m *Multipart) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	mw := multipart.NewWriter(cw)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return cw.n, errors.WithStack(err)
+	}
+
+	for i, part := range m.parts {
+		if err := m.writePart(mw, part); err != nil {
+			return cw.n, errors.Wrapf(err, "write part %d", i)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return cw.n, errors.WithStack(err)
+	}
+
+	return cw.n, nil
+}
+
+// Close closes all parts, e.g. files of binary responses, and returns the first error.
+func (m *Multipart) Close() error {
+	var firstErr error
+	for _, part := range m.parts {
+		if err := part.resp.Close(); err != nil && firstErr == nil {
+			firstErr = errors.WithStack(err)
+		}
+	}
+
+	return firstErr
+}
+
+func (m *Multipart) writePart(mw *multipart.Writer, part multipartPart) error {
+	resp := part.resp
+
+	body, err := resp.Body()
+	if err != nil {
+		return err
+	}
+
+	if !bodyAllowed(resp.StatusCode()) {
+		body = FormattedResponse{}
+	}
+
+	if part.embedded {
+		return writeEmbeddedPart(mw, resp, body)
+	}
+
+	header := make(textproto.MIMEHeader, len(resp.Header())+1)
+	for key, values := range resp.Header() {
+		header[key] = append([]string(nil), values...)
+	}
+
+	switch {
+	case part.name != "":
+		params := map[string]string{"name": part.name}
+		if resp.Filename() != "" {
+			params["filename"] = resp.Filename()
+		}
+		header.Set(HeaderContentDisposition, mime.FormatMediaType("form-data", params))
+	case resp.Filename() != "":
+		header.Set(HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+			"filename": resp.Filename(),
+		}))
+	}
+
+	pw, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	return copyBody(pw, body)
+}
+
+// writeEmbeddedPart writes the response as an HTTP message in an application/http part.
+func writeEmbeddedPart(mw *multipart.Writer, resp *DataResponse, body FormattedResponse) error {
+	pw, err := mw.CreatePart(textproto.MIMEHeader{
+		HeaderContentType: {ContentTypeHTTPResponse},
+	})
+	if err != nil {
+		return err
+	}
+
+	header := resp.Header().Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if body.StreamSize > 0 {
+		header.Set(HeaderContentLength, strconv.FormatInt(body.StreamSize, 10))
+	}
+	if resp.Filename() != "" {
+		header.Set(HeaderContentDisposition, `attachment; filename="`+resp.Filename()+`"`)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "HTTP/1.1 %03d %s\r\n", resp.StatusCode(), http.StatusText(resp.StatusCode()))
+	if err := header.Write(&buf); err != nil {
+		return err
+	}
+	buf.WriteString("\r\n")
+
+	if _, err := pw.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	return copyBody(pw, body)
+}
+
+func copyBody(w io.Writer, body FormattedResponse) error {
+	if body.Stream == nil {
+		return nil
+	}
+
+	var err error
+	if body.StreamSize > 0 {
+		_, err = io.CopyN(w, body.Stream, body.StreamSize)
+	} else {
+		_, err = io.Copy(w, body.Stream)
+	}
+
+	return err
+}
+
+// bodyAllowed reports whether a given response status code permits a body (RFC 7230, section 3.3).
+func bodyAllowed(status int) bool {
+	return (status < 100 || status > 199) && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// multipartReader streams the multipart body through a pipe, the parts are written
+// once the body is read and closed together with the response.
+type multipartReader struct {
+	multipart *Multipart
+
+	once sync.Once
+	pr   *io.PipeReader
+	done chan struct{}
+}
+
+func newMultipartReader(m *Multipart) *multipartReader {
+	return &multipartReader{multipart: m}
+}
+
+func (r *multipartReader) Read(b []byte) (int, error) {
+	r.once.Do(r.start)
+
+	if r.pr == nil {
+		return 0, io.ErrClosedPipe
+	}
+
+	return r.pr.Read(b)
+}
+
+// Close stops writing the parts and closes them.
+func (r *multipartReader) Close() error {
+	r.once.Do(func() {}) // not read, nothing to stop
+
+	if r.pr != nil {
+		_ = r.pr.Close()
+		<-r.done
+	}
+
+	return r.multipart.Close()
+}
+
+func (r *multipartReader) start() {
+	pr, pw := io.Pipe()
+	r.pr = pr
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		_, err := r.multipart.WriteTo(pw)
+		_ = pw.CloseWithError(err)
+	}()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+
+	return n, err
+}
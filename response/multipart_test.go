@@ -0,0 +1,294 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// textFormatter formats the data with fmt, it stands in for the formatter package.
+type textFormatter struct {
+	BaseFormatter
+}
+
+func (textFormatter) Format(resp *DataResponse) (FormattedResponse, error) {
+	body := fmt.Sprint(resp.Data())
+
+	return FormattedResponse{Stream: strings.NewReader(body), StreamSize: int64(len(body))}, nil
+}
+
+func (textFormatter) ContentType() string {
+	return ContentTypePlain
+}
+
+// trackingCloser records whether the part body is closed.
+type trackingCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (tc *trackingCloser) Close() error {
+	tc.closed = true
+
+	return nil
+}
+
+func textPart(status int, data any) *DataResponse {
+	return NewDataResponse(status, data).WithFormatter(textFormatter{})
+}
+
+func binaryPart(body, filename string) (*DataResponse, *trackingCloser) {
+	closer := &trackingCloser{Reader: strings.NewReader(body)}
+
+	return NewDataResponse(http.StatusOK, nil).
+		WithFormatted(FormattedResponse{Stream: closer, StreamSize: int64(len(body))}).
+		WithFile(closer, filename).
+		WithContentType("text/csv"), closer
+}
+
+type wantPart struct {
+	header map[string]string
+	body   string
+}
+
+func TestMultipart_WriteTo(t *testing.T) {
+	tests := []struct {
+		name      string
+		subtype   string
+		build     func(m *Multipart)
+		wantParts []wantPart
+	}{
+		{
+			name:    "mixed parts",
+			subtype: MultipartMixed,
+			build: func(m *Multipart) {
+				csv, _ := binaryPart("id\n1\n", "users.csv")
+				m.Add(textPart(http.StatusOK, "manifest").SetHeader("X-Part", "manifest")).Add(csv)
+			},
+			wantParts: []wantPart{
+				{header: map[string]string{HeaderContentType: ContentTypePlain, "X-Part": "manifest"}, body: "manifest"},
+				{
+					header: map[string]string{
+						HeaderContentType:        "text/csv",
+						HeaderContentDisposition: `attachment; filename=users.csv`,
+					},
+					body: "id\n1\n",
+				},
+			},
+		},
+		{
+			name:    "form-data fields",
+			subtype: MultipartFormData,
+			build: func(m *Multipart) {
+				csv, _ := binaryPart("id\n1\n", "users.csv")
+				m.AddField("manifest", textPart(http.StatusOK, "manifest")).AddField("file", csv)
+			},
+			wantParts: []wantPart{
+				{header: map[string]string{HeaderContentDisposition: `form-data; name=manifest`}, body: "manifest"},
+				{header: map[string]string{HeaderContentDisposition: `form-data; filename=users.csv; name=file`}, body: "id\n1\n"},
+			},
+		},
+		{
+			name:    "no body for 204",
+			subtype: MultipartMixed,
+			build: func(m *Multipart) {
+				m.Add(textPart(http.StatusNoContent, "ignored"))
+			},
+			wantParts: []wantPart{{header: map[string]string{HeaderContentType: ContentTypePlain}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultipart(tt.subtype)
+			tt.build(m)
+
+			var buf bytes.Buffer
+			n, err := m.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo() = %d, want %d written bytes", n, buf.Len())
+			}
+
+			mediaType, params, err := mime.ParseMediaType(m.ContentType())
+			if err != nil || mediaType != "multipart/"+tt.subtype || params["boundary"] != m.Boundary() {
+				t.Fatalf("ContentType() = %q, want multipart/%s with the boundary", m.ContentType(), tt.subtype)
+			}
+
+			reader := multipart.NewReader(&buf, m.Boundary())
+			for i, want := range tt.wantParts {
+				part, err := reader.NextRawPart()
+				if err != nil {
+					t.Fatalf("part %d: %v", i, err)
+				}
+				for name, value := range want.header {
+					if got := part.Header.Get(name); got != value {
+						t.Errorf("part %d %s = %q, want %q", i, name, got, value)
+					}
+				}
+				if body, _ := io.ReadAll(part); string(body) != want.body {
+					t.Errorf("part %d body = %q, want %q", i, body, want.body)
+				}
+			}
+			if _, err := reader.NextRawPart(); !errors.Is(err, io.EOF) {
+				t.Errorf("extra part or error after %d parts: %v", len(tt.wantParts), err)
+			}
+		})
+	}
+}
+
+func TestMultipart_AddResponse(t *testing.T) {
+	m := NewMultipart(MultipartMixed)
+	m.AddResponse(textPart(http.StatusCreated, "created").SetHeader(HeaderLocation, "/users/7")).
+		AddResponse(textPart(http.StatusNotModified, "ignored"))
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	tests := []struct {
+		status     int
+		wantHeader map[string]string
+		wantBody   string
+	}{
+		{
+			status: http.StatusCreated,
+			wantHeader: map[string]string{
+				HeaderLocation:      "/users/7",
+				HeaderContentType:   ContentTypePlain,
+				HeaderContentLength: "7",
+			},
+			wantBody: "created",
+		},
+		{status: http.StatusNotModified, wantHeader: map[string]string{HeaderContentLength: ""}},
+	}
+
+	reader := multipart.NewReader(&buf, m.Boundary())
+	for i, tt := range tests {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if got := part.Header.Get(HeaderContentType); got != ContentTypeHTTPResponse {
+			t.Errorf("part %d Content-Type = %q, want %q", i, got, ContentTypeHTTPResponse)
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			t.Fatalf("part %d: read response: %v", i, err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("part %d status = %d, want %d", i, resp.StatusCode, tt.status)
+		}
+		for name, want := range tt.wantHeader {
+			if got := resp.Header.Get(name); got != want {
+				t.Errorf("part %d %s = %q, want %q", i, name, got, want)
+			}
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != tt.wantBody {
+			t.Errorf("part %d body = %q, want %q", i, body, tt.wantBody)
+		}
+	}
+}
+
+func TestMultipart_SetBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		boundary string
+		wantErr  bool
+	}{
+		{name: "valid", boundary: "batch_42"},
+		{name: "empty", boundary: "", wantErr: true},
+		{name: "too long", boundary: strings.Repeat("b", 71), wantErr: true},
+		{name: "invalid character", boundary: "batch\n42", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultipart(MultipartMixed)
+			random := m.Boundary()
+
+			err := m.SetBoundary(tt.boundary)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetBoundary() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			want := tt.boundary
+			if tt.wantErr {
+				want = random
+			}
+			if m.Boundary() != want {
+				t.Errorf("Boundary() = %q, want %q", m.Boundary(), want)
+			}
+		})
+	}
+}
+
+func TestDataResponse_WithMultipart(t *testing.T) {
+	tests := []struct {
+		name string
+		read bool
+	}{
+		{name: "read body", read: true},
+		{name: "discarded body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv, closer := binaryPart("id\n1\n", "users.csv")
+
+			m := NewMultipart(MultipartMixed).Add(textPart(http.StatusOK, "manifest")).Add(csv)
+			resp := NewDataResponse(http.StatusOK, nil).WithMultipart(m)
+
+			if got := resp.HeaderLine(HeaderContentType); got != m.ContentType() {
+				t.Errorf("Content-Type = %q, want %q", got, m.ContentType())
+			}
+			if resp.Multipart() != m {
+				t.Error("Multipart() is not the builder")
+			}
+
+			body, err := resp.Body()
+			if err != nil {
+				t.Fatalf("Body() error = %v", err)
+			}
+			if body.StreamSize != -1 {
+				t.Errorf("StreamSize = %d, want -1 for a streamed body", body.StreamSize)
+			}
+
+			if tt.read {
+				data, err := io.ReadAll(body.Stream)
+				if err != nil {
+					t.Fatalf("read body: %v", err)
+				}
+				if !bytes.Contains(data, []byte("id\n1\n")) || !bytes.HasSuffix(data, []byte("--"+m.Boundary()+"--\r\n")) {
+					t.Errorf("body = %q, want all parts and the closing boundary", data)
+				}
+			}
+
+			if err := resp.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if !closer.closed {
+				t.Error("binary part is not closed with the response")
+			}
+		})
+	}
+}
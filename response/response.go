@@ -35,8 +35,18 @@ type DataResponse struct {
 	filename string
 
 	closer io.Closer // Close after response is written
+
+	stream StreamFunc // Writes the body directly to the client
+
+	multipart *Multipart // Parts of a multipart response
+
+	cspNonce string // Per-request Content-Security-Policy nonce
 }
 
+// StreamFunc writes the response body directly to the client after the status and headers are written,
+// e.g. a handler flushing server-sent events.
+type StreamFunc func(w http.ResponseWriter) error
+
 func NewDataResponse(statusCode int, data any) *DataResponse {
 	return &DataResponse{
 		statusCode: statusCode,
@@ -62,6 +72,10 @@ func (r *DataResponse) Data() any {
 }
 
 func (r *DataResponse) Body() (FormattedResponse, error) {
+	if r.stream != nil {
+		return FormattedResponse{}, errors.WithStack(ErrStreamResponse)
+	}
+
 	if r.hasFormatted {
 		return r.formatted, nil
 	}
@@ -178,6 +192,15 @@ func (r *DataResponse) SetHeader(key, value string) *DataResponse {
 	return r
 }
 
+// WithDefaultHeader sets a header value only if the header is not set yet.
+func (r *DataResponse) WithDefaultHeader(key, value string) *DataResponse {
+	if r.HasHeader(key) {
+		return r
+	}
+
+	return r.SetHeader(key, value)
+}
+
 func (r *DataResponse) WithoutHeader(key string) *DataResponse {
 	if len(r.header) == 0 {
 		return r
@@ -218,6 +241,7 @@ func (r *DataResponse) WithCacheControl(value string) *DataResponse {
 }
 
 // WithCORS returns a copy of response with CORS headers.
+// For preflight handling and origin matching use middleware.CORS.
 func (r *DataResponse) WithCORS(origin, methods, headers string) *DataResponse {
 	r.WithHeader(HeaderAccessControlAllowOrigin, origin)
 	if len(methods) > 0 {
@@ -231,11 +255,25 @@ func (r *DataResponse) WithCORS(origin, methods, headers string) *DataResponse {
 }
 
 // WithSecurityHeaders returns a copy of response with common security headers.
+// Headers already set on the response are kept as is.
+// For a configurable policy use middleware.SecurityHeaders.
 func (r *DataResponse) WithSecurityHeaders() *DataResponse {
 	return r.
-		WithHeader(HeaderXContentTypeOptions, ContentTypeOptionsNoSniff).
-		WithHeader(HeaderXFrameOptions, FrameOptionsDeny).
-		WithHeader(HeaderReferrerPolicy, ReferrerPolicyStrictOriginWhenCrossOrigin)
+		WithDefaultHeader(HeaderXContentTypeOptions, ContentTypeOptionsNoSniff).
+		WithDefaultHeader(HeaderXFrameOptions, FrameOptionsDeny).
+		WithDefaultHeader(HeaderReferrerPolicy, ReferrerPolicyStrictOriginWhenCrossOrigin)
+}
+
+// CSPNonce returns the Content-Security-Policy nonce of the response.
+func (r *DataResponse) CSPNonce() string {
+	return r.cspNonce
+}
+
+// WithCSPNonce sets the Content-Security-Policy nonce available to formatters (e.g. HTML templates).
+func (r *DataResponse) WithCSPNonce(nonce string) *DataResponse {
+	r.cspNonce = nonce
+
+	return r
 }
 
 func (r *DataResponse) WithFormatter(formatter Formatter) *DataResponse {
@@ -259,3 +297,50 @@ func (r *DataResponse) WithFile(closer io.Closer, filename string) *DataResponse
 
 	return r
 }
+
+// WithCloser sets the closer called once the response is written or discarded.
+func (r *DataResponse) WithCloser(closer io.Closer) *DataResponse {
+	r.closer = closer
+
+	return r
+}
+
+// WithStream makes the response a stream response: its body is written by the function
+// and it is neither formatted nor buffered, so middlewares reading the body (compression, cache) skip it.
+// With status 101 the function writes the status itself, e.g. by hijacking the connection.
+func (r *DataResponse) WithStream(stream StreamFunc) *DataResponse {
+	r.stream = stream
+
+	return r
+}
+
+// Stream returns the function writing the body of a stream response.
+func (r *DataResponse) Stream() StreamFunc {
+	return r.stream
+}
+
+// IsStream returns true if this is a stream response.
+func (r *DataResponse) IsStream() bool {
+	return r.stream != nil
+}
+
+// WithMultipart makes the response a multipart one: the parts are formatted and streamed as the body is read,
+// the Content-Type header is set with the boundary and the parts are closed with the response.
+func (r *DataResponse) WithMultipart(m *Multipart) *DataResponse {
+	reader := newMultipartReader(m)
+
+	r.multipart = m
+	r.closer = reader
+
+	return r.
+		WithFormatted(FormattedResponse{
+			Stream:     reader,
+			StreamSize: -1,
+		}).
+		WithContentType(m.ContentType())
+}
+
+// Multipart returns the parts of a multipart response, nil for other responses.
+func (r *DataResponse) Multipart() *Multipart {
+	return r.multipart
+}
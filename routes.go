@@ -0,0 +1,238 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// StandardMethods lists methods probed to build Allow header of 405 responses.
+var StandardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// Route describes a registered route.
+type Route struct {
+	// Method is the route method, empty if the route matches any method.
+	Method string
+
+	// Pattern is the route path pattern.
+	Pattern string
+
+	// Middlewares lists names of DataResponse middlewares wrapping the handler, outermost first.
+	Middlewares []string
+
+	// Metadata is provided by the handler implementing RouteMetadataProvider, e.g. OpenAPI operation.
+	Metadata any
+}
+
+// RouteMetadataProvider is implemented by handlers describing their route, see openapi.Describe.
+type RouteMetadataProvider interface {
+	RouteMetadata() any
+}
+
+// RouteRegistry records registered routes for introspection.
+type RouteRegistry struct {
+	mu     sync.RWMutex
+	routes []routeEntry
+}
+
+// routeEntry is a registered route, scoped routes resolve middlewares when listed.
+type routeEntry struct {
+	route Route
+	scope *Scope
+}
+
+// NewRouteRegistry creates an empty route registry.
+func NewRouteRegistry() *RouteRegistry {
+	return &RouteRegistry{}
+}
+
+// Add records the route.
+func (rr *RouteRegistry) Add(method, pattern string, handler Handler, middlewares []Middleware) {
+	route := newRoute(method, pattern, handler)
+	route.Middlewares = middlewareNames(middlewares)
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.routes = append(rr.routes, routeEntry{route: route})
+}
+
+// AddScoped records the route served by Scope.Handler, its middlewares are listed as the scope has them.
+func (rr *RouteRegistry) AddScoped(method, pattern string, handler Handler, scope *Scope) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.routes = append(rr.routes, routeEntry{route: newRoute(method, pattern, handler), scope: scope})
+}
+
+// Routes returns the routes in registration order.
+func (rr *RouteRegistry) Routes() []Route {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	routes := make([]Route, 0, len(rr.routes))
+	for _, entry := range rr.routes {
+		route := entry.route
+		if entry.scope != nil {
+			route.Middlewares = middlewareNames(entry.scope.Middlewares())
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes
+}
+
+func newRoute(method, pattern string, handler Handler) Route {
+	var metadata any
+	if p, ok := handler.(RouteMetadataProvider); ok {
+		metadata = p.RouteMetadata()
+	}
+
+	return Route{
+		Method:   method,
+		Pattern:  pattern,
+		Metadata: metadata,
+	}
+}
+
+func middlewareNames(middlewares []Middleware) []string {
+	names := make([]string, 0, len(middlewares))
+	for _, m := range middlewares {
+		names = append(names, MiddlewareName(m))
+	}
+
+	return names
+}
+
+// Dump writes the route table.
+func (rr *RouteRegistry) Dump(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "METHOD\tPATTERN\tMIDDLEWARES"); err != nil {
+		return err
+	}
+
+	for _, route := range rr.Routes() {
+		method := route.Method
+		if method == "" {
+			method = "*"
+		}
+
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", method, route.Pattern, strings.Join(route.Middlewares, ", ")); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// String returns the route table.
+func (rr *RouteRegistry) String() string {
+	var sb strings.Builder
+	_ = rr.Dump(&sb)
+
+	return sb.String()
+}
+
+// MiddlewareName returns the name of the function that has created the middleware, e.g. "middleware.CORS".
+func MiddlewareName(m Middleware) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(m).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if idx := strings.LastIndex(name, "/"); idx > -1 {
+		name = name[idx+1:]
+	}
+
+	// Strip closure suffixes: pkg.Func.func1.2
+	parts := strings.Split(name, ".")
+	for len(parts) > 2 && isClosureSuffix(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func isClosureSuffix(part string) bool {
+	part = strings.TrimPrefix(part, "func")
+
+	return part != "" && strings.Trim(part, "0123456789") == ""
+}
+
+// SplitPattern splits ServeMux pattern "[METHOD ][HOST]/[PATH]" into method and the rest.
+func SplitPattern(pattern string) (method, path string) {
+	if method, path, ok := strings.Cut(pattern, " "); ok && !strings.Contains(method, "/") {
+		return method, strings.TrimLeft(path, " \t")
+	}
+
+	return "", pattern
+}
+
+type allowedMethodsContextKey struct{}
+
+// WithAllowedMethods stores methods allowed for the requested path, used by 405 handlers.
+func WithAllowedMethods(ctx context.Context, methods []string) context.Context {
+	return context.WithValue(ctx, allowedMethodsContextKey{}, methods)
+}
+
+// AllowedMethods returns methods allowed for the requested path.
+func AllowedMethods(ctx context.Context) []string {
+	methods, _ := ctx.Value(allowedMethodsContextKey{}).([]string)
+
+	return methods
+}
+
+// NotFoundHandler returns the default 404 handler formatted by the factory.
+func NotFoundHandler() HandlerFunc {
+	return func(r *http.Request, f *Factory) *response.DataResponse {
+		return f.NotFound(r.Context(), "")
+	}
+}
+
+// MethodNotAllowedHandler returns the default 405 handler formatted by the factory.
+func MethodNotAllowedHandler() HandlerFunc {
+	return func(r *http.Request, f *Factory) *response.DataResponse {
+		return f.MethodNotAllowed(r.Context(), "", AllowedMethods(r.Context())...)
+	}
+}
+
+// WithAllowHeader wraps a 405 handler, so its response always has Allow header.
+func WithAllowHeader(h Handler) Handler {
+	return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+		resp := h.Handle(r, f)
+
+		if allowed := AllowedMethods(r.Context()); len(allowed) > 0 && !resp.HasHeader(response.HeaderAllow) {
+			resp.SetHeader(response.HeaderAllow, strings.Join(allowed, ", "))
+		}
+
+		return resp
+	})
+}
@@ -0,0 +1,131 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// pkgName is the last element of the package path, MiddlewareName prefixes names with it.
+const pkgName = "v2"
+
+// describedHandler provides route metadata.
+type describedHandler struct {
+	HandlerFunc
+}
+
+func (describedHandler) RouteMetadata() any {
+	return "list items"
+}
+
+func authMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+			return next.Handle(r, f)
+		})
+	}
+}
+
+func TestServeMux_Routes(t *testing.T) {
+	mux := newTestMux().WithMiddleware(markMiddleware)
+	mux.Handle("GET /items", describedHandler{HandlerFunc: routeHandler})
+	mux.With(authMiddleware()).HandleFunc("POST /items", routeHandler)
+	mux.Route("/admin", func(admin *ServeMux) {
+		admin.HandleFunc("/stats", routeHandler)
+	})
+
+	want := []Route{
+		{Method: http.MethodGet, Pattern: "/items", Middlewares: []string{pkgName + ".markMiddleware"}, Metadata: "list items"},
+		{Method: http.MethodPost, Pattern: "/items", Middlewares: []string{pkgName + ".markMiddleware", pkgName + ".authMiddleware"}},
+		{Pattern: "/admin/stats", Middlewares: []string{pkgName + ".markMiddleware"}},
+	}
+
+	routes := mux.Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("routes = %+v, want %+v", routes, want)
+	}
+	for i := range want {
+		got := routes[i]
+		if got.Method != want[i].Method || got.Pattern != want[i].Pattern || got.Metadata != want[i].Metadata ||
+			!slices.Equal(got.Middlewares, want[i].Middlewares) {
+			t.Errorf("route %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	// Middlewares added later are listed for the routes registered before
+	mux.WithMiddleware(authMiddleware())
+	if got := mux.Routes()[0].Middlewares; len(got) != 2 || got[1] != pkgName+".authMiddleware" {
+		t.Errorf("middlewares = %v, want the late middleware listed", got)
+	}
+}
+
+func TestRouteRegistry_Dump(t *testing.T) {
+	rr := NewRouteRegistry()
+	rr.Add(http.MethodGet, "/items", HandlerFunc(routeHandler), []Middleware{markMiddleware})
+	rr.Add("", "/health", HandlerFunc(routeHandler), nil)
+
+	want := strings.Join([]string{
+		"METHOD  PATTERN  MIDDLEWARES",
+		"GET     /items   " + pkgName + ".markMiddleware",
+		"*       /health  ",
+		"",
+	}, "\n")
+
+	if got := rr.String(); got != want {
+		t.Errorf("Dump() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMiddlewareName(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Middleware
+		want string
+	}{
+		{name: "function", m: markMiddleware, want: pkgName + ".markMiddleware"},
+		{name: "constructor closure", m: authMiddleware(), want: pkgName + ".authMiddleware"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MiddlewareName(tt.m); got != tt.want {
+				t.Errorf("MiddlewareName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPattern(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		wantMethod string
+		wantPath   string
+	}{
+		{pattern: "GET /items", wantMethod: "GET", wantPath: "/items"},
+		{pattern: "POST   /items/{id}", wantMethod: "POST", wantPath: "/items/{id}"},
+		{pattern: "/items", wantPath: "/items"},
+		{pattern: "example.com/items", wantPath: "example.com/items"},
+		{pattern: "GET example.com/a b", wantMethod: "GET", wantPath: "example.com/a b"},
+		{pattern: "/a b", wantPath: "/a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			method, path := SplitPattern(tt.pattern)
+			if method != tt.wantMethod || path != tt.wantPath {
+				t.Errorf("SplitPattern() = %q, %q, want %q, %q", method, path, tt.wantMethod, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Scope is the DataResponse middleware stack and Factory of a router group.
+// A child scope runs the parent middlewares first, then its own, and uses the parent Factory
+// unless it has its own one. Middlewares and Factory are resolved when a request is served,
+// so they apply to all routes of the scope regardless of the registration order.
+type Scope struct {
+	mu          sync.RWMutex
+	parent      *Scope
+	factory     *Factory
+	middlewares []Middleware
+
+	// generation is shared by the scope tree, it changes on any update to invalidate resolved chains.
+	generation *atomic.Uint64
+}
+
+// NewScope creates a root scope.
+func NewScope(factory *Factory) *Scope {
+	return &Scope{
+		factory:    factory,
+		generation: &atomic.Uint64{},
+	}
+}
+
+// Sub creates a child scope with additional middlewares.
+func (s *Scope) Sub(middlewares ...Middleware) *Scope {
+	return &Scope{
+		parent:      s,
+		middlewares: append([]Middleware{}, middlewares...),
+		generation:  s.generation,
+	}
+}
+
+// Use appends middlewares to the scope.
+func (s *Scope) Use(middlewares ...Middleware) {
+	s.mu.Lock()
+	s.middlewares = append(s.middlewares, middlewares...)
+	s.mu.Unlock()
+
+	s.generation.Add(1)
+}
+
+// SetFactory sets the Factory of the scope and its children not having their own one,
+// e.g. scope.SetFactory(factory.Clone(dr.WithFormatter(formatter.NewXML()))).
+func (s *Scope) SetFactory(factory *Factory) {
+	s.mu.Lock()
+	s.factory = factory
+	s.mu.Unlock()
+
+	s.generation.Add(1)
+}
+
+// Factory returns the Factory of the scope or the nearest parent having one.
+func (s *Scope) Factory() *Factory {
+	for scope := s; scope != nil; scope = scope.parent {
+		scope.mu.RLock()
+		factory := scope.factory
+		scope.mu.RUnlock()
+
+		if factory != nil {
+			return factory
+		}
+	}
+
+	return nil
+}
+
+// Middlewares returns the middlewares of the parents and the scope, outermost first.
+func (s *Scope) Middlewares() []Middleware {
+	var middlewares []Middleware
+	if s.parent != nil {
+		middlewares = s.parent.Middlewares()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append(middlewares, s.middlewares...)
+}
+
+// Wrap returns http.Handler serving the handler with the current middlewares and Factory of the scope.
+func (s *Scope) Wrap(h Handler) http.Handler {
+	return WrapHandler(Chain(h, s.Middlewares()...), s.Factory())
+}
+
+// Handler returns http.Handler serving the handler with the scope middlewares and Factory,
+// including those added after the handler has been registered.
+func (s *Scope) Handler(h Handler) http.Handler {
+	type resolved struct {
+		generation uint64
+		handler    http.Handler
+	}
+
+	var cached atomic.Pointer[resolved]
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		generation := s.generation.Load()
+
+		current := cached.Load()
+		if current == nil || current.generation != generation {
+			current = &resolved{generation: generation, handler: s.Wrap(h)}
+			cached.Store(current)
+		}
+
+		current.handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,252 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// traceMiddleware appends the name to X-Trace header when the request enters it.
+func traceMiddleware(name string) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+			r.Header.Add("X-Trace", name)
+
+			return next.Handle(r, f)
+		})
+	}
+}
+
+// traceHandler responds with the middlewares the request has passed in X-Trace header.
+func traceHandler(r *http.Request, f *Factory) *response.DataResponse {
+	return f.Success(r.Context(), nil).SetHeader("X-Trace", strings.Join(r.Header.Values("X-Trace"), ","))
+}
+
+func TestScope(t *testing.T) {
+	jsonFactory := New(WithFormatter(formatter.NewJSON()))
+	xmlFactory := jsonFactory.Clone(WithFormatter(formatter.NewXML()))
+
+	tests := []struct {
+		name            string
+		setup           func(root *Scope) *Scope
+		wantTrace       string
+		wantContentType string
+	}{
+		{
+			name:            "root",
+			setup:           func(root *Scope) *Scope { return root },
+			wantTrace:       "root",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "parent middlewares run first",
+			setup: func(root *Scope) *Scope {
+				return root.Sub(traceMiddleware("sub")).Sub(traceMiddleware("leaf"))
+			},
+			wantTrace:       "root,sub,leaf",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "middlewares keep the order",
+			setup: func(root *Scope) *Scope {
+				sub := root.Sub(traceMiddleware("first"))
+				sub.Use(traceMiddleware("second"), traceMiddleware("third"))
+
+				return sub
+			},
+			wantTrace:       "root,first,second,third",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "own factory",
+			setup: func(root *Scope) *Scope {
+				sub := root.Sub()
+				sub.SetFactory(xmlFactory)
+
+				return sub
+			},
+			wantTrace:       "root",
+			wantContentType: response.ContentTypeXML,
+		},
+		{
+			name: "parent factory is inherited",
+			setup: func(root *Scope) *Scope {
+				sub := root.Sub()
+				leaf := sub.Sub(traceMiddleware("leaf"))
+				sub.SetFactory(xmlFactory)
+
+				return leaf
+			},
+			wantTrace:       "root,leaf",
+			wantContentType: response.ContentTypeXML,
+		},
+		{
+			name: "child factory does not affect parent",
+			setup: func(root *Scope) *Scope {
+				root.Sub().SetFactory(xmlFactory)
+
+				return root
+			},
+			wantTrace:       "root",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name: "sibling middlewares are not shared",
+			setup: func(root *Scope) *Scope {
+				root.Sub(traceMiddleware("sibling"))
+
+				return root.Sub(traceMiddleware("sub"))
+			},
+			wantTrace:       "root,sub",
+			wantContentType: response.ContentTypeJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewScope(jsonFactory)
+			root.Use(traceMiddleware("root"))
+			scope := tt.setup(root)
+
+			w := httptest.NewRecorder()
+			scope.Wrap(HandlerFunc(traceHandler)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("X-Trace"); got != tt.wantTrace {
+				t.Errorf("X-Trace = %q, want %q", got, tt.wantTrace)
+			}
+		})
+	}
+}
+
+func TestScope_Handler(t *testing.T) {
+	jsonFactory := New(WithFormatter(formatter.NewJSON()))
+
+	root := NewScope(jsonFactory)
+	sub := root.Sub(traceMiddleware("sub"))
+	h := sub.Handler(HandlerFunc(traceHandler))
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return w
+	}
+
+	steps := []struct {
+		name            string
+		update          func()
+		wantTrace       string
+		wantContentType string
+	}{
+		{name: "registered", update: func() {}, wantTrace: "sub", wantContentType: response.ContentTypeJSON},
+		{
+			name:            "parent middleware added later",
+			update:          func() { root.Use(traceMiddleware("root")) },
+			wantTrace:       "root,sub",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "own middleware added later",
+			update:          func() { sub.Use(traceMiddleware("late")) },
+			wantTrace:       "root,sub,late",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "sibling update keeps the chain",
+			update:          func() { root.Sub().Use(traceMiddleware("sibling")) },
+			wantTrace:       "root,sub,late",
+			wantContentType: response.ContentTypeJSON,
+		},
+		{
+			name:            "parent factory set later",
+			update:          func() { root.SetFactory(jsonFactory.Clone(WithFormatter(formatter.NewXML()))) },
+			wantTrace:       "root,sub,late",
+			wantContentType: response.ContentTypeXML,
+		},
+	}
+
+	for _, step := range steps {
+		step.update()
+
+		w := serve()
+		if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, step.wantContentType) {
+			t.Errorf("%s: Content-Type = %q, want %q", step.name, got, step.wantContentType)
+		}
+		if got := w.Header().Get("X-Trace"); got != step.wantTrace {
+			t.Errorf("%s: X-Trace = %q, want %q", step.name, got, step.wantTrace)
+		}
+	}
+}
+
+func TestServeMux_Groups(t *testing.T) {
+	f := New(WithFormatter(formatter.NewJSON()))
+
+	mux := NewServeMux(f)
+	mux.HandleFunc("GET /public", traceHandler)
+	mux.Group(func(admin *ServeMux) {
+		admin.HandleFunc("GET /admin", traceHandler)
+		admin.WithMiddleware(traceMiddleware("admin"))
+	})
+	mux.Route("/api/", func(api *ServeMux) {
+		api.WithFactory(f.Clone(WithFormatter(formatter.NewXML())))
+		api.HandleFunc("GET /users", traceHandler)
+		api.With(traceMiddleware("with")).HandleFunc("GET /stats", traceHandler)
+	})
+	mux.WithMiddleware(traceMiddleware("root"))
+
+	tests := []struct {
+		name            string
+		target          string
+		wantStatus      int
+		wantTrace       string
+		wantContentType string
+	}{
+		{name: "root route", target: "/public", wantStatus: http.StatusOK, wantTrace: "root", wantContentType: response.ContentTypeJSON},
+		{name: "group route", target: "/admin", wantStatus: http.StatusOK, wantTrace: "root,admin", wantContentType: response.ContentTypeJSON},
+		{name: "prefixed route", target: "/api/users", wantStatus: http.StatusOK, wantTrace: "root", wantContentType: response.ContentTypeXML},
+		{name: "with route", target: "/api/stats", wantStatus: http.StatusOK, wantTrace: "root,with", wantContentType: response.ContentTypeXML},
+		{name: "prefix is not a route", target: "/users", wantStatus: http.StatusNotFound, wantContentType: response.ContentTypeJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderContentType); !strings.HasPrefix(got, tt.wantContentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("X-Trace"); got != tt.wantTrace {
+				t.Errorf("X-Trace = %q, want %q", got, tt.wantTrace)
+			}
+		})
+	}
+
+	wantRoutes := []string{"GET /public", "GET /admin", "GET /api/users", "GET /api/stats"}
+	var gotRoutes []string
+	for _, route := range mux.Routes() {
+		gotRoutes = append(gotRoutes, route.Method+" "+route.Pattern)
+	}
+	if !reflect.DeepEqual(gotRoutes, wantRoutes) {
+		t.Errorf("Routes() = %v, want %v", gotRoutes, wantRoutes)
+	}
+}
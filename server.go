@@ -10,37 +10,238 @@ package dataresponse
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 )
 
 type Middleware func(next Handler) Handler
 
 type ServeMux struct {
 	*http.ServeMux
-	factory     *Factory
-	middlewares []Middleware
+	scope  *Scope
+	prefix string
+	shared *muxShared
+}
+
+// muxShared is the state shared by the mux and its groups.
+type muxShared struct {
+	mu               sync.RWMutex
+	scope            *Scope
+	routes           *RouteRegistry
+	notFound         Handler
+	methodNotAllowed Handler
 }
 
 // NewServeMux allocates and returns a new [ServeMux].
 func NewServeMux(factory *Factory) *ServeMux {
+	scope := NewScope(factory)
+
 	return &ServeMux{
-		ServeMux:    http.NewServeMux(),
-		factory:     factory,
-		middlewares: make([]Middleware, 0),
+		ServeMux: http.NewServeMux(),
+		scope:    scope,
+		shared: &muxShared{
+			scope:            scope,
+			routes:           NewRouteRegistry(),
+			notFound:         NotFoundHandler(),
+			methodNotAllowed: MethodNotAllowedHandler(),
+		},
 	}
 }
 
 func (s *ServeMux) Handle(pattern string, handler Handler) {
-	chained := Chain(handler, s.middlewares...)
-	s.ServeMux.Handle(pattern, WrapHandler(chained, s.factory))
+	method, path := SplitPattern(pattern)
+	path = joinPath(s.prefix, path)
+
+	if method != "" {
+		pattern = method + " " + path
+	} else {
+		pattern = path
+	}
+
+	s.ServeMux.Handle(pattern, s.scope.Handler(handler))
+	s.shared.routes.AddScoped(method, path, handler, s.scope)
 }
 
 func (s *ServeMux) HandleFunc(pattern string, handler HandlerFunc) {
-	chained := Chain(handler, s.middlewares...)
-	s.ServeMux.HandleFunc(pattern, WrapHandlerFunc(chained.Handle, s.factory))
+	s.Handle(pattern, handler)
+}
+
+// joinPath inserts the group prefix before the path of "[HOST]/[PATH]".
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	}
+
+	idx := strings.IndexByte(path, '/')
+	if idx < 0 {
+		return path + prefix
+	}
+
+	return path[:idx] + prefix + path[idx:]
+}
+
+// NotFound sets the handler for requests not matching any pattern (default: factory-formatted 404).
+func (s *ServeMux) NotFound(handler Handler) {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+
+	s.shared.notFound = handler
+}
+
+// MethodNotAllowed sets the handler for requests matching a pattern with another method
+// (default: factory-formatted 405). Allow header is always set, the allowed methods
+// are available via AllowedMethods(r.Context()).
+func (s *ServeMux) MethodNotAllowed(handler Handler) {
+	s.shared.mu.Lock()
+	defer s.shared.mu.Unlock()
+
+	s.shared.methodNotAllowed = handler
 }
 
+// Routes returns the registered routes.
+func (s *ServeMux) Routes() []Route {
+	return s.shared.routes.Routes()
+}
+
+// RouteRegistry returns the registry of the registered routes.
+func (s *ServeMux) RouteRegistry() *RouteRegistry {
+	return s.shared.routes
+}
+
+// ServeHTTP dispatches the request, unmatched requests get the NotFound or MethodNotAllowed
+// handlers wrapped with the mux middlewares.
+func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.RequestURI == "*" || r.Method == http.MethodConnect {
+		s.ServeMux.ServeHTTP(w, r)
+
+		return
+	}
+
+	// The matched handler is served directly, so the request is routed once
+	if handler, pattern := s.ServeMux.Handler(r); pattern != "" {
+		setPattern(r, pattern)
+		handler.ServeHTTP(w, r)
+
+		return
+	}
+
+	s.shared.mu.RLock()
+	handler := s.shared.notFound
+	allowed := s.allowedMethods(r)
+	if len(allowed) > 0 {
+		handler = WithAllowHeader(s.shared.methodNotAllowed)
+		r = r.WithContext(WithAllowedMethods(r.Context(), allowed))
+	}
+	s.shared.mu.RUnlock()
+
+	s.shared.scope.Wrap(handler).ServeHTTP(w, r)
+}
+
+// setPattern sets http.Request.Pattern and the path values of the wildcards, as http.ServeMux.ServeHTTP does
+// (http.ServeMux.Handler returns only the pattern). Segments of a redirected path may not align, they are skipped.
+func setPattern(r *http.Request, pattern string) {
+	r.Pattern = pattern
+
+	_, path := SplitPattern(pattern)
+	if idx := strings.IndexByte(path, '/'); idx > 0 {
+		path = path[idx:]
+	}
+
+	segments := strings.Split(r.URL.EscapedPath(), "/")
+	for i, segment := range strings.Split(path, "/") {
+		if i >= len(segments) || !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name := segment[1 : len(segment)-1]
+		value := segments[i]
+		if multi, ok := strings.CutSuffix(name, "..."); ok {
+			name = multi
+			value = strings.Join(segments[i:], "/")
+		}
+
+		if name == "$" {
+			continue
+		}
+
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		r.SetPathValue(name, value)
+	}
+}
+
+// allowedMethods probes the methods matching the request path.
+func (s *ServeMux) allowedMethods(r *http.Request) []string {
+	var allowed []string
+
+	for _, method := range StandardMethods {
+		probe := *r
+		probe.Method = method
+
+		if _, pattern := s.ServeMux.Handler(&probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+
+	return allowed
+}
+
+// WithMiddleware adds DataResponse middlewares to the mux or group,
+// they apply to all its routes, including those registered before.
 func (s *ServeMux) WithMiddleware(m ...Middleware) *ServeMux {
-	s.middlewares = append(s.middlewares, m...)
+	s.scope.Use(m...)
+
+	return s
+}
+
+// WithFactory sets the Factory of the mux or group, e.g. a clone with another formatter.
+func (s *ServeMux) WithFactory(factory *Factory) *ServeMux {
+	s.scope.SetFactory(factory)
 
 	return s
 }
+
+// Factory returns the Factory of the mux or group.
+func (s *ServeMux) Factory() *Factory {
+	return s.scope.Factory()
+}
+
+// With returns a group sharing the same routes with additional middlewares,
+// e.g. mux.With(middleware.RequireRoles("admin")).HandleFunc("GET /stats", h).
+func (s *ServeMux) With(m ...Middleware) *ServeMux {
+	return s.sub(s.prefix, m)
+}
+
+// Group creates a group sharing the routes, with its own middlewares and Factory
+// applied after the parent ones.
+func (s *ServeMux) Group(fn func(g *ServeMux)) *ServeMux {
+	group := s.sub(s.prefix, nil)
+
+	if fn != nil {
+		fn(group)
+	}
+
+	return group
+}
+
+// Route creates a group with the path prefix, e.g. mux.Route("/api", func(api *dr.ServeMux) {
+// api.HandleFunc("GET /users", h) }) registers "GET /api/users".
+func (s *ServeMux) Route(prefix string, fn func(g *ServeMux)) {
+	group := s.sub(s.prefix+strings.TrimSuffix(prefix, "/"), nil)
+
+	if fn != nil {
+		fn(group)
+	}
+}
+
+func (s *ServeMux) sub(prefix string, m []Middleware) *ServeMux {
+	return &ServeMux{
+		ServeMux: s.ServeMux,
+		scope:    s.scope.Sub(m...),
+		prefix:   prefix,
+		shared:   s.shared,
+	}
+}
@@ -0,0 +1,287 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+func newTestMux() *ServeMux {
+	return NewServeMux(New(WithFormatter(formatter.NewJSON())))
+}
+
+// markMiddleware sets X-Middleware header on the responses it wraps.
+func markMiddleware(next Handler) Handler {
+	return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+		return next.Handle(r, f).SetHeader("X-Middleware", "applied")
+	})
+}
+
+// routeHandler responds with the matched pattern.
+func routeHandler(r *http.Request, f *Factory) *response.DataResponse {
+	return f.Success(r.Context(), r.Pattern)
+}
+
+func TestServeMux_Fallbacks(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		wantStatus     int
+		wantAllow      string
+		wantBody       string
+		wantMiddleware bool
+	}{
+		{
+			name:           "matched route",
+			method:         http.MethodGet,
+			target:         "/items/1",
+			wantStatus:     http.StatusOK,
+			wantBody:       `"GET /items/{id}"`,
+			wantMiddleware: true,
+		},
+		{
+			name:           "not found",
+			method:         http.MethodGet,
+			target:         "/missing",
+			wantStatus:     http.StatusNotFound,
+			wantBody:       `Not Found`,
+			wantMiddleware: true,
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPut,
+			target:         "/items/1",
+			wantStatus:     http.StatusMethodNotAllowed,
+			wantAllow:      "GET, HEAD, DELETE",
+			wantBody:       `Method Not Allowed`,
+			wantMiddleware: true,
+		},
+		{
+			name:       "method not allowed on collection",
+			method:     http.MethodDelete,
+			target:     "/items",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "POST",
+		},
+		{
+			name:       "head is served by get",
+			method:     http.MethodHead,
+			target:     "/items/1",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	mux := newTestMux().WithMiddleware(markMiddleware)
+	mux.HandleFunc("GET /items/{id}", routeHandler)
+	mux.HandleFunc("DELETE /items/{id}", routeHandler)
+	mux.HandleFunc("POST /items", routeHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderAllow); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantStatus != http.StatusOK && !strings.HasPrefix(w.Header().Get(response.HeaderContentType), response.ContentTypeJSON) {
+				t.Errorf("Content-Type = %q, want formatted by the factory", w.Header().Get(response.HeaderContentType))
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %s", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantMiddleware && w.Header().Get("X-Middleware") != "applied" {
+				t.Error("mux middleware is not applied")
+			}
+		})
+	}
+}
+
+func TestServeMux_CustomFallbacks(t *testing.T) {
+	mux := newTestMux()
+	mux.HandleFunc("GET /items", routeHandler)
+	mux.NotFound(HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+		return f.NotFound(r.Context(), "no such page")
+	}))
+	mux.MethodNotAllowed(HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+		return f.Error(r.Context(), http.StatusMethodNotAllowed, "use "+strings.Join(AllowedMethods(r.Context()), " or "))
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantAllow  string
+		wantBody   string
+	}{
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			target:     "/missing",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "no such page",
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			target:     "/items",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "GET, HEAD",
+			wantBody:   "use GET or HEAD",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(response.HeaderAllow); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServeMux_PathValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		target      string
+		host        string
+		values      []string
+		wantPattern string
+		want        map[string]string
+	}{
+		{
+			name:        "single wildcard",
+			pattern:     "GET /items/{id}",
+			target:      "/items/42",
+			values:      []string{"id"},
+			wantPattern: "GET /items/{id}",
+			want:        map[string]string{"id": "42"},
+		},
+		{
+			name:        "several wildcards",
+			pattern:     "/users/{user}/orders/{order}",
+			target:      "/users/7/orders/9",
+			values:      []string{"user", "order"},
+			wantPattern: "/users/{user}/orders/{order}",
+			want:        map[string]string{"user": "7", "order": "9"},
+		},
+		{
+			name:        "escaped slash",
+			pattern:     "GET /files/{name}",
+			target:      "/files/a%2Fb",
+			values:      []string{"name"},
+			wantPattern: "GET /files/{name}",
+			want:        map[string]string{"name": "a/b"},
+		},
+		{
+			name:        "remaining segments",
+			pattern:     "GET /static/{path...}",
+			target:      "/static/css/site%20main.css",
+			values:      []string{"path"},
+			wantPattern: "GET /static/{path...}",
+			want:        map[string]string{"path": "css/site main.css"},
+		},
+		{
+			name:        "exact match",
+			pattern:     "GET /{$}",
+			target:      "/",
+			wantPattern: "GET /{$}",
+		},
+		{
+			name:        "host",
+			pattern:     "GET api.example.com/items/{id}",
+			target:      "/items/5",
+			host:        "api.example.com",
+			values:      []string{"id"},
+			wantPattern: "GET api.example.com/items/{id}",
+			want:        map[string]string{"id": "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotPattern string
+				got        = make(map[string]string)
+			)
+
+			mux := newTestMux()
+			mux.HandleFunc(tt.pattern, func(r *http.Request, f *Factory) *response.DataResponse {
+				gotPattern = r.Pattern
+				for _, name := range tt.values {
+					got[name] = r.PathValue(name)
+				}
+
+				return f.Success(r.Context(), nil)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.host != "" {
+				r.Host = tt.host
+			}
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if gotPattern != tt.wantPattern {
+				t.Errorf("r.Pattern = %q, want %q", gotPattern, tt.wantPattern)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("PathValue(%q) = %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestServeMux_Redirect(t *testing.T) {
+	mux := newTestMux()
+	mux.HandleFunc("GET /docs/", routeHandler)
+
+	// The redirect status depends on the Go version, it must be the same as of http.ServeMux
+	std := http.NewServeMux()
+	std.HandleFunc("GET /docs/", func(http.ResponseWriter, *http.Request) {})
+	want := httptest.NewRecorder()
+	std.ServeHTTP(want, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	if w.Code != want.Code {
+		t.Errorf("status = %d, want %d", w.Code, want.Code)
+	}
+	if got := w.Header().Get(response.HeaderLocation); got != "/docs/" {
+		t.Errorf("Location = %q, want %q", got, "/docs/")
+	}
+}
@@ -0,0 +1,108 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+// HandlerTypes describes input and output types of a TypedHandler, it is the route metadata of typed handlers.
+type HandlerTypes struct {
+	In  reflect.Type
+	Out reflect.Type
+}
+
+// TypedHandler adapts the typed function to Handler.
+// In is bound from the request by Bind, Out is returned with Factory.Success. Errors are mapped to responses:
+//
+//	ValidationErrors       422 Factory.ValidationError
+//	*response.Error 4xx    Factory.Error with the error status and message
+//	other errors           Factory.InternalError
+//
+// Example:
+//
+//	type GetUserRequest struct {
+//		ID     int64  `path:"id"`
+//		Fields string `query:"fields"`
+//	}
+//
+//	mux.Handle("GET /users/{id}", dr.TypedHandler(func(ctx context.Context, in GetUserRequest) (User, error) {
+//		return users.Get(ctx, in.ID)
+//	}))
+func TypedHandler[In, Out any](fn func(ctx context.Context, in In) (Out, error)) Handler {
+	return &typedHandler[In, Out]{fn: fn}
+}
+
+type typedHandler[In, Out any] struct {
+	fn func(ctx context.Context, in In) (Out, error)
+}
+
+// Handle implements Handler.
+func (h *typedHandler[In, Out]) Handle(r *http.Request, f *Factory) *response.DataResponse {
+	ctx := r.Context()
+
+	var in In
+	if target, ok := bindTarget(&in); ok {
+		if err := Bind(r, target); err != nil {
+			return ErrorResponse(ctx, f, err)
+		}
+	}
+
+	out, err := h.fn(ctx, in)
+	if err != nil {
+		return ErrorResponse(ctx, f, err)
+	}
+
+	return f.Success(ctx, out)
+}
+
+// RouteMetadata implements RouteMetadataProvider.
+func (h *typedHandler[In, Out]) RouteMetadata() any {
+	return HandlerTypes{
+		In:  reflect.TypeFor[In](),
+		Out: reflect.TypeFor[Out](),
+	}
+}
+
+// bindTarget returns the struct pointer to bind, allocating it if In is a pointer to a struct.
+func bindTarget[In any](in *In) (any, bool) {
+	rv := reflect.ValueOf(in).Elem()
+
+	switch {
+	case rv.Kind() == reflect.Struct:
+		return in, rv.NumField() > 0
+	case rv.Kind() == reflect.Pointer && rv.Type().Elem().Kind() == reflect.Struct:
+		rv.Set(reflect.New(rv.Type().Elem()))
+
+		return rv.Interface(), true
+	default:
+		return nil, false
+	}
+}
+
+// ErrorResponse maps the error to the factory response:
+// ValidationErrors to 422, *response.Error with 4xx status to the error response, others to 500.
+func ErrorResponse(ctx context.Context, f *Factory, err error) *response.DataResponse {
+	var verrs ValidationErrors
+	if errors.As(err, &verrs) {
+		return f.ValidationError(ctx, "", verrs)
+	}
+
+	var respErr *response.Error
+	if errors.As(err, &respErr) && respErr.Code() >= http.StatusBadRequest && respErr.Code() < http.StatusInternalServerError {
+		return f.Error(ctx, respErr.Code(), respErr.Error())
+	}
+
+	return f.InternalError(ctx, err)
+}
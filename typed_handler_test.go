@@ -0,0 +1,200 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+type typedUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type updateUserInput struct {
+	ID     int64  `path:"id"`
+	Name   string `json:"name"`
+	Notify bool   `query:"notify"`
+}
+
+func updateUserFunc(_ context.Context, in updateUserInput) (typedUser, error) {
+	switch in.Name {
+	case "missing":
+		return typedUser{}, response.NewError(http.StatusNotFound, "user not found")
+	case "unavailable":
+		return typedUser{}, response.NewError(http.StatusServiceUnavailable, "storage is unavailable")
+	case "broken":
+		return typedUser{}, errors.New("connection reset")
+	case "invalid":
+		verrs := make(ValidationErrors)
+		verrs.Add("body.name", "is reserved")
+
+		return typedUser{}, verrs
+	}
+
+	return typedUser{ID: in.ID, Name: in.Name}, nil
+}
+
+func TestTypedHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "success",
+			target:     "/users/7",
+			body:       `{"name":"Alice"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":7,"name":"Alice"}`,
+		},
+		{
+			name:       "invalid path value",
+			target:     "/users/abc",
+			body:       `{"name":"Alice"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `{"pointer":"path.id","detail":"must be an integer"}`,
+		},
+		{
+			name:       "invalid query value",
+			target:     "/users/7?notify=sometimes",
+			body:       `{"name":"Alice"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `{"pointer":"query.notify","detail":"must be a boolean"}`,
+		},
+		{
+			name:       "malformed body",
+			target:     "/users/7",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid request body",
+		},
+		{
+			name:       "validation error of the function",
+			target:     "/users/7",
+			body:       `{"name":"invalid"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `{"pointer":"body.name","detail":"is reserved"}`,
+		},
+		{
+			name:       "client error of the function",
+			target:     "/users/7",
+			body:       `{"name":"missing"}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   "user not found",
+		},
+		{
+			name:       "server error of the function",
+			target:     "/users/7",
+			body:       `{"name":"unavailable"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "other error of the function",
+			target:     "/users/7",
+			body:       `{"name":"broken"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	mux := NewServeMux(New(WithFormatter(formatter.NewJSON())))
+	mux.Handle("PUT /users/{id}", TypedHandler(updateUserFunc))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want containing %s", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusInternalServerError &&
+				(strings.Contains(w.Body.String(), "connection reset") || strings.Contains(w.Body.String(), "storage")) {
+				t.Errorf("body = %s, internal error is exposed", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTypedHandler_Inputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  Handler
+		body     string
+		wantBody string
+	}{
+		{
+			name: "pointer input",
+			handler: TypedHandler(func(_ context.Context, in *updateUserInput) (string, error) {
+				return in.Name, nil
+			}),
+			body:     `{"name":"Alice"}`,
+			wantBody: `"Alice"`,
+		},
+		{
+			name: "empty struct input does not read the body",
+			handler: TypedHandler(func(context.Context, struct{}) (string, error) {
+				return "ok", nil
+			}),
+			body:     `not json`,
+			wantBody: `"ok"`,
+		},
+		{
+			name: "non-struct input is not bound",
+			handler: TypedHandler(func(_ context.Context, in string) (string, error) {
+				return "in=" + in, nil
+			}),
+			body:     `"ignored"`,
+			wantBody: `"in="`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WrapHandler(tt.handler, New(WithFormatter(formatter.NewJSON()))).
+				ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTypedHandler_RouteMetadata(t *testing.T) {
+	h := TypedHandler(updateUserFunc)
+
+	p, ok := h.(RouteMetadataProvider)
+	if !ok {
+		t.Fatal("typed handler does not provide route metadata")
+	}
+
+	want := HandlerTypes{In: reflect.TypeFor[updateUserInput](), Out: reflect.TypeFor[typedUser]()}
+	if got := p.RouteMetadata(); got != want {
+		t.Errorf("RouteMetadata() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // required by RFC 6455
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const (
+	webSocketVersion = "13"
+	webSocketGUID    = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	webSocketKeySize = 16
+)
+
+// WebSocketConn is the client connection taken over after the WebSocket handshake.
+// Framing is left to a WebSocket library working on net.Conn, e.g. github.com/gobwas/ws.
+type WebSocketConn struct {
+	net.Conn
+
+	// Subprotocol is the negotiated subprotocol, empty if none.
+	Subprotocol string
+
+	reader *bufio.Reader
+}
+
+// Read reads data the client has sent, including data buffered by the server during the handshake.
+func (c *WebSocketConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// UpgradeOption configures Factory.Upgrade.
+type UpgradeOption func(o *upgradeOptions)
+
+type upgradeOptions struct {
+	checkOrigin  func(r *http.Request) bool
+	subprotocols []string
+}
+
+// WithAllowedOrigins allows cross-origin upgrades from the origins, e.g. "https://example.com", "*" allows any.
+// By default only requests without Origin header or from the same host are allowed.
+func WithAllowedOrigins(origins ...string) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.checkOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get(response.HeaderOrigin)
+			if origin == "" || slices.Contains(origins, "*") {
+				return true
+			}
+
+			for _, allowed := range origins {
+				if strings.EqualFold(allowed, origin) {
+					return true
+				}
+			}
+
+			return sameOrigin(r)
+		}
+	}
+}
+
+// WithOriginCheck sets the function allowing the upgrade request, e.g. by Origin header.
+func WithOriginCheck(checkOrigin func(r *http.Request) bool) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.checkOrigin = checkOrigin
+	}
+}
+
+// WithSubprotocols sets the subprotocols supported by the server,
+// the first one requested by the client is selected.
+func WithSubprotocols(subprotocols ...string) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.subprotocols = subprotocols
+	}
+}
+
+// Upgrade creates a response upgrading the connection to the WebSocket protocol (RFC 6455).
+// Once the response is written, the handler takes over the connection, it is closed after the handler returns.
+// The response bypasses formatters and body middlewares such as compression.
+// Invalid upgrade requests get 400, 426 for unsupported versions and 403 for disallowed origins.
+func (f *Factory) Upgrade(r *http.Request, handler func(conn *WebSocketConn), opts ...UpgradeOption) *response.DataResponse {
+	o := &upgradeOptions{checkOrigin: sameOrigin}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx := r.Context()
+
+	switch {
+	case r.ProtoMajor != 1:
+		return f.BadRequest(ctx, "WebSocket upgrade requires HTTP/1.1")
+	case r.Method != http.MethodGet:
+		return f.BadRequest(ctx, "WebSocket upgrade requires GET method")
+	case !headerContainsToken(r.Header, response.HeaderConnection, "upgrade"):
+		return f.BadRequest(ctx, "Connection header must contain upgrade token")
+	case !headerContainsToken(r.Header, response.HeaderUpgrade, "websocket"):
+		return f.BadRequest(ctx, "Upgrade header must contain websocket token")
+	case r.Header.Get(response.HeaderSecWebSocketVersion) != webSocketVersion:
+		return f.Error(ctx, http.StatusUpgradeRequired, "Unsupported WebSocket version").
+			SetHeader(response.HeaderSecWebSocketVersion, webSocketVersion)
+	}
+
+	key := r.Header.Get(response.HeaderSecWebSocketKey)
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != webSocketKeySize {
+		return f.BadRequest(ctx, "Sec-WebSocket-Key header is invalid")
+	}
+
+	if !o.checkOrigin(r) {
+		return f.Forbidden(ctx, "Origin is not allowed")
+	}
+
+	subprotocol := selectSubprotocol(r, o.subprotocols)
+
+	resp := response.NewDataResponse(http.StatusSwitchingProtocols, nil).
+		SetHeader(response.HeaderUpgrade, "websocket").
+		SetHeader(response.HeaderConnection, "Upgrade").
+		SetHeader(response.HeaderSecWebSocketAccept, webSocketAccept(key))
+
+	if subprotocol != "" {
+		resp.SetHeader(response.HeaderSecWebSocketProtocol, subprotocol)
+	}
+
+	return resp.WithStream(func(w http.ResponseWriter) error {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if err := writeSwitchingProtocols(rw.Writer, w.Header()); err != nil {
+			return err
+		}
+
+		handler(&WebSocketConn{Conn: conn, Subprotocol: subprotocol, reader: rw.Reader})
+
+		return nil
+	})
+}
+
+// writeSwitchingProtocols writes the handshake response on the hijacked connection.
+func writeSwitchingProtocols(w *bufio.Writer, header http.Header) error {
+	header = header.Clone()
+	for _, key := range []string{
+		response.HeaderContentType,
+		response.HeaderContentLength,
+		response.HeaderTransferEncoding,
+	} {
+		header.Del(key)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	if err := header.Write(&buf); err != nil {
+		return err
+	}
+	buf.WriteString("\r\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+func webSocketAccept(key string) string {
+	h := sha1.New() //nolint:gosec // required by RFC 6455
+	h.Write([]byte(key + webSocketGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	for _, value := range r.Header.Values(response.HeaderSecWebSocketProtocol) {
+		for _, protocol := range strings.Split(value, ",") {
+			if protocol = strings.TrimSpace(protocol); slices.Contains(supported, protocol) {
+				return protocol
+			}
+		}
+	}
+
+	return ""
+}
+
+// sameOrigin allows requests without Origin header (non-browser clients) or from the requested host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get(response.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func headerContainsToken(header http.Header, key, token string) bool {
+	for _, value := range header.Values(key) {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
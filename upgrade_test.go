@@ -0,0 +1,363 @@
+/**
+ * This file is part of the raoptimus/data-response.go library
+ *
+ * @copyright Copyright (c) Evgeniy Urvantsev
+ * @license https://github.com/raoptimus/data-response.go/blob/master/LICENSE.md
+ * @link https://github.com/raoptimus/data-response.go
+ */
+
+package dataresponse
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raoptimus/data-response.go/v2/formatter"
+	"github.com/raoptimus/data-response.go/v2/response"
+)
+
+const testWebSocketKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// upgradeHeader returns the headers of a valid upgrade request.
+func upgradeHeader() map[string]string {
+	return map[string]string{
+		response.HeaderConnection:          "keep-alive, Upgrade",
+		response.HeaderUpgrade:             "websocket",
+		response.HeaderSecWebSocketVersion: "13",
+		response.HeaderSecWebSocketKey:     testWebSocketKey,
+	}
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	// Example of RFC 6455, section 1.3
+	if got := webSocketAccept(testWebSocketKey); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("webSocketAccept() = %q, want %q", got, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+	}
+}
+
+func TestFactory_Upgrade_Rejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		proto      int
+		header     map[string]string
+		opts       []UpgradeOption
+		wantStatus int
+		wantTitle  string
+		wantHeader map[string]string
+	}{
+		{
+			name:       "HTTP/2",
+			proto:      2,
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "WebSocket upgrade requires HTTP/1.1",
+		},
+		{
+			name:       "POST",
+			method:     http.MethodPost,
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "WebSocket upgrade requires GET method",
+		},
+		{
+			name:       "no connection upgrade token",
+			header:     map[string]string{response.HeaderConnection: "keep-alive"},
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "Connection header must contain upgrade token",
+		},
+		{
+			name:       "no websocket upgrade token",
+			header:     map[string]string{response.HeaderUpgrade: "h2c"},
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "Upgrade header must contain websocket token",
+		},
+		{
+			name:       "unsupported version",
+			header:     map[string]string{response.HeaderSecWebSocketVersion: "8"},
+			wantStatus: http.StatusUpgradeRequired,
+			wantTitle:  "Unsupported WebSocket version",
+			wantHeader: map[string]string{response.HeaderSecWebSocketVersion: "13"},
+		},
+		{
+			name:       "missing key",
+			header:     map[string]string{response.HeaderSecWebSocketKey: ""},
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "Sec-WebSocket-Key header is invalid",
+		},
+		{
+			name:       "key is not base64",
+			header:     map[string]string{response.HeaderSecWebSocketKey: "not a key!"},
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "Sec-WebSocket-Key header is invalid",
+		},
+		{
+			name:       "key is not 16 bytes",
+			header:     map[string]string{response.HeaderSecWebSocketKey: "c2hvcnQ="},
+			wantStatus: http.StatusBadRequest,
+			wantTitle:  "Sec-WebSocket-Key header is invalid",
+		},
+		{
+			name:       "cross origin",
+			header:     map[string]string{response.HeaderOrigin: "https://evil.example"},
+			wantStatus: http.StatusForbidden,
+			wantTitle:  "Origin is not allowed",
+		},
+		{
+			name:       "origin is not allowed",
+			header:     map[string]string{response.HeaderOrigin: "https://evil.example"},
+			opts:       []UpgradeOption{WithAllowedOrigins("https://app.example")},
+			wantStatus: http.StatusForbidden,
+			wantTitle:  "Origin is not allowed",
+		},
+		{
+			name:       "origin check",
+			opts:       []UpgradeOption{WithOriginCheck(func(*http.Request) bool { return false })},
+			wantStatus: http.StatusForbidden,
+			wantTitle:  "Origin is not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			r := httptest.NewRequest(method, "http://api.example/ws", nil)
+			if tt.proto != 0 {
+				r.ProtoMajor = tt.proto
+			}
+			for name, value := range upgradeHeader() {
+				r.Header.Set(name, value)
+			}
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+
+			called := false
+			h := WrapHandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+				return f.Upgrade(r, func(*WebSocketConn) { called = true }, tt.opts...)
+			}, New(WithFormatter(formatter.NewJSON())))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if want := `"title":"` + tt.wantTitle + `"`; !strings.Contains(w.Body.String(), want) {
+				t.Errorf("body = %s, want containing %s", w.Body.String(), want)
+			}
+			for name, want := range tt.wantHeader {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if w.Header().Get(response.HeaderSecWebSocketAccept) != "" {
+				t.Error("Sec-WebSocket-Accept is set on a rejected upgrade")
+			}
+			if called {
+				t.Error("handler is called on a rejected upgrade")
+			}
+		})
+	}
+}
+
+func TestWithAllowedOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    bool
+	}{
+		{name: "no origin", origins: []string{"https://app.example"}, want: true},
+		{name: "allowed", origins: []string{"https://app.example"}, origin: "https://app.example", want: true},
+		{name: "case insensitive", origins: []string{"https://app.example"}, origin: "https://APP.example", want: true},
+		{name: "same host", origins: []string{"https://app.example"}, origin: "https://api.example", want: true},
+		{name: "other origin", origins: []string{"https://app.example"}, origin: "https://evil.example"},
+		{name: "other scheme", origins: []string{"https://app.example"}, origin: "http://app.example"},
+		{name: "allowed host as subdomain", origins: []string{"https://app.example"}, origin: "https://app.example.evil"},
+		{name: "any", origins: []string{"*"}, origin: "https://evil.example", want: true},
+		{name: "null origin", origins: []string{"https://app.example"}, origin: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &upgradeOptions{}
+			WithAllowedOrigins(tt.origins...)(o)
+
+			r := httptest.NewRequest(http.MethodGet, "http://api.example/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set(response.HeaderOrigin, tt.origin)
+			}
+
+			if got := o.checkOrigin(r); got != tt.want {
+				t.Errorf("checkOrigin() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   bool
+	}{
+		{name: "no origin", host: "api.example", want: true},
+		{name: "same host", host: "api.example", origin: "https://api.example", want: true},
+		{name: "same host with port", host: "api.example:8080", origin: "http://api.example:8080", want: true},
+		{name: "other port", host: "api.example:8080", origin: "http://api.example:9090"},
+		{name: "other host", host: "api.example", origin: "https://evil.example"},
+		{name: "host suffix", host: "api.example", origin: "https://api.example.evil"},
+		{name: "null", host: "api.example", origin: "null"},
+		{name: "malformed", host: "api.example", origin: "http://%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			r.Host = tt.host
+			if tt.origin != "" {
+				r.Header.Set(response.HeaderOrigin, tt.origin)
+			}
+
+			if got := sameOrigin(r); got != tt.want {
+				t.Errorf("sameOrigin() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectSubprotocol(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		supported []string
+		want      string
+	}{
+		{name: "none requested", supported: []string{"chat"}},
+		{name: "none supported", requested: []string{"chat"}},
+		{name: "single", requested: []string{"chat"}, supported: []string{"chat"}, want: "chat"},
+		{name: "client preference", requested: []string{"v2.chat, v1.chat"}, supported: []string{"v1.chat", "v2.chat"}, want: "v2.chat"},
+		{name: "repeated headers", requested: []string{"mqtt", "v1.chat"}, supported: []string{"v1.chat"}, want: "v1.chat"},
+		{name: "unsupported", requested: []string{"mqtt"}, supported: []string{"chat"}},
+		{name: "case sensitive", requested: []string{"Chat"}, supported: []string{"chat"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			for _, value := range tt.requested {
+				r.Header.Add(response.HeaderSecWebSocketProtocol, value)
+			}
+
+			if got := selectSubprotocol(r, tt.supported); got != tt.want {
+				t.Errorf("selectSubprotocol() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFactory_Upgrade(t *testing.T) {
+	tests := []struct {
+		name            string
+		protocol        string
+		wantSubprotocol string
+	}{
+		{name: "without subprotocol"},
+		{name: "with subprotocol", protocol: "v2.chat, v1.chat", wantSubprotocol: "v2.chat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subprotocols := make(chan string, 1)
+
+			// contentMiddleware sets body headers the handshake must not carry
+			contentMiddleware := func(next Handler) Handler {
+				return HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+					return next.Handle(r, f).
+						SetHeader("X-Middleware", "applied").
+						SetHeader(response.HeaderContentType, response.ContentTypeJSON)
+				})
+			}
+
+			srv := httptest.NewServer(WrapHandler(Chain(HandlerFunc(func(r *http.Request, f *Factory) *response.DataResponse {
+				return f.Upgrade(r, func(conn *WebSocketConn) {
+					subprotocols <- conn.Subprotocol
+
+					// Echo lines until the client closes the connection
+					reader := bufio.NewReader(conn)
+					for {
+						line, err := reader.ReadString('\n')
+						if err != nil {
+							return
+						}
+						if _, err := io.WriteString(conn, line); err != nil {
+							return
+						}
+					}
+				}, WithSubprotocols("v1.chat", "v2.chat"))
+			}), contentMiddleware), New(WithFormatter(formatter.NewJSON()))))
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			request := "GET /ws HTTP/1.1\r\nHost: " + srv.Listener.Addr().String() + "\r\n" +
+				"Connection: Upgrade\r\nUpgrade: websocket\r\n" +
+				"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: " + testWebSocketKey + "\r\n"
+			if tt.protocol != "" {
+				request += "Sec-WebSocket-Protocol: " + tt.protocol + "\r\n"
+			}
+			// The first message is sent along with the handshake, it must not be lost in server buffers
+			_, _ = conn.Write([]byte(request + "\r\nearly\n"))
+
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, nil)
+			if err != nil {
+				t.Fatalf("read handshake: %v", err)
+			}
+
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+			}
+			wantHeader := map[string]string{
+				response.HeaderUpgrade:              "websocket",
+				response.HeaderConnection:           "Upgrade",
+				response.HeaderSecWebSocketAccept:   "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+				response.HeaderSecWebSocketProtocol: tt.wantSubprotocol,
+				response.HeaderContentType:          "",
+				"X-Middleware":                      "applied",
+			}
+			for name, want := range wantHeader {
+				if got := resp.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := <-subprotocols; got != tt.wantSubprotocol {
+				t.Errorf("conn.Subprotocol = %q, want %q", got, tt.wantSubprotocol)
+			}
+
+			for _, message := range []string{"early\n", "ping\n"} {
+				if message == "ping\n" {
+					_, _ = conn.Write([]byte(message))
+				}
+				if line, err := reader.ReadString('\n'); err != nil || line != message {
+					t.Errorf("echo = %q, %v, want %q", line, err, message)
+				}
+			}
+		})
+	}
+}
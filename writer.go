@@ -21,12 +21,26 @@ import (
 func Write(w http.ResponseWriter, resp *response.DataResponse) error {
 	defer resp.Close()
 
+	if resp.IsStream() {
+		return writeStream(w, resp)
+	}
+
 	formattedResp, err := resp.Body()
 	if err != nil {
 		return err
 	}
 
+	// Boundary must match the body even if a formatter was set later, e.g. by content negotiation
+	if m := resp.Multipart(); m != nil {
+		resp.SetHeader(response.HeaderContentType, m.ContentType())
+	}
+
 	headers := w.Header()
+	if !bodyAllowedForStatus(resp.StatusCode()) {
+		formattedResp = response.FormattedResponse{}
+		resp.Header().Del(response.HeaderContentType)
+	}
+
 	if formattedResp.StreamSize > 0 {
 		headers.Add(response.HeaderContentLength, strconv.FormatInt(formattedResp.StreamSize, 10))
 	}
@@ -46,7 +60,7 @@ func Write(w http.ResponseWriter, resp *response.DataResponse) error {
 	// Write status code
 	w.WriteHeader(resp.StatusCode())
 
-	// Stream data
+	// Stream data, io.ReaderFrom of the writer copies files by sendfile
 	if formattedResp.StreamSize > 0 {
 		_, err = io.CopyN(w, formattedResp.Stream, formattedResp.StreamSize)
 
@@ -59,3 +73,41 @@ func Write(w http.ResponseWriter, resp *response.DataResponse) error {
 
 	return err
 }
+
+// writeStream writes the status and headers, then lets the stream write the body.
+// Status 101 is left to the stream taking over the connection.
+func writeStream(w http.ResponseWriter, resp *response.DataResponse) error {
+	headers := w.Header()
+	for key, values := range resp.Header() {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+
+	if resp.StatusCode() == http.StatusSwitchingProtocols {
+		return resp.Stream()(w)
+	}
+
+	w.WriteHeader(resp.StatusCode())
+
+	if !bodyAllowedForStatus(resp.StatusCode()) {
+		return nil
+	}
+
+	return resp.Stream()(w)
+}
+
+// bodyAllowedForStatus reports whether a given response status code permits a body.
+// See RFC 7230, section 3.3.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}